@@ -0,0 +1,670 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: orderddl.proto
+
+// orderddl のスキーマ並び替えを gRPC で提供するサービス定義
+
+package orderddlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 並び替えのオプション（CLI の同じ名前のフラグに対応し、指定しない項目は既定値）
+type Options struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// NOT VALID / NOT ENFORCED の外部キーの扱い（order, warn, ignore）
+	SoftConstraints string `protobuf:"bytes,1,opt,name=soft_constraints,json=softConstraints,proto3" json:"soft_constraints,omitempty"`
+	// 方言（mysql, postgres など、空の場合は方言に固有の構文を解釈しない）
+	Dialect string `protobuf:"bytes,2,opt,name=dialect,proto3" json:"dialect,omitempty"`
+	// 修飾されていないテーブル名を探すスキーマ
+	SearchPath []string `protobuf:"bytes,3,rep,name=search_path,json=searchPath,proto3" json:"search_path,omitempty"`
+	// CREATE VIEW の扱い（skip, order, passthrough）
+	Views string `protobuf:"bytes,4,opt,name=views,proto3" json:"views,omitempty"`
+	// 一時テーブルの文の扱い（keep, exclude）
+	Temporary     string `protobuf:"bytes,5,opt,name=temporary,proto3" json:"temporary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Options) Reset() {
+	*x = Options{}
+	mi := &file_orderddl_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Options) ProtoMessage() {}
+
+func (x *Options) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Options.ProtoReflect.Descriptor instead.
+func (*Options) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Options) GetSoftConstraints() string {
+	if x != nil {
+		return x.SoftConstraints
+	}
+	return ""
+}
+
+func (x *Options) GetDialect() string {
+	if x != nil {
+		return x.Dialect
+	}
+	return ""
+}
+
+func (x *Options) GetSearchPath() []string {
+	if x != nil {
+		return x.SearchPath
+	}
+	return nil
+}
+
+func (x *Options) GetViews() string {
+	if x != nil {
+		return x.Views
+	}
+	return ""
+}
+
+func (x *Options) GetTemporary() string {
+	if x != nil {
+		return x.Temporary
+	}
+	return ""
+}
+
+type OrderSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sql           string                 `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Options       *Options               `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderSchemaRequest) Reset() {
+	*x = OrderSchemaRequest{}
+	mi := &file_orderddl_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderSchemaRequest) ProtoMessage() {}
+
+func (x *OrderSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderSchemaRequest.ProtoReflect.Descriptor instead.
+func (*OrderSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OrderSchemaRequest) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *OrderSchemaRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type OrderSchemaResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 並び替えたDDL
+	Sql string `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	// テーブルの作成順序
+	Tables        []string `protobuf:"bytes,2,rep,name=tables,proto3" json:"tables,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderSchemaResponse) Reset() {
+	*x = OrderSchemaResponse{}
+	mi := &file_orderddl_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderSchemaResponse) ProtoMessage() {}
+
+func (x *OrderSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderSchemaResponse.ProtoReflect.Descriptor instead.
+func (*OrderSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OrderSchemaResponse) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *OrderSchemaResponse) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+type GetGraphRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sql           string                 `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Options       *Options               `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGraphRequest) Reset() {
+	*x = GetGraphRequest{}
+	mi := &file_orderddl_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGraphRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGraphRequest) ProtoMessage() {}
+
+func (x *GetGraphRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGraphRequest.ProtoReflect.Descriptor instead.
+func (*GetGraphRequest) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetGraphRequest) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *GetGraphRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type GetGraphResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 入力に現れた順のテーブル
+	Tables        []string `protobuf:"bytes,1,rep,name=tables,proto3" json:"tables,omitempty"`
+	Edges         []*Edge  `protobuf:"bytes,2,rep,name=edges,proto3" json:"edges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGraphResponse) Reset() {
+	*x = GetGraphResponse{}
+	mi := &file_orderddl_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGraphResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGraphResponse) ProtoMessage() {}
+
+func (x *GetGraphResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGraphResponse.ProtoReflect.Descriptor instead.
+func (*GetGraphResponse) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetGraphResponse) GetTables() []string {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *GetGraphResponse) GetEdges() []*Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+// 外部キーによる依存関係（child が parent を参照する）
+type Edge struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Child  string                 `protobuf:"bytes,1,opt,name=child,proto3" json:"child,omitempty"`
+	Parent string                 `protobuf:"bytes,2,opt,name=parent,proto3" json:"parent,omitempty"`
+	// 制約名（CONSTRAINT で指定された場合）
+	Constraint string `protobuf:"bytes,3,opt,name=constraint,proto3" json:"constraint,omitempty"`
+	// 参照元のカラム
+	Columns []string `protobuf:"bytes,4,rep,name=columns,proto3" json:"columns,omitempty"`
+	// 参照先のカラム
+	RefColumns []string `protobuf:"bytes,5,rep,name=ref_columns,json=refColumns,proto3" json:"ref_columns,omitempty"`
+	// 参照動作（ON DELETE / ON UPDATE、指定がない場合は空）
+	OnDelete string `protobuf:"bytes,6,opt,name=on_delete,json=onDelete,proto3" json:"on_delete,omitempty"`
+	OnUpdate string `protobuf:"bytes,7,opt,name=on_update,json=onUpdate,proto3" json:"on_update,omitempty"`
+	// NOT VALID / NOT ENFORCED が指定された制約
+	NotValid    bool `protobuf:"varint,8,opt,name=not_valid,json=notValid,proto3" json:"not_valid,omitempty"`
+	NotEnforced bool `protobuf:"varint,9,opt,name=not_enforced,json=notEnforced,proto3" json:"not_enforced,omitempty"`
+	// DEFERRABLE / INITIALLY DEFERRED の指定
+	Deferrable        bool `protobuf:"varint,10,opt,name=deferrable,proto3" json:"deferrable,omitempty"`
+	InitiallyDeferred bool `protobuf:"varint,11,opt,name=initially_deferred,json=initiallyDeferred,proto3" json:"initially_deferred,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Edge) Reset() {
+	*x = Edge{}
+	mi := &file_orderddl_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Edge) ProtoMessage() {}
+
+func (x *Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Edge.ProtoReflect.Descriptor instead.
+func (*Edge) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Edge) GetChild() string {
+	if x != nil {
+		return x.Child
+	}
+	return ""
+}
+
+func (x *Edge) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
+func (x *Edge) GetConstraint() string {
+	if x != nil {
+		return x.Constraint
+	}
+	return ""
+}
+
+func (x *Edge) GetColumns() []string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *Edge) GetRefColumns() []string {
+	if x != nil {
+		return x.RefColumns
+	}
+	return nil
+}
+
+func (x *Edge) GetOnDelete() string {
+	if x != nil {
+		return x.OnDelete
+	}
+	return ""
+}
+
+func (x *Edge) GetOnUpdate() string {
+	if x != nil {
+		return x.OnUpdate
+	}
+	return ""
+}
+
+func (x *Edge) GetNotValid() bool {
+	if x != nil {
+		return x.NotValid
+	}
+	return false
+}
+
+func (x *Edge) GetNotEnforced() bool {
+	if x != nil {
+		return x.NotEnforced
+	}
+	return false
+}
+
+func (x *Edge) GetDeferrable() bool {
+	if x != nil {
+		return x.Deferrable
+	}
+	return false
+}
+
+func (x *Edge) GetInitiallyDeferred() bool {
+	if x != nil {
+		return x.InitiallyDeferred
+	}
+	return false
+}
+
+type ValidateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sql           string                 `protobuf:"bytes,1,opt,name=sql,proto3" json:"sql,omitempty"`
+	Options       *Options               `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateRequest) Reset() {
+	*x = ValidateRequest{}
+	mi := &file_orderddl_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateRequest) ProtoMessage() {}
+
+func (x *ValidateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateRequest.ProtoReflect.Descriptor instead.
+func (*ValidateRequest) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValidateRequest) GetSql() string {
+	if x != nil {
+		return x.Sql
+	}
+	return ""
+}
+
+func (x *ValidateRequest) GetOptions() *Options {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type ValidateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Errors        []string               `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateResponse) Reset() {
+	*x = ValidateResponse{}
+	mi := &file_orderddl_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateResponse) ProtoMessage() {}
+
+func (x *ValidateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderddl_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateResponse.ProtoReflect.Descriptor instead.
+func (*ValidateResponse) Descriptor() ([]byte, []int) {
+	return file_orderddl_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ValidateResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+var File_orderddl_proto protoreflect.FileDescriptor
+
+const file_orderddl_proto_rawDesc = "" +
+	"\n" +
+	"\x0eorderddl.proto\x12\vorderddl.v1\"\xa3\x01\n" +
+	"\aOptions\x12)\n" +
+	"\x10soft_constraints\x18\x01 \x01(\tR\x0fsoftConstraints\x12\x18\n" +
+	"\adialect\x18\x02 \x01(\tR\adialect\x12\x1f\n" +
+	"\vsearch_path\x18\x03 \x03(\tR\n" +
+	"searchPath\x12\x14\n" +
+	"\x05views\x18\x04 \x01(\tR\x05views\x12\x1c\n" +
+	"\ttemporary\x18\x05 \x01(\tR\ttemporary\"V\n" +
+	"\x12OrderSchemaRequest\x12\x10\n" +
+	"\x03sql\x18\x01 \x01(\tR\x03sql\x12.\n" +
+	"\aoptions\x18\x02 \x01(\v2\x14.orderddl.v1.OptionsR\aoptions\"?\n" +
+	"\x13OrderSchemaResponse\x12\x10\n" +
+	"\x03sql\x18\x01 \x01(\tR\x03sql\x12\x16\n" +
+	"\x06tables\x18\x02 \x03(\tR\x06tables\"S\n" +
+	"\x0fGetGraphRequest\x12\x10\n" +
+	"\x03sql\x18\x01 \x01(\tR\x03sql\x12.\n" +
+	"\aoptions\x18\x02 \x01(\v2\x14.orderddl.v1.OptionsR\aoptions\"S\n" +
+	"\x10GetGraphResponse\x12\x16\n" +
+	"\x06tables\x18\x01 \x03(\tR\x06tables\x12'\n" +
+	"\x05edges\x18\x02 \x03(\v2\x11.orderddl.v1.EdgeR\x05edges\"\xd8\x02\n" +
+	"\x04Edge\x12\x14\n" +
+	"\x05child\x18\x01 \x01(\tR\x05child\x12\x16\n" +
+	"\x06parent\x18\x02 \x01(\tR\x06parent\x12\x1e\n" +
+	"\n" +
+	"constraint\x18\x03 \x01(\tR\n" +
+	"constraint\x12\x18\n" +
+	"\acolumns\x18\x04 \x03(\tR\acolumns\x12\x1f\n" +
+	"\vref_columns\x18\x05 \x03(\tR\n" +
+	"refColumns\x12\x1b\n" +
+	"\ton_delete\x18\x06 \x01(\tR\bonDelete\x12\x1b\n" +
+	"\ton_update\x18\a \x01(\tR\bonUpdate\x12\x1b\n" +
+	"\tnot_valid\x18\b \x01(\bR\bnotValid\x12!\n" +
+	"\fnot_enforced\x18\t \x01(\bR\vnotEnforced\x12\x1e\n" +
+	"\n" +
+	"deferrable\x18\n" +
+	" \x01(\bR\n" +
+	"deferrable\x12-\n" +
+	"\x12initially_deferred\x18\v \x01(\bR\x11initiallyDeferred\"S\n" +
+	"\x0fValidateRequest\x12\x10\n" +
+	"\x03sql\x18\x01 \x01(\tR\x03sql\x12.\n" +
+	"\aoptions\x18\x02 \x01(\v2\x14.orderddl.v1.OptionsR\aoptions\"@\n" +
+	"\x10ValidateResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
+	"\x06errors\x18\x02 \x03(\tR\x06errors2\xee\x01\n" +
+	"\bOrderDDL\x12P\n" +
+	"\vOrderSchema\x12\x1f.orderddl.v1.OrderSchemaRequest\x1a .orderddl.v1.OrderSchemaResponse\x12G\n" +
+	"\bGetGraph\x12\x1c.orderddl.v1.GetGraphRequest\x1a\x1d.orderddl.v1.GetGraphResponse\x12G\n" +
+	"\bValidate\x12\x1c.orderddl.v1.ValidateRequest\x1a\x1d.orderddl.v1.ValidateResponseB.Z,github.com/ba58ajbse/orderddl/api/orderddlpbb\x06proto3"
+
+var (
+	file_orderddl_proto_rawDescOnce sync.Once
+	file_orderddl_proto_rawDescData []byte
+)
+
+func file_orderddl_proto_rawDescGZIP() []byte {
+	file_orderddl_proto_rawDescOnce.Do(func() {
+		file_orderddl_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_orderddl_proto_rawDesc), len(file_orderddl_proto_rawDesc)))
+	})
+	return file_orderddl_proto_rawDescData
+}
+
+var file_orderddl_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_orderddl_proto_goTypes = []any{
+	(*Options)(nil),             // 0: orderddl.v1.Options
+	(*OrderSchemaRequest)(nil),  // 1: orderddl.v1.OrderSchemaRequest
+	(*OrderSchemaResponse)(nil), // 2: orderddl.v1.OrderSchemaResponse
+	(*GetGraphRequest)(nil),     // 3: orderddl.v1.GetGraphRequest
+	(*GetGraphResponse)(nil),    // 4: orderddl.v1.GetGraphResponse
+	(*Edge)(nil),                // 5: orderddl.v1.Edge
+	(*ValidateRequest)(nil),     // 6: orderddl.v1.ValidateRequest
+	(*ValidateResponse)(nil),    // 7: orderddl.v1.ValidateResponse
+}
+var file_orderddl_proto_depIdxs = []int32{
+	0, // 0: orderddl.v1.OrderSchemaRequest.options:type_name -> orderddl.v1.Options
+	0, // 1: orderddl.v1.GetGraphRequest.options:type_name -> orderddl.v1.Options
+	5, // 2: orderddl.v1.GetGraphResponse.edges:type_name -> orderddl.v1.Edge
+	0, // 3: orderddl.v1.ValidateRequest.options:type_name -> orderddl.v1.Options
+	1, // 4: orderddl.v1.OrderDDL.OrderSchema:input_type -> orderddl.v1.OrderSchemaRequest
+	3, // 5: orderddl.v1.OrderDDL.GetGraph:input_type -> orderddl.v1.GetGraphRequest
+	6, // 6: orderddl.v1.OrderDDL.Validate:input_type -> orderddl.v1.ValidateRequest
+	2, // 7: orderddl.v1.OrderDDL.OrderSchema:output_type -> orderddl.v1.OrderSchemaResponse
+	4, // 8: orderddl.v1.OrderDDL.GetGraph:output_type -> orderddl.v1.GetGraphResponse
+	7, // 9: orderddl.v1.OrderDDL.Validate:output_type -> orderddl.v1.ValidateResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_orderddl_proto_init() }
+func file_orderddl_proto_init() {
+	if File_orderddl_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orderddl_proto_rawDesc), len(file_orderddl_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_orderddl_proto_goTypes,
+		DependencyIndexes: file_orderddl_proto_depIdxs,
+		MessageInfos:      file_orderddl_proto_msgTypes,
+	}.Build()
+	File_orderddl_proto = out.File
+	file_orderddl_proto_goTypes = nil
+	file_orderddl_proto_depIdxs = nil
+}
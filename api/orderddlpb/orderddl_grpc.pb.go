@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: orderddl.proto
+
+// orderddl のスキーマ並び替えを gRPC で提供するサービス定義
+
+package orderddlpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OrderDDL_OrderSchema_FullMethodName = "/orderddl.v1.OrderDDL/OrderSchema"
+	OrderDDL_GetGraph_FullMethodName    = "/orderddl.v1.OrderDDL/GetGraph"
+	OrderDDL_Validate_FullMethodName    = "/orderddl.v1.OrderDDL/Validate"
+)
+
+// OrderDDLClient is the client API for OrderDDL service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OrderDDLClient interface {
+	// DDLを外部キーの依存関係の順に並び替える
+	OrderSchema(ctx context.Context, in *OrderSchemaRequest, opts ...grpc.CallOption) (*OrderSchemaResponse, error)
+	// DDLから解析したテーブルと依存関係を返す
+	GetGraph(ctx context.Context, in *GetGraphRequest, opts ...grpc.CallOption) (*GetGraphResponse, error)
+	// DDLを並び替えられるかどうかを検査する
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+}
+
+type orderDDLClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderDDLClient(cc grpc.ClientConnInterface) OrderDDLClient {
+	return &orderDDLClient{cc}
+}
+
+func (c *orderDDLClient) OrderSchema(ctx context.Context, in *OrderSchemaRequest, opts ...grpc.CallOption) (*OrderSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OrderSchemaResponse)
+	err := c.cc.Invoke(ctx, OrderDDL_OrderSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderDDLClient) GetGraph(ctx context.Context, in *GetGraphRequest, opts ...grpc.CallOption) (*GetGraphResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGraphResponse)
+	err := c.cc.Invoke(ctx, OrderDDL_GetGraph_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderDDLClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, OrderDDL_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OrderDDLServer is the server API for OrderDDL service.
+// All implementations must embed UnimplementedOrderDDLServer
+// for forward compatibility.
+type OrderDDLServer interface {
+	// DDLを外部キーの依存関係の順に並び替える
+	OrderSchema(context.Context, *OrderSchemaRequest) (*OrderSchemaResponse, error)
+	// DDLから解析したテーブルと依存関係を返す
+	GetGraph(context.Context, *GetGraphRequest) (*GetGraphResponse, error)
+	// DDLを並び替えられるかどうかを検査する
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	mustEmbedUnimplementedOrderDDLServer()
+}
+
+// UnimplementedOrderDDLServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderDDLServer struct{}
+
+func (UnimplementedOrderDDLServer) OrderSchema(context.Context, *OrderSchemaRequest) (*OrderSchemaResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method OrderSchema not implemented")
+}
+func (UnimplementedOrderDDLServer) GetGraph(context.Context, *GetGraphRequest) (*GetGraphResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetGraph not implemented")
+}
+func (UnimplementedOrderDDLServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedOrderDDLServer) mustEmbedUnimplementedOrderDDLServer() {}
+func (UnimplementedOrderDDLServer) testEmbeddedByValue()                  {}
+
+// UnsafeOrderDDLServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderDDLServer will
+// result in compilation errors.
+type UnsafeOrderDDLServer interface {
+	mustEmbedUnimplementedOrderDDLServer()
+}
+
+func RegisterOrderDDLServer(s grpc.ServiceRegistrar, srv OrderDDLServer) {
+	// If the following call panics, it indicates UnimplementedOrderDDLServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderDDL_ServiceDesc, srv)
+}
+
+func _OrderDDL_OrderSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderDDLServer).OrderSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderDDL_OrderSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderDDLServer).OrderSchema(ctx, req.(*OrderSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderDDL_GetGraph_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGraphRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderDDLServer).GetGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderDDL_GetGraph_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderDDLServer).GetGraph(ctx, req.(*GetGraphRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderDDL_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderDDLServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderDDL_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderDDLServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OrderDDL_ServiceDesc is the grpc.ServiceDesc for OrderDDL service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderDDL_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderddl.v1.OrderDDL",
+	HandlerType: (*OrderDDLServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "OrderSchema",
+			Handler:    _OrderDDL_OrderSchema_Handler,
+		},
+		{
+			MethodName: "GetGraph",
+			Handler:    _OrderDDL_GetGraph_Handler,
+		},
+		{
+			MethodName: "Validate",
+			Handler:    _OrderDDL_Validate_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orderddl.proto",
+}
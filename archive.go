@@ -0,0 +1,240 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// アーカイブ内の .sql ファイル
+type archiveEntry struct {
+	name string
+	data []byte
+	src  string
+	enc  textEncoding
+	// エントリで定義されたテーブル
+	tables []string
+}
+
+// 並び順を書き出すマニフェストのファイル名
+const archiveManifest = "order.txt"
+
+// アーカイブかどうかを拡張子で判定する
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// アーカイブから .sql ファイルを名前順に読み込む
+func readArchive(archivePath string) ([]archiveEntry, error) {
+	files := make(map[string][]byte)
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("アーカイブを開けませんでした: %w", err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || !strings.HasSuffix(strings.ToLower(f.Name), ".sql") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("アーカイブを読み込めませんでした: %w", err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("アーカイブを読み込めませんでした: %w", err)
+			}
+			files[f.Name] = data
+		}
+	} else {
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("アーカイブを開けませんでした: %w", err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("アーカイブを読み込めませんでした: %w", err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("アーカイブを読み込めませんでした: %w", err)
+			}
+			if header.Typeflag != tar.TypeReg || !strings.HasSuffix(strings.ToLower(header.Name), ".sql") {
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("アーカイブを読み込めませんでした: %w", err)
+			}
+			files[header.Name] = data
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []archiveEntry
+	for _, name := range names {
+		src, enc, err := decodeInput(files[name], *inputEnc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, archiveEntry{name: name, data: files[name], src: src, enc: enc})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("アーカイブに .sql ファイルがありません: %s", archivePath)
+	}
+	return entries, nil
+}
+
+// エントリごとに定義されたテーブルを、並び替えと同じ opts（方言・search_path・ビューの扱いなど）で取り出す
+func setEntryTables(entries []archiveEntry, opts ddl.Options) error {
+	for i := range entries {
+		tables, _, err := ddl.Edges(strings.NewReader(entries[i].src), opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entries[i].name, err)
+		}
+		entries[i].tables = tables
+	}
+	return nil
+}
+
+// アーカイブにはエントリを入力のまま書き出すため、文を書き換えるフラグのうち指定されたものを返す（指定がない場合は空）
+func archiveRewriteFlag() string {
+	switch {
+	case len(renames) > 0:
+		return "-rename"
+	case *renameFile != "":
+		return "-rename-file"
+	case *prefix != "":
+		return "-prefix"
+	case *suffix != "":
+		return "-suffix"
+	case *inlineFKs:
+		return "-inline-fks"
+	case *separateFKs != "":
+		return "-separate-fks"
+	}
+	return ""
+}
+
+// すべてのエントリをつなげて1つのDDLにする
+func joinEntries(entries []archiveEntry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.src)
+		if !strings.HasSuffix(entry.src, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// テーブルの依存関係からエントリの適用順を決める
+func orderEntries(entries []archiveEntry, graph map[string][]string) ([]string, error) {
+	owner := make(map[string]string)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.name)
+		for _, table := range entry.tables {
+			owner[table] = entry.name
+		}
+	}
+
+	entryGraph := make(map[string][]string)
+	for parent, children := range graph {
+		for _, child := range children {
+			from, to := owner[parent], owner[child]
+			if from == "" || to == "" || from == to || slices.Contains(entryGraph[from], to) {
+				continue
+			}
+			entryGraph[from] = append(entryGraph[from], to)
+		}
+	}
+
+	return ddl.StableSort(names, entryGraph)
+}
+
+// エントリを適用順に並べ、マニフェストを付けてアーカイブに書き出す
+func writeArchive(archivePath string, entries []archiveEntry, order []string) error {
+	byName := make(map[string]archiveEntry)
+	for _, entry := range entries {
+		byName[entry.name] = entry
+	}
+	manifest := strings.Join(order, "\n") + "\n"
+
+	var buf bytes.Buffer
+	if strings.HasSuffix(strings.ToLower(archivePath), ".zip") {
+		zw := zip.NewWriter(&buf)
+		add := func(name string, content []byte) error {
+			w, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(content)
+			return err
+		}
+		if err := add(archiveManifest, []byte(manifest)); err != nil {
+			return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+		}
+		for _, name := range order {
+			if err := add(name, byName[name].data); err != nil {
+				return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+		}
+	} else {
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		add := func(name string, content []byte) error {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+				return err
+			}
+			_, err := tw.Write(content)
+			return err
+		}
+		if err := add(archiveManifest, []byte(manifest)); err != nil {
+			return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+		}
+		for _, name := range order {
+			if err := add(name, byName[name].data); err != nil {
+				return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("アーカイブを作成できませんでした: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Printf("✅ 適用順のマニフェスト (%s) を付けてアーカイブを出力しました: %s\n", archiveManifest, archivePath)
+	return nil
+}
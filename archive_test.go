@@ -0,0 +1,196 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// テスト用のアーカイブを作成する
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	if strings.HasSuffix(path, ".zip") {
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(files[name])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, name := range names {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(files[name])); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// アーカイブ内のファイルを名前順に読み込む
+func readTestArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	if strings.HasSuffix(path, ".zip") {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[f.Name] = string(data)
+		}
+		return files
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(data)
+	}
+	return files
+}
+
+func TestArchive(t *testing.T) {
+	files := map[string]string{
+		"01_orders.sql": "CREATE TABLE orders (id int PRIMARY KEY, user_id int REFERENCES users(id));\n",
+		"02_users.sql":  "CREATE TABLE users (id int PRIMARY KEY);\n",
+		"README.md":     "not sql\n",
+	}
+	for _, ext := range []string{".zip", ".tar.gz"} {
+		t.Run(ext, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "in"+ext)
+			output := filepath.Join(dir, "out"+ext)
+			writeTestArchive(t, input, files)
+
+			if err := processSQL(context.Background(), input, output); err != nil {
+				t.Fatal(err)
+			}
+			got := readTestArchive(t, output)
+			if want := "02_users.sql\n01_orders.sql\n"; got[archiveManifest] != want {
+				t.Errorf("マニフェスト = %q, want %q", got[archiveManifest], want)
+			}
+			for _, name := range []string{"01_orders.sql", "02_users.sql"} {
+				if got[name] != files[name] {
+					t.Errorf("%s = %q, want %q", name, got[name], files[name])
+				}
+			}
+			if _, ok := got["README.md"]; ok {
+				t.Error(".sql 以外のファイルがアーカイブに含まれています")
+			}
+
+			// 通常のファイルにはつなげたDDLを並べて出力する
+			merged := filepath.Join(dir, "merged.sql")
+			if err := processSQL(context.Background(), input, merged); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(merged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if users, orders := strings.Index(string(data), "CREATE TABLE users"), strings.Index(string(data), "CREATE TABLE orders"); users < 0 || orders < users {
+				t.Errorf("users が orders より先に出力されていません:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestOrderEntriesCycle(t *testing.T) {
+	entries := []archiveEntry{
+		{name: "a.sql", tables: []string{"a"}},
+		{name: "b.sql", tables: []string{"b"}},
+	}
+	graph := map[string][]string{"a": {"b"}, "b": {"a"}}
+	if _, err := orderEntries(entries, graph); err == nil {
+		t.Error("ファイル間の循環でエラーになりませんでした")
+	}
+}
+
+func TestArchiveOptions(t *testing.T) {
+	// processSQL が設定する dialect も、後のテストに残らないよう戻す
+	t.Cleanup(func() { *dialectName, *prefix, dialect = "", "", nil })
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.zip")
+	output := filepath.Join(dir, "out.zip")
+	writeTestArchive(t, input, map[string]string{
+		"01_orders.sql": "CREATE TABLE orders (id int, user_id int REFERENCES users(id));\n",
+		"02_users.sql":  "CREATE TRANSIENT TABLE users (id int PRIMARY KEY);\n",
+	})
+
+	// 方言に固有の書き方で定義したテーブルも、ファイルの適用順に反映する
+	*dialectName = "snowflake"
+	if err := processSQL(context.Background(), input, output); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readTestArchive(t, output)[archiveManifest], "02_users.sql\n01_orders.sql\n"; got != want {
+		t.Errorf("マニフェスト = %q, want %q", got, want)
+	}
+
+	// エントリは入力のまま書き出すため、文を書き換えるフラグは断る
+	*prefix = "p_"
+	if err := processSQL(context.Background(), input, output); err == nil || !strings.Contains(err.Error(), "-prefix") {
+		t.Errorf("err = %v, want -prefix のエラー", err)
+	}
+}
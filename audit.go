@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -audit で書き出す、入力に対して行った判断の記録
+type auditLog struct {
+	Version   string `json:"version"`
+	Input     string `json:"input"`
+	InputHash string `json:"input_hash"`
+	Output    string `json:"output"`
+	// 明示的に指定したオプション
+	Options map[string]string `json:"options"`
+	// 入力に現れた順のテーブルと、出力した作成順序
+	Tables []string `json:"tables"`
+	Sorted []string `json:"sorted"`
+	// 依存関係で順序が決まらないテーブルの並べ方
+	TieBreak string `json:"tie_break"`
+	// 作成順序を決めた依存関係
+	Dependencies []auditEdge `json:"dependencies"`
+	// 重複していたため1つにまとめた外部キー
+	Duplicates []ddl.ForeignKey `json:"duplicates"`
+	// -ignore-dep の指定に従って除いた依存関係
+	Ignored []auditEdge `json:"ignored"`
+	// 循環依存を解消するために作成順序の判断から除いた依存関係
+	CycleBreaks []auditCycleBreak `json:"cycle_breaks"`
+	// 入力の位置から動かしたテーブル
+	Moves []ddl.Relocation `json:"moves"`
+	// 入力を書き換えた内容（テーブル名の変更、外部キーのコメント化・移動・別ファイルへの分離・CREATE TABLE への移動、拡張の追加）
+	Rewrites []auditRewrite `json:"rewrites"`
+	// 出力に含めなかった入力の文
+	Dropped []auditStatement `json:"dropped"`
+}
+
+type auditEdge struct {
+	Child  string `json:"child"`
+	Parent string `json:"parent"`
+	// foreign_key, hint（orderddl:after / orderddl:before）, extra（-extra-dep）, object（INHERITS・ビュー・型などの参照）
+	Kind       string          `json:"kind"`
+	ForeignKey *ddl.ForeignKey `json:"foreign_key,omitempty"`
+}
+
+type auditCycleBreak struct {
+	// deferred（DEFERRABLE INITIALLY DEFERRED）, resolved（端末で選択）, commented（-cycles comment）, fixed（-fix）
+	Action string `json:"action"`
+	auditEdge
+}
+
+type auditRewrite struct {
+	// rename, comment_out_foreign_key, move_foreign_key, separate_foreign_keys, inline_foreign_key, add_extension
+	Action string `json:"action"`
+	Table  string `json:"table,omitempty"`
+	Detail string `json:"detail"`
+}
+
+type auditStatement struct {
+	Line int    `json:"line"`
+	SQL  string `json:"sql"`
+}
+
+// processSQL で記録している判断（-audit を指定しない場合は nil）
+var audit *auditLog
+
+// 入力ごとに記録を始める
+func startAudit(input, output string) {
+	audit = nil
+	if *auditOut == "" {
+		return
+	}
+	audit = &auditLog{Version: toolVersion(), Input: input, Output: output, Options: make(map[string]string)}
+	flag.Visit(func(f *flag.Flag) {
+		audit.Options[f.Name] = f.Value.String()
+	})
+}
+
+// 入力を書き換えた内容を記録する
+func (a *auditLog) rewrite(action, table, detail string) {
+	if a != nil {
+		a.Rewrites = append(a.Rewrites, auditRewrite{Action: action, Table: table, Detail: detail})
+	}
+}
+
+func newAuditEdge(edge ddl.Edge) auditEdge {
+	e := auditEdge{Child: edge.Child, Parent: edge.Parent}
+	switch {
+	case edge.Hint:
+		e.Kind = "hint"
+	case edge.Extra:
+		e.Kind = "extra"
+	case edge.ForeignKey.Table != "":
+		e.Kind = "foreign_key"
+		fk := edge.ForeignKey
+		e.ForeignKey = &fk
+	default:
+		e.Kind = "object"
+	}
+	return e
+}
+
+func auditEdges(edges []ddl.Edge) []auditEdge {
+	list := make([]auditEdge, 0, len(edges))
+	for _, edge := range edges {
+		list = append(list, newAuditEdge(edge))
+	}
+	return list
+}
+
+// 解析の結果と出力から除いた文を加えて、記録を -audit の出力先に書き出す
+func writeAudit(ctx context.Context, src string, result *ddl.Result, sortedTables []string) error {
+	if audit == nil {
+		return nil
+	}
+	audit.InputHash = inputHash
+	audit.Tables = result.Tables
+	audit.Sorted = sortedTables
+	audit.TieBreak = *tieBreak
+	audit.Dependencies = auditEdges(result.Edges)
+	audit.Duplicates = append([]ddl.ForeignKey{}, result.Duplicates...)
+	audit.Ignored = auditEdges(result.Ignored)
+	audit.CycleBreaks = []auditCycleBreak{}
+	for _, breaks := range []struct {
+		action string
+		edges  []ddl.Edge
+	}{
+		{"deferred", result.Deferred},
+		{"resolved", result.Resolved},
+		{"commented", result.Commented},
+		{"fixed", result.Fixed},
+	} {
+		for _, edge := range breaks.edges {
+			audit.CycleBreaks = append(audit.CycleBreaks, auditCycleBreak{Action: breaks.action, auditEdge: newAuditEdge(edge)})
+		}
+	}
+	audit.Moves = append([]ddl.Relocation{}, ddl.Relocations(result.Tables, sortedTables, result.Graph)...)
+	if audit.Rewrites == nil {
+		audit.Rewrites = []auditRewrite{}
+	}
+
+	dropped, err := ddl.DroppedStatements(ctx, src, splitOptions())
+	if err != nil {
+		return err
+	}
+	audit.Dropped = []auditStatement{}
+	for _, stmt := range dropped {
+		// 前置きのコメントは含めない
+		leading := len(stmt.Code) - len(strings.TrimLeft(stmt.Code, " \t\r\n"))
+		body := ddl.Statement{Text: stmt.Text[leading:], Code: stmt.Code[leading:], Terminator: stmt.Terminator}
+		audit.Dropped = append(audit.Dropped, auditStatement{Line: executedLine(stmt), SQL: body.SQL()})
+	}
+	sort.SliceStable(audit.Dropped, func(i, j int) bool { return audit.Dropped[i].Line < audit.Dropped[j].Line })
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*auditOut, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ 判断の記録をJSONで出力しました:", *auditOut)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// baseline サブコマンドを実行する
+func runBaseline(args []string) error {
+	fs := flag.NewFlagSet("baseline", flag.ExitOnError)
+	out := fs.String("o", "baseline.sql", "出力先")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("使い方: orderddl baseline [-o baseline.sql] <migrations_dir>")
+	}
+
+	// ファイル名の順（タイムスタンプ順）に適用する。ロールバック用の .down.sql は除く
+	paths, err := filepath.Glob(filepath.Join(fs.Arg(0), "*.sql"))
+	if err != nil {
+		return fmt.Errorf("マイグレーションを探せませんでした: %w", err)
+	}
+	sort.Strings(paths)
+
+	var migrations []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".down.sql") {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("ファイルを開けませんでした: %w", err)
+		}
+		migrations = append(migrations, string(content))
+	}
+	if len(migrations) == 0 {
+		return fmt.Errorf("マイグレーションが見つかりません: %s", fs.Arg(0))
+	}
+
+	baseline, skipped, err := ddl.Baseline(migrations, ddl.Options{})
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		fmt.Printf("ℹ️ テーブル定義に関係しない %d 個の文は含めませんでした\n", skipped)
+	}
+	if err := os.WriteFile(*out, []byte(baseline), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Printf("✅ %d 個のマイグレーションからベースラインを出力しました: %s\n", len(migrations), *out)
+	return nil
+}
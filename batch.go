@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -check で並び順が正しくないファイルがあった場合のエラー（メッセージはファイルごとに表示済み）
+var errNeedsReorder = errors.New("並び順が正しくないファイルがあります")
+
+// 並び替えの結果を一時ファイルに書き出している間は、出力先を表示しない
+var quietOutput bool
+
+// -files-from で指定したファイル（- の場合は標準入力）から1行に1つのパスを読む（空行は除く）
+func readFileList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("ファイルの一覧を開けませんでした: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ファイルの一覧を読み込めませんでした: %w", err)
+	}
+	return paths, nil
+}
+
+// -check / -w でファイルを1つずつ並び替える。
+// write が false の場合は並び順が正しくないファイルを表示し、1つでもあれば errNeedsReorder を返す。
+// write が true の場合は並び順が正しくないファイルだけを書き換える
+func reorderFiles(ctx context.Context, paths []string, write bool) error {
+	if *format != "sql" || *schemaDir != "" || *schemaOut != "" || *dataOut != "" || *compDir != "" {
+		return errors.New("-check と -w は -format sql で、-schema-dir・-schema-out・-data-out・-component-dir を指定しない場合だけ使えます")
+	}
+	// 出力先が1つのフラグは、ファイルごとの結果で上書きされてしまう
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"-drop-out", *dropOut != ""},
+		{"-plan-format", *planFormat != ""},
+		{"-source-map", *sourceMap != ""},
+		{"-separate-fks", *separateFKs != ""},
+		{"-audit", *auditOut != ""},
+		{"-report", *reportOut != ""},
+	} {
+		if f.set {
+			return fmt.Errorf("-check と -w では %s を指定できません（ファイルごとの出力で上書きされます）", f.name)
+		}
+	}
+
+	cache := openOrderCache()
+	unordered := 0
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// git diff --name-only には削除したファイルも含まれる
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			fmt.Println("ℹ️ ファイルがないためスキップしました:", path)
+			continue
+		}
+		changed, err := reorderFile(ctx, path, write, cache)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		switch {
+		case !changed:
+			fmt.Println("✅ 並び順は正しいです:", path)
+		case write:
+			fmt.Println("✅ 正しい順序に書き換えました:", path)
+		default:
+			fmt.Println("❌ 並び順が正しくありません:", path)
+			unordered++
+		}
+	}
+
+	if unordered > 0 {
+		fmt.Printf("❌ %d個のファイルの並び順が正しくありません（-w で書き換えられます）\n", unordered)
+		return errNeedsReorder
+	}
+	return nil
+}
+
+// ファイルを並び替えた結果が元の内容と異なるかどうかを返す（write の場合は異なるときだけ書き換える）。
+// cache に同じ内容の前回の結果がある場合は並び替えずに、前回の判定と並び替えた内容を使う
+func reorderFile(ctx context.Context, path string, write bool, cache *orderCache) (bool, error) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	if isSQLiteDatabase(original) {
+		return false, errors.New("SQLite のデータベースファイルは -check と -w で並び替えられません")
+	}
+	ordered, reordered, found := cache.lookup(original)
+	if found && (ordered || !write) {
+		return !ordered, nil
+	}
+
+	// 元のファイルと同じディレクトリに、拡張子を保った一時ファイルを作る（書き換える場合はリネームで置き換える）
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".orderddl-*-"+filepath.Base(path))
+	if err != nil {
+		return false, fmt.Errorf("一時ファイルを作成できませんでした: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if found {
+		if err := os.WriteFile(tmp.Name(), reordered, 0o644); err != nil {
+			return false, fmt.Errorf("一時ファイルに書き込めませんでした: %w", err)
+		}
+	} else {
+		quietOutput = true
+		err = processSQL(ctx, path, tmp.Name())
+		quietOutput = false
+		if err != nil {
+			return false, err
+		}
+		if reordered, err = os.ReadFile(tmp.Name()); err != nil {
+			return false, fmt.Errorf("一時ファイルを読み込めませんでした: %w", err)
+		}
+		// -header のコメントは入力のハッシュが変わるため比べない
+		if bytes.Equal(original, reordered) || (*header && ddl.StripManifest(string(original)) == ddl.StripManifest(string(reordered))) {
+			cache.store(original, nil)
+			return false, nil
+		}
+		cache.store(original, reordered)
+		if !write {
+			return true, nil
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, fmt.Errorf("ファイルを書き換えられませんでした: %w", err)
+	}
+	cache.store(reordered, nil)
+	return true, nil
+}
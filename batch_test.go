@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReorderFiles(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	ordered := filepath.Join(dir, "ordered.sql")
+	unordered := filepath.Join(dir, "unordered.sql")
+	if err := os.WriteFile(ordered, []byte("CREATE TABLE a (id int PRIMARY KEY);\nCREATE TABLE b (a_id int REFERENCES a(id));\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := "CREATE TABLE b (a_id int REFERENCES a(id));\nCREATE TABLE a (id int PRIMARY KEY);\n"
+	if err := os.WriteFile(unordered, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{ordered, unordered, filepath.Join(dir, "deleted.sql")}
+
+	if err := reorderFiles(context.Background(), paths, false); !errors.Is(err, errNeedsReorder) {
+		t.Fatalf("-check の err = %v, want errNeedsReorder", err)
+	}
+	if err := reorderFiles(context.Background(), paths, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := reorderFiles(context.Background(), paths, false); err != nil {
+		t.Errorf("書き換えた後の -check の err = %v", err)
+	}
+
+	// -w はキャッシュにある並び替えた内容を解析し直さずに書き込む
+	cached := []byte("-- cached\n")
+	openOrderCache().store([]byte(src), cached)
+	if err := os.WriteFile(unordered, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reorderFiles(context.Background(), paths, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(unordered); string(got) != string(cached) {
+		t.Errorf("書き換えた内容 = %q, want %q", got, cached)
+	}
+
+	// 出力先が1つのフラグはファイルごとの出力で上書きされるため断る
+	*dropOut = filepath.Join(dir, "drop.sql")
+	t.Cleanup(func() { *dropOut = "" })
+	if err := reorderFiles(context.Background(), paths, false); err == nil || !strings.Contains(err.Error(), "-drop-out") {
+		t.Errorf("err = %v, want -drop-out のエラー", err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// -check / -w で並び替えた結果のキャッシュ。
+// 内容・オプション・ビルドが同じファイルは、前回の並び順の判定と並び替えた内容を使って解析を省く
+type orderCache struct {
+	dir string
+	// オプションと、オプションで指定したファイルの内容から作ったキーの接頭辞
+	options string
+}
+
+// キーに含めないオプション（並び替えの結果を変えないもの）
+var uncachedFlags = map[string]bool{"files-from": true, "check": true, "w": true, "no-cache": true, "no-progress": true, "stats": true}
+
+// キャッシュのディレクトリ（$XDG_CACHE_HOME/orderddl など）を使うキャッシュを返す。
+// -no-cache の場合、キャッシュのディレクトリがない場合と、結果が外部のコマンドによる -plugin の場合は nil を返す
+func openOrderCache() *orderCache {
+	if *noCache || *pluginCmd != "" {
+		return nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+
+	var options strings.Builder
+	options.WriteString(buildKey())
+	flag.Visit(func(f *flag.Flag) {
+		if uncachedFlags[f.Name] {
+			return
+		}
+		options.WriteString(f.Name + "=" + f.Value.String() + "\n")
+	})
+	// 設定ファイルなどは名前が同じでも内容が変わることがあるため、内容もキーに含める
+	for _, path := range optionFiles() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		options.WriteString(path + ":" + checksumOf(content) + "\n")
+	}
+	return &orderCache{dir: filepath.Join(base, "orderddl"), options: options.String()}
+}
+
+// 内容のキャッシュのファイル
+func (c *orderCache) path(content []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.options))
+	hash.Write([]byte{0})
+	hash.Write(content)
+	key := hex.EncodeToString(hash.Sum(nil))
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// 内容を前回並び替えたときに並び順が正しかったかどうかと、並び順が正しくなかった場合は並び替えた内容、
+// 前回の結果があるかどうかを返す
+func (c *orderCache) lookup(content []byte) (ordered bool, reordered []byte, found bool) {
+	if c == nil {
+		return false, nil, false
+	}
+	data, err := os.ReadFile(c.path(content))
+	if err != nil {
+		return false, nil, false
+	}
+	if string(data) == "ordered\n" {
+		return true, nil, true
+	}
+	if rest, ok := bytes.CutPrefix(data, []byte("reordered\n")); ok {
+		return false, rest, true
+	}
+	return false, nil, false
+}
+
+// 内容の並び順が正しいかどうかと、正しくない場合は並び替えた内容を記録する（reordered が nil の場合は正しい）。
+// 書き込めない場合は記録しない
+func (c *orderCache) store(content, reordered []byte) {
+	if c == nil {
+		return
+	}
+	path := c.path(content)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	value := []byte("ordered\n")
+	if reordered != nil {
+		value = append([]byte("reordered\n"), reordered...)
+	}
+	// 同時に実行した場合に途中までの内容を読まないよう、一時ファイルをリネームする
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// キャッシュのキーに含めるビルドの情報。
+// ローカルでビルドしたバイナリはバージョンが (devel) のままになるため、リビジョンを省略せずに含め、
+// リビジョンがない場合や未コミットの変更を含む場合は実行ファイルのパス・サイズ・更新日時も含める
+func buildKey() string {
+	key := toolVersion() + "\n"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return key + executableKey()
+	}
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	key += "revision=" + revision + "\n"
+	if version == "" && (revision == "" || modified) {
+		key += executableKey()
+	}
+	return key
+}
+
+// 実行ファイルのパス・サイズ・更新日時（取得できない場合は空）
+func executableKey() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("executable=%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+}
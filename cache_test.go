@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	cache := openOrderCache()
+	if cache == nil {
+		t.Fatal("キャッシュを開けませんでした")
+	}
+
+	content := []byte("CREATE TABLE a (id int);\n")
+	if _, _, found := cache.lookup(content); found {
+		t.Fatal("記録していない内容の結果が見つかりました")
+	}
+	reordered := []byte("CREATE TABLE a (id int PRIMARY KEY);\n")
+	cache.store(content, reordered)
+	if ordered, got, found := cache.lookup(content); !found || ordered || string(got) != string(reordered) {
+		t.Errorf("lookup = %v, %q, %v, want false, %q, true", ordered, got, found, reordered)
+	}
+	cache.store(content, nil)
+	if ordered, got, found := cache.lookup(content); !found || !ordered || got != nil {
+		t.Errorf("lookup = %v, %q, %v, want true, nil, true", ordered, got, found)
+	}
+	if !strings.Contains(cache.options, "revision=") {
+		t.Errorf("キーにリビジョンが含まれていません: %q", cache.options)
+	}
+
+	// 内容やオプションが違う場合は前回の結果を使わない
+	if _, _, found := cache.lookup([]byte("CREATE TABLE b (id int);\n")); found {
+		t.Error("内容の違うファイルの結果が見つかりました")
+	}
+	other := &orderCache{dir: cache.dir, options: cache.options + "dialect=mysql\n"}
+	if _, _, found := other.lookup(content); found {
+		t.Error("オプションの違う結果が見つかりました")
+	}
+
+	// -no-cache の場合は nil で、nil のキャッシュは何も記録しない
+	*noCache = true
+	t.Cleanup(func() { *noCache = false })
+	if cache := openOrderCache(); cache != nil {
+		t.Error("-no-cache でキャッシュを開きました")
+	}
+	var none *orderCache
+	none.store(content, nil)
+	if _, _, found := none.lookup(content); found {
+		t.Error("nil のキャッシュで結果が見つかりました")
+	}
+}
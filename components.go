@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 依存関係でつながるテーブルのまとまりを表示する
+func reportComponents(components [][]string) {
+	if len(components) == 0 {
+		return
+	}
+	fmt.Printf("📊 依存関係でつながるテーブルのまとまりが%d個あります\n", len(components))
+	for i, tables := range components {
+		fmt.Printf("  %d. %s（%d個）\n", i+1, strings.Join(tables, ", "), len(tables))
+	}
+}
+
+// まとまりごとに、作成順に並べたDDLを dir の 番号_最初のテーブル名.sql に書き出す
+func writeComponentDir(src, dir string, graph map[string][]string, components [][]string, sortedTables []string) error {
+	if fixedConstraints != "" {
+		// 末尾の ALTER TABLE は最後のテーブルのブロックに書くため、別のまとまりのファイルに入ってしまう
+		return errors.New("-component-dir は -fix・-cycles fix で外部キーを移す場合には使えません")
+	}
+	ddlContent, err := splitDDL(src, graph, sortedTables)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("出力ディレクトリを作成できませんでした: %w", err)
+	}
+	for i, tables := range components {
+		path := filepath.Join(dir, fmt.Sprintf("%02d_%s.sql", i+1, tables[0]))
+		if err := writeDDL(path, tables, ddlContent); err != nil {
+			return err
+		}
+		fmt.Println("✅ まとまりごとのDDLを出力しました:", path)
+	}
+	return nil
+}
+
+// -components / -component-dir の指定に従って、まとまりを表示し、ファイルに書き出す
+func outputComponents(src string, result *ddl.Result, sortedTables []string) error {
+	if !*components && *compDir == "" {
+		return nil
+	}
+	list := ddl.Components(sortedTables, result.Graph)
+	reportComponents(list)
+	if *compDir == "" {
+		return nil
+	}
+	return writeComponentDir(src, *compDir, result.Graph, list, sortedTables)
+}
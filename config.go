@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -config で指定する設定ファイル（JSON）
+type config struct {
+	// 組み込みの解析で判断できない文から、オブジェクトの名前と依存関係を取り出す規則
+	Rules []ddl.Rule `json:"rules"`
+	// テーブル名のパターンごとの並び順の重み（参照用のテーブルを先に、大きなテーブルを後に置くなど）
+	Weights []ddl.Weight `json:"weights"`
+}
+
+// 設定ファイルを読み込む（path が空の場合は空の設定）
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		return cfg, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルを読み込めませんでした: %w", err)
+	}
+	if err := json.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("設定ファイルを解析できませんでした: %s: %w", path, err)
+	}
+	if err := ddl.CheckWeights(cfg.Weights); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// -deps-file の CSV から依存関係を読む（指定しない場合は nil）
+func readDependencyFile(path string) ([]ddl.Dependency, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("依存関係のファイルを開けませんでした: %w", err)
+	}
+	defer f.Close()
+	deps, err := ddl.ReadDependencies(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return deps, nil
+}
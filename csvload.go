@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// load-csv サブコマンドを実行する
+func runLoadCSV(args []string) error {
+	fs := flag.NewFlagSet("load-csv", flag.ExitOnError)
+	dir := fs.String("dir", ".", "テーブル名のCSVファイル（users.csv や public.users.csv）を置いたディレクトリ")
+	dialect := fs.String("dialect", "postgres", "読み込みに使う文（postgres: \\copy, mysql: LOAD DATA）")
+	header := fs.Bool("header", true, "CSVの1行目を見出しとして読み飛ばす")
+	out := fs.String("o", "", "出力先（空の場合は標準出力）")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 {
+		return errors.New("使い方: orderddl load-csv -dir <CSVのディレクトリ> [-dialect postgres|mysql] [-o 出力先] <schema.sql>")
+	}
+	if *dialect != "postgres" && *dialect != "mysql" {
+		return fmt.Errorf("不明な dialect の指定です: %s", *dialect)
+	}
+
+	content, err := os.ReadFile(rest[0])
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	result, err := ddl.Analyze(string(content), ddl.Options{})
+	if err != nil {
+		return err
+	}
+	files, unknown, err := csvFiles(*dir, result.Sorted)
+	if err != nil {
+		return err
+	}
+
+	quote, err := ddl.LookupDialect(*dialect)
+	if err != nil {
+		return err
+	}
+	spellings := ddl.TableSpellings(string(content), ddl.Options{Dialect: quote})
+	var b strings.Builder
+	for _, table := range result.Sorted {
+		path, exists := files[table]
+		if !exists {
+			continue
+		}
+		name := spellings.Quote(quote, table)
+		switch *dialect {
+		case "postgres":
+			options := "FORMAT csv"
+			if *header {
+				options += ", HEADER true"
+			}
+			fmt.Fprintf(&b, "\\copy %s FROM %s WITH (%s)\n", name, sqlString(path), options)
+		case "mysql":
+			fmt.Fprintf(&b, "LOAD DATA LOCAL INFILE %s INTO TABLE %s FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' LINES TERMINATED BY '\\n'", sqlString(path), name)
+			if *header {
+				b.WriteString(" IGNORE 1 LINES")
+			}
+			b.WriteString(";\n")
+		}
+	}
+
+	// 対応するテーブルが決まらないCSVは読み込まない
+	for _, path := range unknown {
+		fmt.Fprintln(os.Stderr, "⚠️ 警告: 対応するテーブルがない（または複数のスキーマに同じ名前のテーブルがある）ため読み込みません:", path)
+	}
+
+	if *out == "" {
+		fmt.Print(b.String())
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ CSVを依存関係の順に読み込むスクリプトを出力しました:", *out)
+	return nil
+}
+
+// ディレクトリのCSVファイルをテーブルに対応付ける。
+// ファイル名は修飾されたテーブル名、または他のスキーマと重ならない修飾なしのテーブル名とする。
+// 対応するテーブルがないファイルは別に返す
+func csvFiles(dir string, tables []string) (map[string]string, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ディレクトリを開けませんでした: %w", err)
+	}
+
+	// 小文字にしたファイル名 → テーブル
+	byName := make(map[string]string)
+	unqualified := make(map[string][]string)
+	for _, table := range tables {
+		byName[strings.ToLower(table)] = table
+		name := strings.ToLower(strings.TrimPrefix(table, ddl.SchemaOf(table)+"."))
+		unqualified[name] = append(unqualified[name], table)
+	}
+	for name, list := range unqualified {
+		if _, exists := byName[name]; !exists && len(list) == 1 {
+			byName[name] = list[0]
+		}
+	}
+
+	files := make(map[string]string)
+	var unknown []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".csv") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		table, exists := byName[name]
+		if !exists {
+			unknown = append(unknown, path)
+			continue
+		}
+		files[table] = path
+	}
+	return files, unknown, nil
+}
+
+// SQL の文字列リテラルにする
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// スキーマの文とデータの文を、それぞれ並び替えた順に別のファイルに書き出す
+func writeSchemaAndData(src, schemaPath, dataPath string, graph map[string][]string, sortedTables []string) error {
+	ddlContent, err := splitDDL(src, graph, sortedTables)
+	if err != nil {
+		return err
+	}
+	schema, data := ddl.SplitData(ddlContent)
+
+	if schemaPath != "" {
+		if err := writeDDL(schemaPath, sortedTables, schema); err != nil {
+			return err
+		}
+		fmt.Println("✅ 正しい順序でスキーマを出力しました:", schemaPath)
+	}
+	if dataPath != "" {
+		if err := writeDDL(dataPath, sortedTables, data); err != nil {
+			return err
+		}
+		fmt.Println("✅ 正しい順序でデータを出力しました:", dataPath)
+	}
+	return nil
+}
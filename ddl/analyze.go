@@ -0,0 +1,333 @@
+package ddl
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result は入力を解析し、テーブルの作成順序を決めた結果
+type Result struct {
+	// 入力に現れた順のテーブル
+	Tables []string
+	// 作成順序を決める依存関係
+	Edges []Edge
+	// 重複して定義されていたため依存関係から除いた外部キー
+	Duplicates []ForeignKey
+	// Options.IgnoredDependencies に従って作成順序の判断から除いた依存関係
+	Ignored []Edge
+	// Options.IgnoredDependencies のうち、一致する依存関係がなかったもの
+	UnusedIgnores []Dependency
+	// テーブルが型を使っているが、CREATE EXTENSION が入力にない拡張（AddExtensions で作成する文を加えられる）
+	MissingExtensions []MissingExtension
+	// 入力に定義されたテーブル間の依存関係（親 → 子）
+	Graph map[string][]string
+	// テーブルごとの並び順の指定
+	Hints map[string]Hint
+	// 循環依存を解消するために作成順序の判断から除外した依存関係
+	Deferred []Edge
+	// Options.ResolveCycle で選ばれたため作成順序の判断から除外した依存関係（外部キーは FixForeignKeys で末尾の ALTER TABLE に移せる）
+	Resolved []Edge
+	// Options.Cycles が comment の場合に作成順序の判断から除外した外部キー（CommentOutForeignKeys でコメントにする）
+	Commented []Edge
+	// Options.Cycles が fix の場合に作成順序の判断から除外した、循環に含まれる外部キー（FixForeignKeys で末尾の ALTER TABLE に移す）
+	Fixed []Edge
+	// テーブルの作成順序（循環依存が残る場合は空）
+	Sorted []string
+	// 解消できなかった循環依存
+	Cycles [][]string
+	// Options.MinimalMoves の場合に入力の位置から動かしたテーブル（作成順）
+	Moves []Move
+	// 字句解析で問題があったが、そのまま出力した文
+	Warnings []Warning
+	// 組み込みの解析・方言のどちらでも種類を判断できなかった文と、最初のテーブルより前にあるため出力に含まれない文
+	Unrecognized []Warning
+	// 解析と並び替えにかかった時間などの統計
+	Stats Stats
+}
+
+// Stats は Analyze の統計
+type Stats struct {
+	// 入力を読み込んで依存関係を取り出すのにかかった時間
+	ParseTime time.Duration
+	// 依存関係からテーブルの作成順序を決めるのにかかった時間
+	SortTime time.Duration
+	// 文の数
+	Statements int
+	// 最も大きい文のバイト数（前置きの空白とコメントを含む）と開始行
+	LargestStatement     int
+	LargestStatementLine int
+}
+
+// Analyze は入力を解析してテーブルの作成順序を決める。
+// 循環依存が残る場合は Sorted を空にしたまま Result と ErrCycle を返す
+func Analyze(src string, opts Options) (*Result, error) {
+	return AnalyzeContext(context.Background(), src, opts)
+}
+
+// AnalyzeContext は ctx が取り消された場合に途中で終える Analyze（ctx のエラーを返す）
+func AnalyzeContext(ctx context.Context, src string, opts Options) (*Result, error) {
+	start := time.Now()
+	p, err := parse(ctx, strings.NewReader(src), opts)
+	if err != nil {
+		return nil, err
+	}
+	parsedAt := time.Now()
+
+	result := &Result{
+		Tables:            p.tables,
+		Edges:             p.edges,
+		Duplicates:        p.duplicates,
+		Ignored:           p.ignored,
+		UnusedIgnores:     p.unusedIgnores,
+		MissingExtensions: p.missingExtensions,
+		Graph:             make(map[string][]string),
+		Hints:             p.hints,
+		Warnings:          p.warnings,
+		Unrecognized:      p.unrecognized,
+		Stats: Stats{
+			ParseTime:            parsedAt.Sub(start),
+			Statements:           p.statements,
+			LargestStatement:     p.largest,
+			LargestStatementLine: p.largestLine,
+		},
+	}
+	defer func() { result.Stats.SortTime = time.Since(parsedAt) }()
+
+	// 入力に定義されていないテーブルへの依存と、そのまま作成できる自己参照は作成順序に影響しない
+	defined := make(map[string]bool)
+	inDegree := make(map[string]int)
+	for _, table := range p.tables {
+		defined[table] = true
+		result.Graph[table] = []string{}
+		inDegree[table] = 0
+	}
+	for _, edge := range p.edges {
+		if !defined[edge.Parent] || !defined[edge.Child] || edge.Parent == edge.Child {
+			continue
+		}
+		result.Graph[edge.Parent] = append(result.Graph[edge.Parent], edge.Child)
+		inDegree[edge.Child]++
+	}
+
+	if opts.Cycles == CyclesFix {
+		result.Fixed = breakCycles(result.Graph, inDegree, p.edges)
+	}
+	result.Deferred = BreakDeferrableCycles(result.Graph, inDegree, p.edges)
+	switch {
+	case opts.Cycles == CyclesComment:
+		commented, err := ResolveCycles(result.Graph, inDegree, p.edges, commentableEdge(p.tables))
+		result.Commented = commented
+		if err != nil {
+			result.Cycles = Cycles(result.Graph)
+			return result, err
+		}
+	case opts.ResolveCycle != nil:
+		resolved, err := ResolveCycles(result.Graph, inDegree, p.edges, opts.ResolveCycle)
+		result.Resolved = resolved
+		if err != nil {
+			result.Cycles = Cycles(result.Graph)
+			return result, err
+		}
+	}
+	if cycles := Cycles(result.Graph); len(cycles) > 0 {
+		result.Cycles = cycles
+		return result, ErrCycle
+	}
+
+	if opts.MinimalMoves {
+		result.Sorted, result.Moves = MinimalMoves(p.tables, result.Graph)
+		return result, nil
+	}
+	result.Sorted = sortTables(opts.tieBreakOrder(p.tables), result.Graph, priorities(p.hints), opts.weights(p.tables))
+	return result, nil
+}
+
+// 並び順の指定から優先度を決める（小さいほど先に置く）
+func priorities(hints map[string]Hint) map[string]int {
+	priority := make(map[string]int)
+	for table, hint := range hints {
+		switch {
+		case hint.First:
+			priority[table] = -1
+		case hint.Last:
+			priority[table] = 1
+		}
+	}
+	return priority
+}
+
+// sortTables は作成できるテーブルのうち、優先度が小さく、次に重みが小さく、入力で先に現れたものから順に並べる
+func sortTables(tables []string, graph map[string][]string, priority, weight map[string]int) []string {
+	index := make(map[string]int)
+	inDegree := make(map[string]int)
+	for i, table := range tables {
+		index[table] = i
+		inDegree[table] = 0
+	}
+	for _, children := range graph {
+		for _, child := range children {
+			inDegree[child]++
+		}
+	}
+
+	ready := &tableQueue{index: index, priority: priority, weight: weight}
+	for _, table := range tables {
+		if inDegree[table] == 0 {
+			heap.Push(ready, table)
+		}
+	}
+
+	sorted := make([]string, 0, len(tables))
+	for ready.Len() > 0 {
+		current := heap.Pop(ready).(string)
+		sorted = append(sorted, current)
+		for _, child := range graph[current] {
+			inDegree[child]--
+			if inDegree[child] == 0 {
+				heap.Push(ready, child)
+			}
+		}
+	}
+	return sorted
+}
+
+// 作成できるテーブルの優先度付きキュー
+type tableQueue struct {
+	tables   []string
+	index    map[string]int
+	priority map[string]int
+	weight   map[string]int
+}
+
+func (q *tableQueue) Len() int { return len(q.tables) }
+
+func (q *tableQueue) Less(i, j int) bool {
+	a, b := q.tables[i], q.tables[j]
+	if q.priority[a] != q.priority[b] {
+		return q.priority[a] < q.priority[b]
+	}
+	if q.weight[a] != q.weight[b] {
+		return q.weight[a] < q.weight[b]
+	}
+	return q.index[a] < q.index[b]
+}
+
+func (q *tableQueue) Swap(i, j int) { q.tables[i], q.tables[j] = q.tables[j], q.tables[i] }
+
+func (q *tableQueue) Push(x any) { q.tables = append(q.tables, x.(string)) }
+
+func (q *tableQueue) Pop() any {
+	last := q.tables[len(q.tables)-1]
+	q.tables = q.tables[:len(q.tables)-1]
+	return last
+}
+
+// Orphans は他のテーブルとの外部キーの依存関係を持たないテーブルを入力に現れた順に返す（自己参照と並び順の指定、加えた依存関係は数えない）
+func Orphans(tables []string, edges []Edge) []string {
+	connected := make(map[string]bool)
+	for _, edge := range edges {
+		if edge.Hint || edge.Extra || edge.Parent == edge.Child || !contains(tables, edge.Parent) {
+			continue
+		}
+		connected[edge.Parent] = true
+		connected[edge.Child] = true
+	}
+
+	var orphans []string
+	for _, table := range tables {
+		if !connected[table] {
+			orphans = append(orphans, table)
+		}
+	}
+	return orphans
+}
+
+// Components は依存関係を向きを無視してたどったときにつながるテーブルのまとまりを返す。
+// まとまりの中のテーブルは sortedTables の順で、まとまりは最初のテーブルの位置の順に並べる。
+// 異なるまとまりのテーブルの間には依存関係がないため、まとまりごとに別々に作成できる
+func Components(sortedTables []string, graph map[string][]string) [][]string {
+	index := make(map[string]int, len(sortedTables))
+	for i, table := range sortedTables {
+		index[table] = i
+	}
+	// 互いにつながるテーブルを同じ代表にまとめる
+	parent := make([]int, len(sortedTables))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for from, children := range graph {
+		i, exists := index[from]
+		if !exists {
+			continue
+		}
+		for _, child := range children {
+			if j, exists := index[child]; exists {
+				a, b := find(i), find(j)
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	var components [][]string
+	positions := make(map[int]int)
+	for i, table := range sortedTables {
+		root := find(i)
+		position, exists := positions[root]
+		if !exists {
+			position = len(components)
+			positions[root] = position
+			components = append(components, nil)
+		}
+		components[position] = append(components[position], table)
+	}
+	return components
+}
+
+// Levels はテーブルごとの段数（依存先をたどった最長の段数、依存先がなければ 0）を返す
+func Levels(sortedTables []string, graph map[string][]string) map[string]int {
+	levels := make(map[string]int)
+	for _, table := range sortedTables {
+		for _, child := range graph[table] {
+			if child != table {
+				levels[child] = max(levels[child], levels[table]+1)
+			}
+		}
+	}
+	return levels
+}
+
+// Dependencies はテーブルごとの直接の依存先を名前順に返す（自己参照は含めない）
+func Dependencies(graph map[string][]string) map[string][]string {
+	deps := make(map[string][]string)
+	for parent, children := range graph {
+		for _, child := range children {
+			if child != parent && !contains(deps[child], parent) {
+				deps[child] = append(deps[child], parent)
+			}
+		}
+	}
+	for _, parents := range deps {
+		sort.Strings(parents)
+	}
+	return deps
+}
+
+// StableSort は nodes を graph（親 → 子）の依存関係の順に並べる。
+// 同時に置けるものは nodes での順を保ち、循環依存がある場合は ErrCycle を返す
+func StableSort(nodes []string, graph map[string][]string) ([]string, error) {
+	sorted := sortTables(nodes, graph, nil, nil)
+	if len(sorted) != len(nodes) {
+		return nil, ErrCycle
+	}
+	return sorted, nil
+}
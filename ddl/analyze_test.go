@@ -0,0 +1,18 @@
+package ddl
+
+import "testing"
+
+func BenchmarkAnalyze(b *testing.B) {
+	src := benchmarkSchema(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for range b.N {
+		result, err := Analyze(src, Options{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(result.Sorted) != 1000 {
+			b.Fatalf("並び替えたテーブルの数が %d です", len(result.Sorted))
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package ddl
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	reDropTable   = regexp.MustCompile(`(?is)^\s*DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.*?)\s*(?:\bCASCADE\b|\bRESTRICT\b)?\s*;?\s*$`)
+	reRenameTo    = regexp.MustCompile(`(?is)\bRENAME\s+TO\s+` + QUALIFIED_NAME_PATTERN)
+	reRenameTable = regexp.MustCompile(`(?is)^\s*RENAME\s+TABLE\s+` + QUALIFIED_NAME_PATTERN + `\s+TO\s+` + QUALIFIED_NAME_PATTERN)
+	reDropIndex   = regexp.MustCompile(`(?is)^\s*DROP\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?` + QUALIFIED_NAME_PATTERN + `(?:\s+ON\s+` + QUALIFIED_NAME_PATTERN + `)?`)
+
+	// ALTER TABLE の項目で追加する制約・インデックスとカラム
+	reAddKeyItem    = regexp.MustCompile(`(?is)^\s*ADD\s+(?:CONSTRAINT|(?:UNIQUE\s+|FULLTEXT\s+|SPATIAL\s+)?(?:INDEX|KEY))\s+(?:IF\s+NOT\s+EXISTS\s+)?` + IDENTIFIER_PATTERN)
+	reAddColumnItem = regexp.MustCompile(`(?is)^\s*ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + IDENTIFIER_PATTERN)
+	// ALTER TABLE の項目で削除する制約・インデックスとカラム
+	reDropKeyItem    = regexp.MustCompile(`(?is)^\s*DROP\s+(?:CONSTRAINT|FOREIGN\s+KEY|CHECK|INDEX|KEY)\s+(?:IF\s+EXISTS\s+)?` + IDENTIFIER_PATTERN)
+	reDropColumnItem = regexp.MustCompile(`(?is)^\s*DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?` + IDENTIFIER_PATTERN)
+	// ALTER TABLE の項目で変更するカラム
+	reModifyColumnItem = regexp.MustCompile(`(?is)^\s*(?:ALTER|MODIFY|CHANGE)\s+(?:COLUMN\s+)?` + IDENTIFIER_PATTERN)
+	// ALTER TABLE の項目で追加する外部キーの表制約
+	reAddForeignKeyItem = regexp.MustCompile(`(?is)^\s*ADD\s+(?:CONSTRAINT\s+` + IDENTIFIER_PATTERN + `\s+)?FOREIGN\s+KEY\b`)
+	// 列制約の REFERENCES（参照動作などの指定を含む）
+	reColumnReferences = regexp.MustCompile(`(?is)\s*(?:\bCONSTRAINT\s+` + IDENTIFIER_PATTERN + `\s+)?\bREFERENCES\s+` + QUALIFIED_NAME_PATTERN + `\s*(?:\([^()]*\))?(?:\s+(?:ON\s+(?:DELETE|UPDATE)\s+(?:CASCADE|RESTRICT|NO\s+ACTION|SET\s+NULL|SET\s+DEFAULT)|MATCH\s+\w+|(?:NOT\s+)?DEFERRABLE|INITIALLY\s+(?:DEFERRED|IMMEDIATE)))*`)
+)
+
+// ADD / DROP の後ろでカラム名ではなく制約やインデックスを表す語
+var alterItemKeywords = map[string]bool{
+	"constraint": true, "primary": true, "unique": true, "index": true, "key": true, "foreign": true,
+	"check": true, "fulltext": true, "spatial": true, "partition": true, "column": true,
+}
+
+// Baseline はマイグレーションを順に適用した結果のテーブル定義を、依存関係の順に並べたDDLとして返す。
+// 各テーブルは CREATE TABLE と、その後の ALTER TABLE・CREATE INDEX で構成し、名前の変更は変更後の名前で作成するように書き換える。
+// 後のマイグレーションで削除したカラム・制約・インデックスを追加した ALTER TABLE の項目や CREATE INDEX と、
+// 削除したテーブルを参照する ALTER TABLE の項目は含めない。テーブル名は大文字と小文字を区別せずに比べる。
+// テーブルに関係しない文は含めず、その数を skipped として返す
+func Baseline(migrations []string, opts Options) (baseline string, skipped int, err error) {
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+
+	var tables []string
+	statements := make(map[string][]Statement)
+	// 定義したテーブルの中で name と同じもの（ない場合は name）
+	find := func(name string) string {
+		for _, table := range tables {
+			if strings.EqualFold(table, name) {
+				return table
+			}
+		}
+		return name
+	}
+	drop := func(table string) {
+		table = find(table)
+		delete(statements, table)
+		tables = removeOne(tables, table)
+		// 削除したテーブルを参照する外部キーは、CASCADE と同じように取り除く（列制約はカラムを残して REFERENCES だけを取り除く）
+		references := func(item string) bool {
+			matches := reReferences.FindStringSubmatch(item)
+			return matches != nil && strings.EqualFold(qualifiedName(matches), table)
+		}
+		for other, list := range statements {
+			list = removeAlterItems(list, func(item string) bool {
+				return reAddForeignKeyItem.MatchString(item) && references(item)
+			})
+			for i, stmt := range list {
+				items := alterItems(stmt.Code)
+				for j := len(items) - 1; j >= 0; j-- {
+					item := items[j]
+					for _, loc := range slices.Backward(reColumnReferences.FindAllStringSubmatchIndex(stmt.Code[item[0]:item[1]], -1)) {
+						if start, end := item[0]+loc[0], item[0]+loc[1]; references(stmt.Code[start:end]) {
+							stmt.Text = stmt.Text[:start] + stmt.Text[end:]
+							stmt.Code = stmt.Code[:start] + stmt.Code[end:]
+						}
+					}
+				}
+				list[i] = stmt
+			}
+			statements[other] = list
+		}
+	}
+
+	for _, migration := range migrations {
+		scanner := NewStatementScanner(strings.NewReader(migration))
+		for scanner.Scan() {
+			stmt := scanner.Statement()
+			code := stmt.Code
+			if strings.TrimSpace(code) == "" {
+				continue
+			}
+			if !strings.HasSuffix(stmt.Text, "\n") {
+				stmt.Text += "\n"
+				stmt.Code += "\n"
+			}
+
+			switch {
+			case reCreateTable.MatchString(code):
+				// 作り直された場合はそれまでの定義を置き換える
+				table := qualifiedName(reCreateTable.FindStringSubmatch(code))
+				if _, exists := statements[find(table)]; exists {
+					drop(table)
+				}
+				tables = append(tables, table)
+				statements[table] = []Statement{stmt}
+
+			case reAlterTable.MatchString(code):
+				table := find(qualifiedName(reAlterTable.FindStringSubmatch(code)))
+				if _, exists := statements[table]; !exists {
+					skipped++
+					continue
+				}
+				// 名前の変更は文を残さず、それまでの文を新しい名前に書き換える
+				if matches := reRenameTo.FindStringSubmatch(code); matches != nil {
+					renameTable(tables, statements, table, qualifiedName(matches))
+					continue
+				}
+				// 前の文で追加したものを削除する項目は、追加した項目と一緒に取り除く
+				items := alterItems(code)
+				removed := make(map[int]bool)
+				for i, item := range items {
+					if list, ok := undoAlter(statements[table], code[item[0]:item[1]]); ok {
+						statements[table] = list
+						removed[i] = true
+					}
+				}
+				if len(removed) == len(items) {
+					continue
+				}
+				statements[table] = append(statements[table], withoutItems(stmt, items, removed))
+
+			case reRenameTable.MatchString(code):
+				matches := reRenameTable.FindStringSubmatch(code)
+				table := find(qualifiedName(matches))
+				if _, exists := statements[table]; !exists {
+					skipped++
+					continue
+				}
+				renameTable(tables, statements, table, qualifiedName(matches[2:]))
+
+			case reDropTable.MatchString(code):
+				for _, name := range strings.Split(reDropTable.FindStringSubmatch(code)[1], ",") {
+					drop(unquoteName(name))
+				}
+
+			case reDropIndex.MatchString(code):
+				// 前の文で作成したインデックスは作成した文ごと取り除き、CREATE TABLE で定義したものは削除する文を残す
+				matches := reDropIndex.FindStringSubmatch(code)
+				index := qualifiedName(matches)
+				table := ""
+				if matches[3] != "" {
+					table = find(qualifiedName(matches[2:]))
+				}
+				undone := false
+				for _, name := range tables {
+					if table != "" && name != table {
+						continue
+					}
+					if list, ok := undoIndex(statements[name], index); ok {
+						statements[name] = list
+						undone = true
+						break
+					}
+				}
+				if _, exists := statements[table]; !undone && exists {
+					statements[table] = append(statements[table], stmt)
+				} else if !undone {
+					skipped++
+				}
+
+			case reCreateIndex.MatchString(code):
+				table := find(qualifiedName(reCreateIndex.FindStringSubmatch(code)[2:]))
+				if _, exists := statements[table]; !exists {
+					skipped++
+					continue
+				}
+				statements[table] = append(statements[table], stmt)
+
+			default:
+				skipped++
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return "", 0, err
+		}
+	}
+
+	var replayed strings.Builder
+	for _, table := range tables {
+		for _, stmt := range statements[table] {
+			replayed.WriteString(stmt.Text)
+		}
+	}
+	baseline, err = Order(replayed.String(), opts)
+	if err != nil {
+		return "", 0, err
+	}
+	return baseline, skipped, nil
+}
+
+// テーブルの名前を変更し、これまでの文に現れるテーブル名を書き換える（入力に現れた順は保つ）
+func renameTable(tables []string, statements map[string][]Statement, from, to string) {
+	for i, table := range tables {
+		if table == from {
+			tables[i] = to
+		}
+	}
+	statements[to] = statements[from]
+	delete(statements, from)
+
+	rename := func(table string) string {
+		if strings.EqualFold(table, from) {
+			return to
+		}
+		return table
+	}
+	for _, list := range statements {
+		for i := range list {
+			list[i] = rewriteTableNames(list[i], rename)
+		}
+	}
+}
+
+// ALTER TABLE の項目の範囲（テーブル名の後ろから終端文字の前まで、区切りのカンマは含めない）
+func alterItems(code string) [][2]int {
+	loc := reAlterTable.FindStringIndex(code)
+	if loc == nil {
+		return nil
+	}
+	end := len(strings.TrimRight(code, " \t\r\n"))
+	end = len(strings.TrimSuffix(code[:end], ";"))
+	var items [][2]int
+	for start := loc[1]; start <= end; {
+		itemEnd := min(itemEnd(code[:end], start), end)
+		items = append(items, [2]int{start, itemEnd})
+		if itemEnd >= end {
+			break
+		}
+		start = itemEnd + 1
+	}
+	return items
+}
+
+// stmt から removed の項目を取り除いた ALTER TABLE の文
+func withoutItems(stmt Statement, items [][2]int, removed map[int]bool) Statement {
+	if len(removed) == 0 {
+		return stmt
+	}
+	rebuild := func(s string) string {
+		var kept []string
+		for i, item := range items {
+			if !removed[i] {
+				kept = append(kept, s[item[0]:item[1]])
+			}
+		}
+		return s[:items[0][0]] + strings.Join(kept, ",") + s[items[len(items)-1][1]:]
+	}
+	stmt.Text, stmt.Code = rebuild(stmt.Text), rebuild(stmt.Code)
+	return stmt
+}
+
+// list の ALTER TABLE から remove に当てはまる項目を取り除く（すべての項目を取り除いた文は除く）
+func removeAlterItems(list []Statement, remove func(item string) bool) []Statement {
+	kept := list[:0]
+	for _, stmt := range list {
+		items := alterItems(stmt.Code)
+		removed := make(map[int]bool)
+		for i, item := range items {
+			if remove(stmt.Code[item[0]:item[1]]) {
+				removed[i] = true
+			}
+		}
+		if len(items) > 0 && len(removed) == len(items) {
+			continue
+		}
+		kept = append(kept, withoutItems(stmt, items, removed))
+	}
+	return kept
+}
+
+// ALTER TABLE の item が list の文で追加したカラム・制約・インデックスを削除する場合は、追加した項目を取り除く
+// （カラムを変更する項目も取り除く）
+func undoAlter(list []Statement, item string) ([]Statement, bool) {
+	if matches := reDropKeyItem.FindStringSubmatch(item); matches != nil {
+		return undoIndex(list, matches[1])
+	}
+	matches := reDropColumnItem.FindStringSubmatch(item)
+	if matches == nil || alterItemKeywords[strings.ToLower(matches[1])] {
+		return list, false
+	}
+	column := matches[1]
+	added := false
+	for _, stmt := range list {
+		for _, item := range alterItems(stmt.Code) {
+			if m := reAddColumnItem.FindStringSubmatch(stmt.Code[item[0]:item[1]]); m != nil && strings.EqualFold(m[1], column) {
+				added = true
+			}
+		}
+	}
+	if !added {
+		return list, false
+	}
+	return removeAlterItems(list, func(item string) bool {
+		if reAddKeyItem.MatchString(item) {
+			return false
+		}
+		for _, re := range []*regexp.Regexp{reAddColumnItem, reModifyColumnItem} {
+			if m := re.FindStringSubmatch(item); m != nil && strings.EqualFold(m[1], column) {
+				return true
+			}
+		}
+		return false
+	}), true
+}
+
+// list の CREATE INDEX か ALTER TABLE の項目で追加した index という名前の制約・インデックスを取り除く
+func undoIndex(list []Statement, index string) ([]Statement, bool) {
+	if i := strings.LastIndex(index, "."); i >= 0 {
+		index = index[i+1:]
+	}
+	for i, stmt := range list {
+		if matches := reCreateIndex.FindStringSubmatch(stmt.Code); matches != nil && strings.EqualFold(matches[2], index) {
+			return append(list[:i:i], list[i+1:]...), true
+		}
+	}
+	added := false
+	list = removeAlterItems(list, func(item string) bool {
+		if matches := reAddKeyItem.FindStringSubmatch(item); matches != nil && !added && strings.EqualFold(matches[1], index) {
+			added = true
+			return true
+		}
+		return false
+	})
+	return list, added
+}
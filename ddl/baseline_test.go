@@ -0,0 +1,73 @@
+package ddl
+
+import "testing"
+
+func TestBaseline(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []string
+		want       string
+		skipped    int
+	}{
+		{
+			name:       "削除したテーブルは作成しない",
+			migrations: []string{"CREATE TABLE a (id INT PRIMARY KEY);\nCREATE TABLE b (id INT);\n", "DROP TABLE b;\n"},
+			want:       "CREATE TABLE a (id INT PRIMARY KEY);\n",
+		},
+		{
+			name:       "後のマイグレーションの ALTER TABLE を続ける",
+			migrations: []string{"CREATE TABLE a (id INT);\n", "ALTER TABLE a ADD COLUMN note TEXT;\n"},
+			want:       "CREATE TABLE a (id INT);\nALTER TABLE a ADD COLUMN note TEXT;\n",
+		},
+		{
+			name:       "削除したインデックスは作成しない",
+			migrations: []string{"CREATE TABLE a (id INT PRIMARY KEY, name TEXT);\nCREATE INDEX ix_name ON a (name);\n", "DROP INDEX ix_name;\n"},
+			want:       "CREATE TABLE a (id INT PRIMARY KEY, name TEXT);\n",
+		},
+		{
+			name:       "ALTER TABLE で追加して削除したインデックス",
+			migrations: []string{"CREATE TABLE c (id INT PRIMARY KEY, v INT);\nALTER TABLE c ADD INDEX ix_v (v), ADD UNIQUE KEY uq (v);\nALTER TABLE c DROP INDEX ix_v;\n"},
+			want:       "CREATE TABLE c (id INT PRIMARY KEY, v INT);\nALTER TABLE c ADD UNIQUE KEY uq (v);\n",
+		},
+		{
+			name:       "削除したカラムを追加する項目は含めない",
+			migrations: []string{"CREATE TABLE a (id INT);\nALTER TABLE a ADD COLUMN b_id INT, ADD COLUMN note TEXT;\n", "ALTER TABLE a ALTER COLUMN b_id SET NOT NULL;\nALTER TABLE a DROP COLUMN b_id;\n"},
+			want:       "CREATE TABLE a (id INT);\nALTER TABLE a ADD COLUMN note TEXT;\n",
+		},
+		{
+			name:       "テーブル名は大文字と小文字を区別しない",
+			migrations: []string{"CREATE TABLE A2 (id INT PRIMARY KEY);\nCREATE TABLE b (id INT);\n", "DROP TABLE a2;\n"},
+			want:       "CREATE TABLE b (id INT);\n",
+		},
+		{
+			name:       "削除したテーブルへの外部キーは取り除く",
+			migrations: []string{"CREATE TABLE b (id INT PRIMARY KEY);\nCREATE TABLE a (id INT);\nALTER TABLE a ADD COLUMN b_id INT REFERENCES b(id) ON DELETE CASCADE;\nALTER TABLE a ADD CONSTRAINT fk FOREIGN KEY (id) REFERENCES b(id);\n", "DROP TABLE IF EXISTS \"b\" CASCADE;\n"},
+			want:       "CREATE TABLE a (id INT);\nALTER TABLE a ADD COLUMN b_id INT;\n",
+		},
+		{
+			name:       "名前の変更は変更後の名前で作成する",
+			migrations: []string{"CREATE TABLE old (id INT);\n", "ALTER TABLE old RENAME TO new;\n"},
+			want:       "CREATE TABLE new (id INT);\n",
+		},
+		{
+			name:       "テーブルに関係しない文",
+			migrations: []string{"SET x = 1;\nCREATE TABLE a (id INT);\nDROP INDEX missing;\n"},
+			want:       "CREATE TABLE a (id INT);\n",
+			skipped:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := Baseline(tt.migrations, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Baseline =\n%s\nwant\n%s", got, tt.want)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.skipped)
+			}
+		})
+	}
+}
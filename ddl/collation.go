@@ -0,0 +1,36 @@
+package ddl
+
+import "regexp"
+
+const (
+	// CREATE COLLATION [IF NOT EXISTS] 名前 ...
+	COLLATION_PATTERN = `(?is)^\s*CREATE\s+COLLATION\s+(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?(?:\."?(\w+)"?)?`
+	// SET NAMES / SET CHARACTER SET / SET character_set_* / SET collation_* などの文字コードの設定
+	CHARSET_SETTING_PATTERN = `(?i)^\s*SET\s+(?:NAMES|CHARACTER\s+SET|CHARSET|(?:SESSION\s+|GLOBAL\s+|@@(?:SESSION\.|GLOBAL\.)?)?(?:character_set_\w+|collation_\w+))\b`
+)
+
+var (
+	reCreateCollation = regexp.MustCompile(COLLATION_PATTERN)
+	reCharsetSetting  = regexp.MustCompile(CHARSET_SETTING_PATTERN)
+	// カラム・ドメイン・インデックスなどの COLLATE 名前
+	reCollate = regexp.MustCompile(`(?i)\bCOLLATE\s+"?(\w+)"?(?:\s*\.\s*"?(\w+)"?)?`)
+)
+
+// 文が定義する照合順序の名前
+func collationDefinition(code string) (string, bool) {
+	if matches := reCreateCollation.FindStringSubmatch(code); matches != nil {
+		return qualifiedName(matches), true
+	}
+	return "", false
+}
+
+// 文が COLLATE で使う照合順序の名前（組み込みの照合順序も含むため、入力に定義されたものだけを依存関係にする）
+func collationUses(code string) []string {
+	var names []string
+	for _, matches := range reCollate.FindAllStringSubmatch(code, -1) {
+		if name := qualifiedName(matches); !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,109 @@
+package ddl
+
+import (
+	"sort"
+	"strings"
+)
+
+// CondensedNode は循環依存しているテーブルを1つにまとめたノード（循環していないテーブルはそれだけのノード）
+type CondensedNode struct {
+	// ノードの名前（まとめたテーブルを + でつなげたもの）
+	Name string `json:"name"`
+	// まとめたテーブル（入力に現れた順）
+	Tables []string `json:"tables"`
+	// 依存先をたどった最長の段数（依存先がなければ 0）
+	Level int `json:"level"`
+}
+
+// CondensedEdge はノードの間の依存関係（子のノードのテーブルは親のノードのテーブルに依存する）
+type CondensedEdge struct {
+	Parent string `json:"parent"`
+	Child  string `json:"child"`
+}
+
+// Condensation は強連結成分を1つのノードにまとめた、循環のない依存関係のグラフ
+type Condensation struct {
+	// 作成できる順のノード
+	Nodes []CondensedNode `json:"nodes"`
+	Edges []CondensedEdge `json:"edges"`
+}
+
+// Condense は依存関係のグラフ（親 → 子）の強連結成分をまとめたグラフを返す。
+// 循環依存の多いスキーマでも、まとめたノードの間には循環がないため、段ごとの大まかな構成がわかる
+func Condense(tables []string, graph map[string][]string) Condensation {
+	position := make(map[string]int)
+	for i, table := range tables {
+		position[table] = i
+	}
+	// 入力に現れた順が決まらないノード（依存先にだけ現れる名前）は入力のテーブルの後ろに置く
+	parents := make([]string, 0, len(graph))
+	for node := range graph {
+		parents = append(parents, node)
+	}
+	sort.Strings(parents)
+	for _, node := range parents {
+		if _, exists := position[node]; !exists {
+			position[node] = len(position)
+		}
+		for _, child := range graph[node] {
+			if _, exists := position[child]; !exists {
+				position[child] = len(position)
+			}
+		}
+	}
+	nodes := make([]string, len(position))
+	for node, i := range position {
+		nodes[i] = node
+	}
+
+	// テーブルごとのノードの名前
+	names := make(map[string]string)
+	members := make(map[string][]string)
+	for _, cycle := range Cycles(graph) {
+		sort.Slice(cycle, func(i, j int) bool { return position[cycle[i]] < position[cycle[j]] })
+		name := strings.Join(cycle, "+")
+		for _, table := range cycle {
+			names[table] = name
+		}
+		members[name] = cycle
+	}
+	for _, node := range nodes {
+		if _, exists := names[node]; !exists {
+			names[node] = node
+			members[node] = []string{node}
+		}
+	}
+
+	condensed := make(map[string][]string)
+	inDegree := make(map[string]int)
+	var edges []CondensedEdge
+	for _, node := range nodes {
+		name := names[node]
+		if _, exists := condensed[name]; !exists {
+			condensed[name] = []string{}
+			inDegree[name] = 0
+		}
+	}
+	for _, node := range nodes {
+		parent := names[node]
+		for _, child := range graph[node] {
+			if child := names[child]; child != parent && !contains(condensed[parent], child) {
+				condensed[parent] = append(condensed[parent], child)
+				inDegree[child]++
+				edges = append(edges, CondensedEdge{Parent: parent, Child: child})
+			}
+		}
+	}
+
+	// まとめたグラフには循環がないため、必ず並べられる
+	order, _ := TopologicalSort(condensed, inDegree)
+	levels := Levels(order, condensed)
+	result := Condensation{Nodes: make([]CondensedNode, 0, len(order)), Edges: edges}
+	for _, name := range order {
+		result.Nodes = append(result.Nodes, CondensedNode{Name: name, Tables: members[name], Level: levels[name]})
+	}
+	if result.Edges == nil {
+		result.Edges = []CondensedEdge{}
+	}
+	return result
+}
@@ -0,0 +1,247 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ResolveCycles は BreakDeferrableCycles でも解消できない循環依存ごとに、循環に含まれる依存関係（入力に現れた順）を
+// resolve に渡し、選ばれた依存関係を graph と inDegree から取り除く。取り除いた依存関係を返し、
+// resolve がエラーを返した場合はそこで止めてそのエラーを返す
+func ResolveCycles(graph map[string][]string, inDegree map[string]int, edges []Edge, resolve func(cycle []string, candidates []Edge) (int, error)) ([]Edge, error) {
+	var removed []Edge
+	used := make([]bool, len(edges))
+
+	for {
+		cycles := Cycles(graph)
+		if len(cycles) == 0 {
+			return removed, nil
+		}
+		cycle := cycles[0]
+
+		var candidates []Edge
+		var indices []int
+		for i, edge := range edges {
+			if used[i] || !contains(cycle, edge.Parent) || !contains(cycle, edge.Child) || !contains(graph[edge.Parent], edge.Child) {
+				continue
+			}
+			candidates = append(candidates, edge)
+			indices = append(indices, i)
+		}
+		choice, err := resolve(cycle, candidates)
+		if err != nil {
+			return removed, err
+		}
+		if choice < 0 || choice >= len(candidates) {
+			return removed, ErrCycle
+		}
+
+		edge := candidates[choice]
+		used[indices[choice]] = true
+		graph[edge.Parent] = removeOne(graph[edge.Parent], edge.Child)
+		inDegree[edge.Child]--
+		removed = append(removed, edge)
+	}
+}
+
+// CommentOutForeignKeys の目印
+const cycleMarker = "TODO(orderddl): 循環依存のため外部キーを無効にしました"
+
+// commentableEdge は Options.Cycles が comment の場合に除外する外部キーを選ぶ関数を返す。
+// 自己参照があればそれを、なければ参照先より前に定義されたテーブルの外部キーのうち入力で最初のものを選び、
+// どちらもなければコメントにできる最初の外部キーを選ぶ（orderddl:after などの指定と方言の依存関係は選ばない）
+func commentableEdge(tables []string) func(cycle []string, candidates []Edge) (int, error) {
+	index := make(map[string]int, len(tables))
+	for i, table := range tables {
+		index[table] = i
+	}
+	return func(cycle []string, candidates []Edge) (int, error) {
+		choice := -1
+		for i, edge := range candidates {
+			if !edge.ForeignKey.clause.located {
+				continue
+			}
+			if edge.Parent == edge.Child {
+				return i, nil
+			}
+			if choice < 0 || index[edge.Child] < index[edge.Parent] && index[candidates[choice].Child] > index[candidates[choice].Parent] {
+				choice = i
+			}
+		}
+		if choice < 0 {
+			return -1, ErrCycle
+		}
+		return choice, nil
+	}
+}
+
+// CommentOutForeignKeys は Analyze に渡した src の中の、edges の外部キーの制約をその場でコメントにする。
+// 列定義の中の REFERENCES はその部分を、表制約はカンマを含めた1項目を /* */ で囲み、
+// ALTER TABLE ... ADD CONSTRAINT だけの文は行ごとに -- を付ける。自己参照はそのまま作成できるためコメントにしない
+func CommentOutForeignKeys(src string, edges []Edge) string {
+	var clauses []clause
+	for _, edge := range edges {
+		if c := edge.ForeignKey.clause; c.located && edge.Parent != edge.Child {
+			clauses = append(clauses, c)
+			clauses = append(clauses, edge.ForeignKey.duplicateClauses()...)
+		}
+	}
+	// 後ろから置き換えて、前の位置がずれないようにする
+	sort.Slice(clauses, func(i, j int) bool { return clauses[i].start > clauses[j].start })
+
+	for _, c := range clauses {
+		if !c.statement {
+			comment := "/* " + cycleMarker + ": " + strings.ReplaceAll(src[c.start:c.end], "*/", "* /") + " */"
+			if c.start > 0 && !isSpaceByte(src[c.start-1]) {
+				comment = " " + comment
+			}
+			src = src[:c.start] + comment + src[c.end:]
+			continue
+		}
+
+		var out strings.Builder
+		out.WriteString("-- " + cycleMarker + "\n")
+		for _, line := range strings.Split(src[c.start:c.end], "\n") {
+			out.WriteString("-- " + line + "\n")
+		}
+		// 同じ行に続くものは次の行に残す
+		rest := src[c.end:]
+		if strings.HasPrefix(rest, "\r\n") {
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, "\n") {
+			rest = rest[1:]
+		} else {
+			rest = strings.TrimLeft(rest, " \t")
+		}
+		src = src[:c.start] + out.String() + rest
+	}
+	return src
+}
+
+// breakCycles は循環しているテーブルの間の外部キーをすべて graph と inDegree から取り除き、取り除いた外部キーを返す。
+// 自己参照はそのまま作成できるため、取り除くが返さない（orderddl:after などの指定と方言の依存関係は残す）
+func breakCycles(graph map[string][]string, inDegree map[string]int, edges []Edge) []Edge {
+	component := make(map[string]int)
+	for i, cycle := range Cycles(graph) {
+		for _, table := range cycle {
+			component[table] = i + 1
+		}
+	}
+
+	var removed []Edge
+	for _, edge := range edges {
+		c := component[edge.Parent]
+		if c == 0 || component[edge.Child] != c || !edge.ForeignKey.clause.located || !contains(graph[edge.Parent], edge.Child) {
+			continue
+		}
+		graph[edge.Parent] = removeOne(graph[edge.Parent], edge.Child)
+		inDegree[edge.Child]--
+		if edge.Parent != edge.Child {
+			removed = append(removed, edge)
+		}
+	}
+	return removed
+}
+
+// 既に DEFERRABLE / NOT DEFERRABLE が指定されているか
+var reDeferrability = regexp.MustCompile(`(?i)\b(?:NOT\s+)?DEFERRABLE\b`)
+
+// FixForeignKeys は Analyze に渡した src から edges の外部キーの制約を取り除き、取り除いた制約を追加する
+// ALTER TABLE の文を返す。方言が postgres の場合は、データを入れる順によらず検査が通るよう
+// DEFERRABLE INITIALLY DEFERRED を付ける（NOT DEFERRABLE などの指定がある場合はそのまま）
+func FixForeignKeys(src string, edges []Edge, opts Options) (string, string) {
+	var moved []Edge
+	for _, edge := range edges {
+		if edge.Parent != edge.Child {
+			moved = append(moved, edge)
+		}
+	}
+	return moveForeignKeys(src, moved, opts, opts.Dialect != nil && opts.Dialect.Name() == "postgres")
+}
+
+// SeparateForeignKeys は Analyze に渡した src から edges のすべての外部キーの制約（自己参照を含む）を取り除き、
+// 取り除いた制約を追加する ALTER TABLE の文を、参照元のテーブルの order（作成順序）での順に返す。
+// すべてのテーブルを作成した後に追加するため、DEFERRABLE は付けない
+func SeparateForeignKeys(src string, edges []Edge, order []string, opts Options) (string, string) {
+	index := make(map[string]int, len(order))
+	for i, table := range order {
+		index[table] = i
+	}
+	var moved []Edge
+	for _, edge := range edges {
+		if edge.ForeignKey.clause.located {
+			moved = append(moved, edge)
+		}
+	}
+	sort.SliceStable(moved, func(i, j int) bool { return index[moved[i].Child] < index[moved[j].Child] })
+	return moveForeignKeys(src, moved, opts, false)
+}
+
+// edges の外部キーの制約を src から取り除き、追加する ALTER TABLE の文を edges の順に返す（deferred の場合は検査を遅らせる）
+func moveForeignKeys(src string, edges []Edge, opts Options, deferred bool) (string, string) {
+	var alters strings.Builder
+	var removed []clause
+	spellings := TableSpellings(src, opts)
+	for _, edge := range edges {
+		fk := edge.ForeignKey
+		if !fk.clause.located {
+			continue
+		}
+		removed = append(removed, fk.clause)
+		removed = append(removed, fk.duplicateClauses()...)
+
+		c := fk.clause
+		definition := src[c.definition[0]:c.definition[1]]
+		if c.column != "" {
+			// 列制約は表制約の形にする
+			if loc := reReferences.FindStringIndex(maskExpressions(definition)); loc != nil {
+				definition = definition[:loc[0]] + "FOREIGN KEY (" + c.column + ") " + definition[loc[0]:]
+			}
+		}
+		if deferred {
+			switch {
+			case fk.Deferred():
+			case fk.Deferrable:
+				definition += " INITIALLY DEFERRED"
+			case !reDeferrability.MatchString(definition):
+				definition += " DEFERRABLE INITIALLY DEFERRED"
+			}
+		}
+		fmt.Fprintf(&alters, "ALTER TABLE %s ADD %s;\n", spellings.Quote(opts.Dialect, fk.Table), definition)
+	}
+
+	// 後ろから取り除いて、前の位置がずれないようにする
+	sort.Slice(removed, func(i, j int) bool { return removed[i].start > removed[j].start })
+	for _, c := range removed {
+		start, end := c.removal(src)
+		src = src[:start] + src[end:]
+	}
+	return src, alters.String()
+}
+
+// 制約を src から取り除く範囲（文全体の場合は後ろの改行、列制約の場合は列の型との間の空白を含める）
+func (c clause) removal(src string) (int, int) {
+	start, end := c.start, c.end
+	switch {
+	case c.statement:
+		// 文の後ろの改行（同じ行に続くものがある場合はその前の空白）まで取り除く
+		for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+			end++
+		}
+		if strings.HasPrefix(src[end:], "\r\n") {
+			end += 2
+		} else if strings.HasPrefix(src[end:], "\n") {
+			end++
+		}
+	case c.column != "":
+		// 列の型との間の空白も取り除く
+		for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+			start--
+		}
+	case start > 0 && end < len(src) && src[start-1] == ' ' && src[end] == ' ':
+		end++
+	}
+	return start, end
+}
@@ -0,0 +1,79 @@
+package ddl
+
+import (
+	"errors"
+	"testing"
+)
+
+const cyclicSchema = "CREATE TABLE a (id int PRIMARY KEY, b_id int REFERENCES b(id));\n" +
+	"CREATE TABLE b (id int PRIMARY KEY, a_id int, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a(id));\n"
+
+func TestFixForeignKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		alters  string
+	}{
+		{
+			name:   "方言なし",
+			alters: "ALTER TABLE a ADD FOREIGN KEY (b_id) REFERENCES b(id);\nALTER TABLE b ADD CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a(id);\n",
+		},
+		{
+			name:    "postgres では検査を遅らせる",
+			dialect: "postgres",
+			alters:  "ALTER TABLE a ADD FOREIGN KEY (b_id) REFERENCES b(id) DEFERRABLE INITIALLY DEFERRED;\nALTER TABLE b ADD CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a(id) DEFERRABLE INITIALLY DEFERRED;\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Cycles: CyclesFix}
+			if tt.dialect != "" {
+				opts.Dialect = mustDialect(t, tt.dialect)
+			}
+			result, err := Analyze(cyclicSchema, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, alters := FixForeignKeys(cyclicSchema, result.Fixed, opts)
+			if want := "CREATE TABLE a (id int PRIMARY KEY, b_id int);\nCREATE TABLE b (id int PRIMARY KEY, a_id int);\n"; src != want {
+				t.Errorf("src =\n%s\nwant\n%s", src, want)
+			}
+			if alters != tt.alters {
+				t.Errorf("alters =\n%s\nwant\n%s", alters, tt.alters)
+			}
+		})
+	}
+}
+
+func TestCommentOutForeignKeys(t *testing.T) {
+	result, err := Analyze(cyclicSchema, Options{Cycles: CyclesComment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CREATE TABLE a (id int PRIMARY KEY, b_id int /* TODO(orderddl): 循環依存のため外部キーを無効にしました: REFERENCES b(id) */);\n" +
+		"CREATE TABLE b (id int PRIMARY KEY, a_id int, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a(id));\n"
+	if got := CommentOutForeignKeys(cyclicSchema, result.Commented); got != want {
+		t.Errorf("CommentOutForeignKeys =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResolveCycle(t *testing.T) {
+	var offered int
+	opts := Options{ResolveCycle: func(cycle []string, candidates []Edge) (int, error) {
+		offered = len(candidates)
+		return 1, nil
+	}}
+	result, err := Analyze(cyclicSchema, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offered != 2 || len(result.Resolved) != 1 || result.Resolved[0].Child != "b" {
+		t.Errorf("Resolved = %+v（候補 %d 個）", result.Resolved, offered)
+	}
+
+	// 中止した場合は循環依存のエラーを返す
+	opts.ResolveCycle = func([]string, []Edge) (int, error) { return -1, ErrCycle }
+	if _, err := Analyze(cyclicSchema, opts); !errors.Is(err, ErrCycle) {
+		t.Errorf("err = %v, want ErrCycle", err)
+	}
+}
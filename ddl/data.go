@@ -0,0 +1,58 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// DATA_PATTERN はデータを読み込む文
+	DATA_PATTERN = `(?i)^\s*(?:INSERT|REPLACE|COPY|LOAD\s+DATA)\b`
+	// INSERT / REPLACE / COPY の対象のテーブル
+	DATA_TABLE_PATTERN = `(?i)^\s*(?:(?:INSERT|REPLACE)(?:\s+(?:LOW_PRIORITY|DELAYED|HIGH_PRIORITY|IGNORE))*\s+(?:INTO\s+)?|COPY\s+)` + QUALIFIED_NAME_PATTERN
+)
+
+var (
+	reData      = regexp.MustCompile(DATA_PATTERN)
+	reDataTable = regexp.MustCompile(DATA_TABLE_PATTERN)
+)
+
+// SplitData はテーブルごとのブロックを、スキーマの文とデータの文（INSERT / COPY など）に分ける。
+// LOCK TABLES から UNLOCK TABLES までの文と COPY のデータ行はデータに含め、USE は両方に含める
+func SplitData(ddlContent map[string]string) (schema, data map[string]string) {
+	schema = make(map[string]string, len(ddlContent))
+	data = make(map[string]string)
+	for table, block := range ddlContent {
+		var schemaPart, dataPart strings.Builder
+		locked, hasData := false, false
+		scanner := NewStatementScanner(strings.NewReader(block))
+		for scanner.Scan() {
+			stmt := scanner.Statement()
+			switch {
+			case reUse.MatchString(stmt.Code):
+				schemaPart.WriteString(stmt.Text)
+				dataPart.WriteString(stmt.Text)
+				continue
+			case reLockTables.MatchString(stmt.Code):
+				locked = true
+				dataPart.WriteString(stmt.Text)
+			case reUnlockTables.MatchString(stmt.Code):
+				locked = false
+				dataPart.WriteString(stmt.Text)
+			case locked, stmt.CopyData, reData.MatchString(stmt.Code):
+				dataPart.WriteString(stmt.Text)
+			default:
+				schemaPart.WriteString(stmt.Text)
+				continue
+			}
+			hasData = true
+		}
+		if strings.TrimSpace(schemaPart.String()) != "" {
+			schema[table] = endLine(schemaPart.String())
+		}
+		if hasData {
+			data[table] = endLine(dataPart.String())
+		}
+	}
+	return schema, data
+}
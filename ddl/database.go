@@ -0,0 +1,186 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	USE_PATTERN             = `(?i)^\s*USE\s+` + IDENTIFIER_PATTERN + `\s*;?\s*$`
+	CREATE_DATABASE_PATTERN = `(?i)^\s*CREATE\s+(?:DATABASE|SCHEMA)\s+(?:IF\s+NOT\s+EXISTS\s+)?` + IDENTIFIER_PATTERN
+	LOCK_TABLES_PATTERN     = `(?i)^\s*LOCK\s+TABLES?\s+` + QUALIFIED_NAME_PATTERN
+	UNLOCK_TABLES_PATTERN   = `(?i)^\s*UNLOCK\s+TABLES?\b`
+)
+
+var (
+	reUse            = regexp.MustCompile(USE_PATTERN)
+	reCreateDatabase = regexp.MustCompile(CREATE_DATABASE_PATTERN)
+	// mysqldump はテーブルごとのデータを LOCK TABLES と UNLOCK TABLES で囲む
+	reLockTables   = regexp.MustCompile(LOCK_TABLES_PATTERN)
+	reUnlockTables = regexp.MustCompile(UNLOCK_TABLES_PATTERN)
+)
+
+// database は USE で選択されているデータベース（選択されていない場合は空文字）
+type database string
+
+// use は文が USE の場合に選択されているデータベースを切り替え、true を返す
+func (d *database) use(code string) bool {
+	matches := reUse.FindStringSubmatch(code)
+	if matches == nil {
+		return false
+	}
+	*d = database(matches[1])
+	return true
+}
+
+// qualify は修飾されていないテーブル名を選択されているデータベースで修飾する
+func (d database) qualify(table string) string {
+	if d == "" || table == "" || SchemaOf(table) != "" {
+		return table
+	}
+	return string(d) + "." + table
+}
+
+// 外部キーの参照元と参照先を選択されているデータベースで修飾する
+func (d database) qualifyForeignKeys(fks []ForeignKey) []ForeignKey {
+	for i := range fks {
+		fks[i].Table = d.qualify(fks[i].Table)
+		fks[i].RefTable = d.qualify(fks[i].RefTable)
+	}
+	return fks
+}
+
+// ブロックの先頭にある文字コードの設定、CREATE DATABASE と USE の文
+type preamble struct {
+	// SET NAMES などの文字コードの設定
+	settings   []Statement
+	statements []Statement
+	// 文ごとのデータベース名（小文字）と、USE かどうか
+	names []string
+	uses  []bool
+	// 前置きの文を除いたブロックの本体
+	body string
+}
+
+// ブロックの先頭から文字コードの設定、CREATE DATABASE と USE の文を取り出す
+func splitPreamble(block string) preamble {
+	var p preamble
+	scanner := NewStatementScanner(strings.NewReader(block))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		if reCharsetSetting.MatchString(stmt.Code) && !ignored(stmt) {
+			p.settings = append(p.settings, stmt)
+			continue
+		}
+		matches := reCreateDatabase.FindStringSubmatch(stmt.Code)
+		use := false
+		if matches == nil {
+			matches, use = reUse.FindStringSubmatch(stmt.Code), true
+		}
+		if matches == nil {
+			p.body = block[stmt.Offset:]
+			return p
+		}
+		p.statements = append(p.statements, stmt)
+		p.names = append(p.names, strings.ToLower(matches[1]))
+		p.uses = append(p.uses, use)
+	}
+	return p
+}
+
+// scopedBlocks は並び替えた順のブロックを返す。
+// ブロックの先頭の文字コードの設定は最初のブロックの先頭に移し、USE は直前と同じデータベースであれば省き、
+// CREATE DATABASE / CREATE SCHEMA はそのデータベースを最初に使うブロックの前に移す
+func scopedBlocks(sortedTables []string, ddlContent map[string]string) []string {
+	preambles := make(map[string]preamble)
+	creates := make(map[string]Statement)
+	used := make(map[string]bool)
+	var settings []Statement
+	for _, table := range sortedTables {
+		block, exists := ddlContent[table]
+		if !exists {
+			continue
+		}
+		p := splitPreamble(block)
+		preambles[table] = p
+		settings = append(settings, p.settings...)
+		if schema := SchemaOf(table); schema != "" {
+			used[strings.ToLower(schema)] = true
+		}
+		for i, stmt := range p.statements {
+			if p.uses[i] {
+				used[p.names[i]] = true
+			} else if _, exists := creates[p.names[i]]; !exists {
+				creates[p.names[i]] = stmt
+			}
+		}
+	}
+
+	current := ""
+	created := make(map[string]bool)
+	var blocks []string
+	for _, table := range sortedTables {
+		block, exists := ddlContent[table]
+		if !exists {
+			continue
+		}
+		p := preambles[table]
+		schema := strings.ToLower(SchemaOf(table))
+		first := len(blocks) == 0
+		if len(p.statements)+len(p.settings) == 0 && (creates[schema].Text == "" || created[schema]) && (!first || len(settings) == 0) {
+			blocks = append(blocks, block)
+			continue
+		}
+
+		var out strings.Builder
+		write := func(stmt Statement) {
+			out.WriteString(stmt.Text)
+			if !strings.HasSuffix(stmt.Text, "\n") {
+				out.WriteString("\n")
+			}
+		}
+		// データベースを使う文の前で作成する（前置きのコメントは元の位置に残す）
+		create := func(name string) {
+			if stmt, exists := creates[name]; exists && !created[name] {
+				created[name] = true
+				stmt.Text = stmt.Text[len(leadingText(stmt)):]
+				write(stmt)
+			}
+		}
+		// 省いた文の前のコメントは残す
+		skip := func(stmt Statement) {
+			if leading := leadingText(stmt); strings.TrimSpace(leading) != "" {
+				out.WriteString(leading)
+			}
+		}
+		if first {
+			for _, stmt := range settings {
+				write(stmt)
+			}
+		}
+		for i, stmt := range p.statements {
+			name := p.names[i]
+			if !p.uses[i] {
+				// USE されるデータベースは USE の直前で作成する
+				if created[name] || used[name] {
+					skip(stmt)
+					continue
+				}
+				created[name] = true
+				write(stmt)
+				continue
+			}
+			create(name)
+			if name == current {
+				skip(stmt)
+				continue
+			}
+			current = name
+			write(stmt)
+		}
+		create(schema)
+		out.WriteString(p.body)
+		blocks = append(blocks, out.String())
+	}
+	return blocks
+}
@@ -0,0 +1,47 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// CREATE [GLOBAL TEMPORARY] TABLE [IF NOT EXISTS] 名前
+	reDB2Table = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:GLOBAL\s+TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + DIALECT_NAME_PATTERN)
+	// CREATE TABLE 名前 LIKE 元
+	reDB2Like = regexp.MustCompile(`(?is)^\s*CREATE\b[^(]*?\bTABLE\s+\S+\s+LIKE\s+` + DIALECT_NAME_PATTERN)
+)
+
+func init() {
+	RegisterDialect(db2Dialect{})
+}
+
+// IBM DB2。ORGANIZE BY や IN 表スペースなどの後ろに続く句はそのまま残す。
+// ルーチンのファイルでは --#SET TERMINATOR か -terminator @ で終端文字を切り替える
+type db2Dialect struct{}
+
+func (db2Dialect) Name() string { return "db2" }
+
+func (db2Dialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (db2Dialect) Terminator() string { return ";" }
+
+func (db2Dialect) FoldIdentifier(name string) string { return foldASCII(name, unicode.ToUpper) }
+
+// LIKE の元のテーブルは先に作成する必要がある
+func (db2Dialect) Dependencies(stmt Statement) []string {
+	if matches := reDB2Like.FindStringSubmatch(stmt.Code); matches != nil {
+		return []string{dialectTableName(matches[1])}
+	}
+	return nil
+}
+
+func (db2Dialect) CreateTable(stmt Statement) (string, bool) {
+	if matches := reDB2Table.FindStringSubmatch(stmt.Code); matches != nil {
+		return dialectTableName(matches[1]), true
+	}
+	return "", false
+}
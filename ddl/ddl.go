@@ -0,0 +1,879 @@
+// Package ddl はDDLを解析し、外部キーの依存関係の順にテーブルを並び替える。
+//
+// 同じ入力と設定からは、実行ごと・OSごと・Go のバージョンごとに常に同じバイト列を出力する。
+// map を走査する場合は名前順に並べてから使い、順序が決まらない箇所は入力に現れた順か名前順で決める
+package ddl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	TABLE_PATTERN           = `(?i)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + QUALIFIED_NAME_PATTERN
+	TABLE_STATEMENT_PATTERN = `(?i)^\s*(?:CREATE(?:\s+OR\s+REPLACE)?|ALTER)\s+TABLE\b`
+	// CREATE TEMPORARY TABLE / DECLARE GLOBAL TEMPORARY TABLE / DROP TEMPORARY TABLE（DB2 などの CREATE GLOBAL TEMPORARY TABLE は定義が残るため含めない）
+	TEMPORARY_TABLE_PATTERN = `(?i)^\s*(?:CREATE\s+(?:OR\s+REPLACE\s+)?(?:LOCAL\s+)?(?:TEMP|TEMPORARY|VOLATILE)|DECLARE\s+GLOBAL\s+TEMPORARY|DROP\s+TEMPORARY)\s+TABLE\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?` + QUALIFIED_NAME_PATTERN
+	REFERENCES_PATTERN      = `(?i)\bREFERENCES\s+` + QUALIFIED_NAME_PATTERN
+)
+
+var reTemporaryTable = regexp.MustCompile(TEMPORARY_TABLE_PATTERN)
+
+// ErrCycle は外部キーの循環依存を表すエラー
+var ErrCycle = errors.New("外部キーの循環依存が発生しています")
+
+// 正規表現のマッチ結果から schema.table 形式のテーブル名を組み立てる
+func qualifiedName(matches []string) string {
+	if len(matches) > 2 && matches[2] != "" {
+		return matches[1] + "." + matches[2]
+	}
+	return matches[1]
+}
+
+// SchemaOf はテーブル名からスキーマ名を取り出す（修飾されていない場合は空文字）
+func SchemaOf(table string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[:i]
+	}
+	return ""
+}
+
+// Edge は作成順序を決める依存関係（Child のブロックを Parent より後に作成する）
+type Edge struct {
+	Parent string
+	Child  string
+	// 依存関係の元になった外部キー
+	ForeignKey ForeignKey
+	// orderddl:after / orderddl:before の指定による依存関係
+	Hint bool
+	// Options.ExtraDependencies で加えた依存関係
+	Extra bool
+}
+
+// 解析した入力
+type parsed struct {
+	tables []string // 入力に現れた順のテーブル
+	edges  []Edge
+	hints  map[string]Hint
+	// 同じ外部キーが重複して定義されていたため依存関係から除いたもの
+	duplicates []ForeignKey
+	// Options.IgnoredDependencies に従って依存関係から除いたものと、一致するものがなかった指定
+	ignored       []Edge
+	unusedIgnores []Dependency
+	// 型を使っているが CREATE EXTENSION が入力にない拡張
+	missingExtensions []MissingExtension
+	// 字句解析で問題があった文
+	warnings []Warning
+	// 種類を判断できなかった文と、出力に含まれない文
+	unrecognized []Warning
+	// 文の数と、最も大きい文のバイト数と開始行
+	statements           int
+	largest, largestLine int
+}
+
+// Edges は入力に現れた順のテーブルと、作成順序を決める依存関係を返す
+func Edges(r io.Reader, opts Options) ([]string, []Edge, error) {
+	p, err := parse(context.Background(), r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.tables, p.edges, nil
+}
+
+func parse(ctx context.Context, r io.Reader, opts Options) (*parsed, error) {
+	// 正規表現: CREATE TABLE と FOREIGN KEY を抽出
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+	reTableStatement := regexp.MustCompile(TABLE_STATEMENT_PATTERN)
+
+	p := &parsed{tables: []string{}, hints: make(map[string]Hint)}
+	currentTable := ""
+	// 追加した外部キーと方言の依存関係の p.edges での位置
+	seen := make(map[string]int)
+	// USE で選択されているデータベース（修飾されていないテーブル名はこのデータベースのものとみなす）
+	var db database
+	locked := false
+	// テーブルごとのブロックで追加した依存関係の範囲と、CREATE OR REPLACE で置き換えられたため除く依存関係
+	blockStart := 0
+	blockEdges := make(map[string][][2]int)
+	replaced := make(map[int]bool)
+	// CREATE SYNONYM で定義したシノニム（名前 → 対象）
+	synonyms := make(map[string]string)
+	// CREATE TYPE / CREATE DOMAIN で定義した型、CREATE COLLATION / CREATE TABLESPACE / CREATE EXTENSION で定義した照合順序・テーブルスペース・拡張と、
+	// それらを使う文の依存関係の候補（組み込みのものを含む）
+	named := make(map[string]bool)
+	var namedUses, extensionUses []Edge
+
+	// 読み込んだバイト数（Options.Progress に渡す）
+	var read int64
+
+	// 文字列リテラルとコメントを除いたテキストからキーワードを探す
+	scanner := opts.newScanner(ctx, r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		read += int64(len(stmt.Text))
+		p.statements++
+		if len(stmt.Text) > p.largest {
+			p.largest, p.largestLine = len(stmt.Text), stmt.Line
+		}
+		if opts.Progress != nil {
+			opts.Progress(read, len(p.tables))
+		}
+		if stmt.Malformed != nil {
+			p.warnings = append(p.warnings, *stmt.Malformed)
+		}
+
+		// orderddl:ignore の文はテーブルにも依存関係にも含めない
+		if ignored(stmt) || db.use(code) {
+			continue
+		}
+
+		if name, target, ok := synonymDefinition(code, db); ok {
+			synonyms[name] = target
+		}
+
+		// CREATE TABLE と、方言が定義するオブジェクトの検出
+		created, isObject := opts.dialectObject(stmt)
+		_, isTable := opts.dialectTable(stmt)
+		if matches := reCreateTable.FindStringSubmatch(code); len(matches) > 1 {
+			created, isObject, isTable = qualifiedName(matches), true, true
+		}
+		if isObject {
+			if currentTable != "" {
+				blockEdges[currentTable] = append(blockEdges[currentTable], [2]int{blockStart, len(p.edges)})
+			}
+			blockStart = len(p.edges)
+			currentTable = db.qualify(created)
+			_, isType := typeDefinition(code)
+			_, isCollation := collationDefinition(code)
+			_, isTablespace := tablespaceDefinition(code)
+			_, isExtension := extensionDefinition(code)
+			if isType || isCollation || isTablespace || isExtension {
+				named[currentTable] = true
+			}
+
+			// CREATE OR REPLACE で定義し直したテーブルは、前の定義の依存関係と並び順の指定を除く
+			if kind, _ := replacedKey(code, db); kind != "" && contains(p.tables, currentTable) {
+				if !opts.KeepReplaced {
+					for _, r := range blockEdges[currentTable] {
+						for i := r[0]; i < r[1]; i++ {
+							replaced[i] = true
+						}
+					}
+					delete(blockEdges, currentTable)
+					delete(p.hints, currentTable)
+				}
+				// 定義し直した外部キーは重複として扱わない
+				for key := range seen {
+					if strings.HasPrefix(key, currentTable+"\x00") {
+						delete(seen, key)
+					}
+				}
+			} else {
+				p.tables = append(p.tables, currentTable)
+			}
+
+			// コメントによる並び順の指定
+			hint := parseHint(stmt)
+			for _, parent := range hint.After {
+				p.edges = append(p.edges, Edge{Parent: db.qualify(parent), Child: currentTable, Hint: true})
+			}
+			for _, child := range hint.Before {
+				p.edges = append(p.edges, Edge{Parent: currentTable, Child: db.qualify(child), Hint: true})
+			}
+			if hint.First || hint.Last || len(hint.After) > 0 || len(hint.Before) > 0 {
+				p.hints[currentTable] = hint
+			}
+		}
+
+		// 方言に固有の依存関係（INHERITS など）
+		dialectDeps := opts.dialectDependencies(stmt)
+		// 最初のテーブルより前の文は CREATE DATABASE、文字コードの設定、TimescaleDB の関数の呼び出し、そのまま残すビューと LOCK TABLES から UNLOCK TABLES までだけを残す
+		switch {
+		case !stmt.executable():
+		case currentTable != "":
+			if opts.unknown(stmt) {
+				p.unrecognized = append(p.unrecognized, statementWarning(stmt, "組み込みの解析で判断できない文です"))
+			}
+		case opts.Views == ViewsPassthrough && reCreateView.MatchString(code):
+			// 最初のテーブルより前でも、最初のテーブルのブロックの先頭に残す
+		case locked:
+			locked = !reUnlockTables.MatchString(code)
+		case reLockTables.MatchString(code):
+			locked = true
+		case !reCreateDatabase.MatchString(code) && !reCharsetSetting.MatchString(code) && hypertableTarget(stmt) == "":
+			p.unrecognized = append(p.unrecognized, statementWarning(stmt, "最初のテーブルより前にあるため出力されない文です"))
+		}
+		if currentTable != "" {
+			for _, name := range append(append(columnTypes(code), collationUses(code)...), tablespaceUses(code)...) {
+				namedUses = append(namedUses, Edge{Parent: db.qualify(name), Child: currentTable})
+			}
+			for _, name := range opts.extensionUses(code) {
+				extensionUses = append(extensionUses, Edge{Parent: db.qualify(name), Child: currentTable})
+			}
+			for _, parent := range dialectDeps {
+				parent = db.qualify(parent)
+				key := currentTable + "\x00dialect\x00" + parent
+				if _, exists := seen[key]; parent == currentTable || exists {
+					continue
+				}
+				seen[key] = len(p.edges)
+				p.edges = append(p.edges, Edge{Parent: parent, Child: currentTable})
+			}
+		}
+
+		// FOREIGN KEY の検出（CREATE TABLE / ALTER TABLE のみ）
+		if currentTable == "" || !reTableStatement.MatchString(code) && !isTable {
+			continue
+		}
+		// ALTER TABLE は直前のテーブルのブロックに含まれるため、依存関係はブロックのテーブルに付ける
+		table := currentTable
+		if matches := reAlterTable.FindStringSubmatch(code); len(matches) > 1 {
+			table = db.qualify(qualifiedName(matches))
+		}
+		for _, fk := range db.qualifyForeignKeys(statementForeignKeys(table, code)) {
+			if !opts.ordersBy(fk) {
+				continue
+			}
+			fk.clause.locate(stmt.Offset)
+			// 列制約と表制約の両方で書かれた場合など、同じ外部キーは1つにまとめる
+			key := currentTable + "\x00" + fk.key()
+			if i, exists := seen[key]; exists {
+				p.duplicates = append(p.duplicates, fk)
+				p.edges[i].ForeignKey.duplicates = append(p.edges[i].ForeignKey.duplicates, fk)
+				continue
+			}
+			seen[key] = len(p.edges)
+			p.edges = append(p.edges, Edge{Parent: fk.RefTable, Child: currentTable, ForeignKey: fk})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(replaced) > 0 {
+		edges := p.edges[:0]
+		for i, edge := range p.edges {
+			if !replaced[i] {
+				edges = append(edges, edge)
+			}
+		}
+		p.edges = edges
+	}
+	p.edges = append(p.edges, opts.namedEdges(append(namedUses, extensionUses...), named)...)
+	p.missingExtensions = missingExtensions(extensionUses, named)
+	p.edges = append(p.edges, opts.extraEdges()...)
+
+	// スキーマで修飾されていない参照先を解決する
+	opts.resolveEdges(p.tables, p.edges)
+	opts.resolveSynonyms(p.tables, p.edges, synonyms)
+	p.edges, p.ignored, p.unusedIgnores = opts.ignoreEdges(p.tables, p.edges)
+	return p, nil
+}
+
+// 文の開始行と先頭の行を付けた警告
+func statementWarning(stmt Statement, message string) Warning {
+	leading := leadingText(stmt)
+	sql := strings.TrimSpace(strings.TrimPrefix(stmt.SQL(), strings.TrimSpace(leading)))
+	if i := strings.IndexByte(sql, '\n'); i >= 0 {
+		sql = strings.TrimSpace(sql[:i]) + " ..."
+	}
+	return Warning{Line: stmt.Line + strings.Count(leading, "\n"), Message: message + ": " + sql}
+}
+
+// Parse はテーブルの依存関係を解析する
+func Parse(r io.Reader, opts Options) (map[string][]string, map[string]int, []string, error) {
+	tableOrder, edges, err := Edges(r, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// データ構造
+	graph := make(map[string][]string) // 外部キーの依存関係（親 → 子）
+	inDegree := make(map[string]int)   // 入次数
+	for _, table := range tableOrder {
+		if _, exists := graph[table]; !exists {
+			graph[table] = []string{}
+		}
+		inDegree[table] = 0
+	}
+	for _, edge := range edges {
+		// 自己参照はそのまま作成できるため、作成順序に影響しない
+		if edge.Parent == edge.Child {
+			continue
+		}
+		graph[edge.Parent] = append(graph[edge.Parent], edge.Child)
+		inDegree[edge.Child]++
+	}
+
+	return graph, inDegree, tableOrder, nil
+}
+
+// BreakDeferrableCycles は循環依存を解消するため、循環に含まれる DEFERRABLE INITIALLY DEFERRED の外部キーを
+// graph と inDegree から取り除き、取り除いた依存関係を返す
+func BreakDeferrableCycles(graph map[string][]string, inDegree map[string]int, edges []Edge) []Edge {
+	var removed []Edge
+	used := make([]bool, len(edges))
+
+	for {
+		progress := false
+		for _, cycle := range Cycles(graph) {
+			for i, edge := range edges {
+				if used[i] || !edge.ForeignKey.Deferred() || !contains(cycle, edge.Parent) || !contains(cycle, edge.Child) {
+					continue
+				}
+				used[i] = true
+				graph[edge.Parent] = removeOne(graph[edge.Parent], edge.Child)
+				inDegree[edge.Child]--
+				removed = append(removed, edge)
+				progress = true
+				break
+			}
+			if progress {
+				break
+			}
+		}
+		// 解消できない循環が残っている場合はそのままにする
+		if !progress {
+			return removed
+		}
+	}
+}
+
+// list から value を1つ取り除く
+func removeOne(list []string, value string) []string {
+	for i, v := range list {
+		if v == value {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// TopologicalSort は Kahn's Algorithm を使ったトポロジカルソート。
+// 同時に置けるものは名前順に並べるため、map の走査順によらず結果は常に同じになる
+func TopologicalSort(graph map[string][]string, inDegree map[string]int) ([]string, error) {
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	for node := range inDegree {
+		if _, exists := graph[node]; !exists {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+
+	sortedTables := sortTables(nodes, graph, nil, nil)
+
+	// 閉路チェック（DAGでない場合）
+	if len(sortedTables) != len(nodes) {
+		return nil, ErrCycle
+	}
+
+	return sortedTables, nil
+}
+
+// Split はDDLをテーブルごとに分割する。
+// USE でデータベースが選択されている場合、ブロックの先頭に USE を含め、
+// CREATE DATABASE / CREATE SCHEMA はそのデータベースの最初のブロック（使われない場合は最初のブロック）の先頭に含める。
+// LOCK TABLES から UNLOCK TABLES までの文は、ロックするテーブルのブロックの末尾に含める。
+// ALTER TABLE・CREATE INDEX・COMMENT ON TABLE は、入力のどこにあっても対象のテーブルのブロックに含める
+func Split(r io.Reader) (map[string]string, error) {
+	return SplitWithOptions(context.Background(), r, Options{})
+}
+
+// SplitContext は ctx が取り消された場合に途中で終える Split
+func SplitContext(ctx context.Context, r io.Reader) (map[string]string, error) {
+	return SplitWithOptions(ctx, r, Options{})
+}
+
+// SplitWithOptions は opts の方言の終端文字で文を区切る SplitContext。
+// opts.Unknown を指定した場合は、最初のテーブルより前の種類を判断できない文を最初のテーブルのブロックに含める
+func SplitWithOptions(ctx context.Context, r io.Reader, opts Options) (map[string]string, error) {
+	return split(ctx, r, opts, nil)
+}
+
+// DroppedStatements は src を opts で分割したときに、指定に従って出力から除く文（置き換えられた CREATE OR REPLACE の前の定義、
+// 除いた一時テーブルの文、-views skip で除いたビュー）を返す（空白やコメントだけの文は除く）。
+// 最初のテーブルより前にあるため出力されない文は含めない
+func DroppedStatements(ctx context.Context, src string, opts Options) ([]Statement, error) {
+	var dropped []Statement
+	if _, err := split(ctx, strings.NewReader(src), opts, &dropped); err != nil {
+		return nil, err
+	}
+	statements := dropped[:0]
+	for _, stmt := range dropped {
+		if stmt.executable() {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements, nil
+}
+
+// split は SplitWithOptions の本体。dropped が nil でない場合は、設定に従って出力から除いた文を加える
+func split(ctx context.Context, r io.Reader, opts Options, dropped *[]Statement) (map[string]string, error) {
+	drop := func(stmt Statement) {
+		if dropped != nil {
+			*dropped = append(*dropped, stmt)
+		}
+	}
+	ddlContent := make(map[string]string)
+	var currentTable, firstTable string
+	// 最初のテーブルより前にある文字コードの設定
+	var settings strings.Builder
+	var currentDDL strings.Builder
+	var db database
+	use := ""
+	// まだブロックに含めていない CREATE DATABASE（データベース名は小文字）
+	creates := make(map[string]string)
+	var createOrder []string
+	// LOCK TABLES でロックしているテーブルと、UNLOCK TABLES までの文
+	lockTable := ""
+	var lockDDL strings.Builder
+	// まだ定義されていないテーブルのブロックに含める文（LOCK TABLES から UNLOCK TABLES までの文と、create_hypertable などの呼び出し）
+	locked := make(map[string]string)
+	var lockedOrder []string
+	// CREATE OR REPLACE で定義したテーブル以外のオブジェクトごとの、定義を含むブロックのテーブルと文
+	type definition struct {
+		table string
+		stmt  Statement
+	}
+	definitions := make(map[string]definition)
+	// 始めたブロックが CREATE OR REPLACE で定義し直したテーブルのもの
+	replacing := false
+	// opts.Temporary が exclude の場合に除く一時テーブル
+	temporaries := make(map[string]bool)
+	// opts.Unknown を指定した場合に、最初のテーブルのブロックに含める種類を判断できない文
+	var unknownDDL strings.Builder
+	// これまでに定義したテーブル
+	seen := make(map[string]bool)
+	// 対象のテーブルがまだ定義されていない ALTER TABLE などの文（対象が定義されなかった場合は block に含める）
+	type pendingStatement struct {
+		target, block, text string
+	}
+	var pending []pendingStatement
+
+	// 最後のテーブルを追加（次のブロックと連結されないように改行で終える）
+	flush := func() {
+		if currentTable == "" {
+			return
+		}
+		// 前の文と同じ行から始まったブロックでは、文の間の空白を除く
+		block := strings.TrimLeft(currentDDL.String(), " \t")
+		if data, exists := locked[currentTable]; exists {
+			block = endLine(block) + data
+			delete(locked, currentTable)
+		}
+		// CREATE OR REPLACE の前の定義は、KeepReplaced の場合だけ残す
+		if previous, exists := ddlContent[currentTable]; exists && replacing {
+			if opts.KeepReplaced {
+				block = previous + block
+			} else if dropped != nil {
+				scanner := opts.newScanner(ctx, strings.NewReader(previous))
+				for scanner.Scan() {
+					drop(scanner.Statement())
+				}
+			}
+		}
+		ddlContent[currentTable] = endLine(block)
+		currentDDL.Reset()
+	}
+	// 文を table のブロックに含める（まだ定義されていない場合は定義されたときに含める）
+	attach := func(table, data string) {
+		switch block, exists := ddlContent[table]; {
+		case table == currentTable:
+			currentDDL.WriteString(data)
+		case exists:
+			ddlContent[table] = block + data
+		default:
+			if _, exists := locked[table]; !exists {
+				lockedOrder = append(lockedOrder, table)
+			}
+			locked[table] += data
+		}
+	}
+	// LOCK TABLES から UNLOCK TABLES までの文をテーブルのブロックに含める
+	unlock := func() {
+		attach(lockTable, lockDDL.String())
+		lockTable = ""
+		lockDDL.Reset()
+	}
+
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+	scanner := opts.newScanner(ctx, r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+
+		if lockTable != "" {
+			lockDDL.WriteString(stmt.Text)
+			if reUnlockTables.MatchString(stmt.Code) {
+				unlock()
+			}
+			continue
+		}
+		if matches := reLockTables.FindStringSubmatch(stmt.Code); matches != nil && !ignored(stmt) {
+			lockTable = db.qualify(qualifiedName(matches))
+			lockDDL.WriteString(stmt.Text)
+			continue
+		}
+
+		// USE と CREATE DATABASE / CREATE SCHEMA は、並び替えた後もテーブルの前にあるようにブロックの先頭に移す
+		if !ignored(stmt) && db.use(stmt.Code) {
+			use = stmt.SQL() + ";\n"
+			continue
+		}
+		// 最初のテーブルより前の文字コードの設定は、最初のテーブルのブロックの先頭に移す（並び替えた後は WriteTables が出力の先頭に置く）
+		if currentTable == "" && !ignored(stmt) && reCharsetSetting.MatchString(stmt.Code) {
+			settings.WriteString(endLine(strings.TrimLeft(stmt.Text, "\r\n")))
+			continue
+		}
+		if matches := reCreateDatabase.FindStringSubmatch(stmt.Code); matches != nil && !ignored(stmt) {
+			text := strings.TrimLeft(stmt.Text, "\r\n")
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			name := strings.ToLower(matches[1])
+			if _, exists := creates[name]; !exists {
+				createOrder = append(createOrder, name)
+			}
+			creates[name] += text
+			continue
+		}
+
+		// create_hypertable などの呼び出しは、対象のテーブルを作成した後に置く
+		if target := hypertableTarget(stmt); target != "" && !ignored(stmt) {
+			attach(db.qualify(target), endLine(stmt.Text))
+			continue
+		}
+
+		// 一時テーブルの文と、一時テーブルへのデータ・ALTER TABLE・CREATE INDEX の文を除く
+		if opts.Temporary == TemporaryExclude && !ignored(stmt) {
+			if matches := reTemporaryTable.FindStringSubmatch(stmt.Code); matches != nil {
+				temporaries[db.qualify(qualifiedName(matches))] = true
+				drop(stmt)
+				continue
+			}
+			if temporaries[db.qualify(statementTarget(stmt.Code))] {
+				drop(stmt)
+				continue
+			}
+		}
+		if opts.Views == ViewsSkip && !ignored(stmt) && reCreateView.MatchString(stmt.Code) {
+			drop(stmt)
+			continue
+		}
+
+		// orderddl:ignore の文は新しいブロックを始めず、直前のブロックにそのまま含める
+		created, isObject := opts.dialectObject(stmt)
+		if matches := reCreateTable.FindStringSubmatch(stmt.Code); len(matches) > 1 && !ignored(stmt) {
+			created, isObject = qualifiedName(matches), true
+		}
+		if isObject {
+			flush()
+			currentTable = db.qualify(created)
+			seen[currentTable] = true
+			_, exists := ddlContent[currentTable]
+			kind, _ := replacedKey(stmt.Code, db)
+			replacing = exists && kind != ""
+			if firstTable == "" {
+				firstTable = currentTable
+			}
+			// 前置きのコメントはブロックの先頭に残す
+			leading := leadingText(stmt)
+			currentDDL.WriteString(unknownDDL.String())
+			unknownDDL.Reset()
+			currentDDL.WriteString(leading)
+			if create, exists := creates[strings.ToLower(SchemaOf(currentTable))]; exists {
+				currentDDL.WriteString(create)
+				delete(creates, strings.ToLower(SchemaOf(currentTable)))
+			}
+			currentDDL.WriteString(use)
+			currentDDL.WriteString(stmt.Text[len(leading):])
+			continue
+		}
+
+		// CREATE OR REPLACE で定義し直したビューや関数は、KeepReplaced でなければ前の定義を除く
+		if _, key := replacedKey(stmt.Code, db); key != "" && !ignored(stmt) {
+			if previous, exists := definitions[key]; exists && !opts.KeepReplaced {
+				removeDefinition(ddlContent, &currentDDL, &unknownDDL, currentTable, previous.table, previous.stmt.Text)
+				drop(previous.stmt)
+			}
+			definitions[key] = definition{table: currentTable, stmt: stmt}
+		}
+
+		// ALTER TABLE・CREATE INDEX・COMMENT ON TABLE は、ほかのテーブルのブロックにあっても対象のテーブルのブロックに含める
+		if target := blockTarget(stmt.Code); target != "" && !ignored(stmt) {
+			target = db.qualify(target)
+			if !seen[target] {
+				if resolved := opts.resolve(target, "", seen); seen[resolved] {
+					target = resolved
+				}
+			}
+			switch {
+			case target == currentTable:
+			case seen[target]:
+				ddlContent[target] += endLine(stmt.Text)
+				continue
+			default:
+				pending = append(pending, pendingStatement{target: target, block: currentTable, text: endLine(stmt.Text)})
+				continue
+			}
+		}
+
+		switch {
+		case currentTable != "":
+			currentDDL.WriteString(stmt.Text)
+		case opts.Unknown != "" && (stmt.Directive || stmt.executable() && opts.unknown(stmt)):
+			unknownDDL.WriteString(endLine(stmt.Text))
+		case ignored(stmt) && stmt.executable(), opts.Views == ViewsPassthrough && reCreateView.MatchString(stmt.Code):
+			// orderddl:ignore の文とそのまま残すビューは、最初のテーブルより前でも最初のテーブルのブロックの先頭に残す
+			unknownDDL.WriteString(endLine(stmt.Text))
+		default:
+			// 最初のテーブルより前の文は出力しない（指定に従って除いた文ではないため dropped に含めない）
+		}
+	}
+
+	// UNLOCK TABLES がない場合や、ロックしたテーブルや関数の呼び出しの対象が定義されていない場合は最後のブロックに含める
+	// （修飾されていない名前などは SearchPath と Resolution に従って定義されたテーブルに解決する）
+	if lockTable != "" {
+		currentDDL.WriteString(lockDDL.String())
+	}
+	flush()
+	defined := make(map[string]bool, len(ddlContent))
+	for table := range ddlContent {
+		defined[table] = true
+	}
+	for _, table := range lockedOrder {
+		data, exists := locked[table]
+		if !exists {
+			continue
+		}
+		if resolved := opts.resolve(table, "", defined); resolved != table && defined[resolved] {
+			ddlContent[resolved] += data
+		} else if currentTable != "" {
+			ddlContent[currentTable] += data
+		}
+		delete(locked, table)
+	}
+	// 後から定義されたテーブルへの文はそのテーブルのブロックに、定義されなかったテーブルへの文は元のブロックに含める
+	// （最初のテーブルより前にあった文は、ほかの文と同じく出力しない）
+	for _, p := range pending {
+		if resolved := opts.resolve(p.target, "", defined); defined[resolved] {
+			ddlContent[resolved] += p.text
+		} else if p.block != "" {
+			ddlContent[p.block] += p.text
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var unused strings.Builder
+	for _, name := range createOrder {
+		unused.WriteString(creates[name])
+	}
+	if firstTable != "" && settings.Len()+unused.Len() > 0 {
+		ddlContent[firstTable] = settings.String() + unused.String() + ddlContent[firstTable]
+	}
+	return ddlContent, nil
+}
+
+// ブロックの中から置き換えられた定義の文を除く（table が空の場合は最初のテーブルより前の文）
+func removeDefinition(ddlContent map[string]string, current, unknown *strings.Builder, currentTable, table, text string) {
+	var builder *strings.Builder
+	switch table {
+	case "":
+		builder = unknown
+	case currentTable:
+		builder = current
+	default:
+		ddlContent[table] = strings.Replace(ddlContent[table], text, "", 1)
+		return
+	}
+	s := strings.Replace(builder.String(), text, "", 1)
+	builder.Reset()
+	builder.WriteString(s)
+}
+
+// データ・ALTER TABLE・CREATE INDEX の文の対象のテーブル（それ以外の文では空文字）
+func statementTarget(code string) string {
+	switch {
+	case reData.MatchString(code):
+		if matches := reDataTable.FindStringSubmatch(code); matches != nil {
+			return qualifiedName(matches)
+		}
+	case reAlterTable.MatchString(code):
+		return qualifiedName(reAlterTable.FindStringSubmatch(code))
+	case reCreateIndex.MatchString(code):
+		return qualifiedName(reCreateIndex.FindStringSubmatch(code)[2:])
+	}
+	return ""
+}
+
+// 対象のテーブルのブロックに含める ALTER TABLE・CREATE INDEX・COMMENT ON TABLE の文の対象のテーブル（それ以外の文では空文字）
+func blockTarget(code string) string {
+	switch {
+	case reAlterTable.MatchString(code):
+		return qualifiedName(reAlterTable.FindStringSubmatch(code))
+	case reCreateIndex.MatchString(code):
+		return qualifiedName(reCreateIndex.FindStringSubmatch(code)[2:])
+	case reCommentOnTable.MatchString(code):
+		return qualifiedName(reCommentOnTable.FindStringSubmatch(code))
+	}
+	return ""
+}
+
+// 改行で終わるようにする
+func endLine(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
+
+// WriteTables は指定した順序でテーブルのDDLを書き出す
+func WriteTables(w io.Writer, sortedTables []string, ddlContent map[string]string) error {
+	writer := bufio.NewWriter(w)
+	for _, ddl := range scopedBlocks(sortedTables, ddlContent) {
+		_, err := writer.WriteString(ddl)
+		if err != nil {
+			return fmt.Errorf("書き込みに失敗しました: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("書き込みに失敗しました: %w", err)
+	}
+	return nil
+}
+
+// Order はDDL文字列を依存関係の順に並び替えた結果を返す
+func Order(ddl string, opts Options) (string, error) {
+	return OrderContext(context.Background(), ddl, opts)
+}
+
+// OrderContext は ctx が取り消された場合に途中で終える Order
+func OrderContext(ctx context.Context, ddl string, opts Options) (string, error) {
+	result, err := AnalyzeContext(ctx, ddl, opts)
+	if err != nil {
+		return "", err
+	}
+	return OrderResult(ctx, ddl, result, opts)
+}
+
+// OrderResult は AnalyzeContext で解析済みの result の作成順に ddl を並び替えた結果を返す（解析をやり直さない）
+func OrderResult(ctx context.Context, ddl string, result *Result, opts Options) (string, error) {
+	sortedTables := result.Sorted
+
+	ddlContent, err := SplitWithOptions(ctx, strings.NewReader(ddl), opts)
+	if err != nil {
+		return "", err
+	}
+	ddlContent = PlaceUnknown(ddlContent, sortedTables, opts)
+	ddlContent = NormalizeTerminators(ddlContent, opts)
+
+	var out strings.Builder
+	if err := WriteTables(&out, sortedTables, ddlContent); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Cycles は循環依存しているテーブルの組を返す（Tarjan の強連結成分分解）
+func Cycles(graph map[string][]string) [][]string {
+	// 訪問順を安定させるためにノードを並べる
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var strongConnect func(node string)
+	strongConnect = func(node string) {
+		indices[node] = index
+		lowlink[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range graph[node] {
+			if _, visited := indices[next]; !visited {
+				strongConnect(next)
+				lowlink[node] = min(lowlink[node], lowlink[next])
+			} else if onStack[next] {
+				lowlink[node] = min(lowlink[node], indices[next])
+			}
+		}
+
+		if lowlink[node] != indices[node] {
+			return
+		}
+
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+
+		// 自己参照のみのテーブルも循環として扱う
+		if len(component) > 1 || contains(graph[node], node) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := indices[node]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	return cycles
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var reLevelComment = regexp.MustCompile(`^(?:-- orderddl: level \d+.*\n)+`)
+
+// Annotate は各テーブルのブロックの先頭に段数と依存先のコメントを付けたDDLを返す。
+// 以前に付けたコメントは置き換える
+func Annotate(ddlContent map[string]string, sortedTables []string, graph map[string][]string) map[string]string {
+	levels := Levels(sortedTables, graph)
+	deps := Dependencies(graph)
+
+	annotated := make(map[string]string, len(ddlContent))
+	for table, block := range ddlContent {
+		header := fmt.Sprintf("-- orderddl: level %d", levels[table])
+		if len(deps[table]) > 0 {
+			header += " (depends on: " + strings.Join(deps[table], ", ") + ")"
+		}
+		annotated[table] = header + "\n" + reLevelComment.ReplaceAllString(block, "")
+	}
+	return annotated
+}
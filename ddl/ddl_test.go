@@ -0,0 +1,205 @@
+package ddl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// out に parts がこの順に現れることを確かめる
+func assertInOrder(t *testing.T, out string, parts ...string) {
+	t.Helper()
+	rest := out
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			t.Fatalf("%q が順に含まれていません:\n%s", part, out)
+		}
+		rest = rest[i+len(part):]
+	}
+}
+
+func mustDialect(t *testing.T, name string) Dialect {
+	t.Helper()
+	d, err := LookupDialect(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dialect string
+		views   ViewPolicy
+		want    []string
+	}{
+		{
+			name: "参照先を先に作成する",
+			src:  "CREATE TABLE c (\n  id int,\n  p int,\n  FOREIGN KEY (p) REFERENCES p(id)\n);\nCREATE TABLE p (\n  id int PRIMARY KEY\n);\n",
+			want: []string{"CREATE TABLE p", "CREATE TABLE c"},
+		},
+		{
+			name: "自己参照は循環として扱わない",
+			src:  "CREATE TABLE employees (id int PRIMARY KEY, manager_id int REFERENCES employees(id));\nCREATE TABLE x (e int REFERENCES employees(id));\n",
+			want: []string{"CREATE TABLE employees", "CREATE TABLE x"},
+		},
+		{
+			name: "スキーマで修飾したテーブル",
+			src:  "CREATE TABLE app.c (\n  FOREIGN KEY (p) REFERENCES app.p(id)\n);\nCREATE TABLE app.p (\n  id int\n);\n",
+			want: []string{"CREATE TABLE app.p", "CREATE TABLE app.c"},
+		},
+		{
+			name: "コメントと文字列リテラルの中の外部キーは依存関係にしない",
+			src:  "CREATE TABLE a (\n  FOREIGN KEY (b_id) REFERENCES b(id)\n);\nCREATE TABLE b (\n  id int, -- FOREIGN KEY (a_id) REFERENCES a(id)\n  s text DEFAULT 'FOREIGN KEY (a_id) REFERENCES a(id)'\n);\n",
+			want: []string{"CREATE TABLE b", "CREATE TABLE a"},
+		},
+		{
+			name: "orderddl:ignore の文はテーブルにせず、直前のテーブルのブロックに残す",
+			src:  "CREATE TABLE c (\n  FOREIGN KEY (p_id) REFERENCES p(id)\n);\n-- orderddl:ignore\nCREATE TABLE legacy (\n  id int\n);\nCREATE TABLE p (\n  id int,\n  FOREIGN KEY (l_id) REFERENCES legacy(id)\n);\n",
+			want: []string{"CREATE TABLE p", "CREATE TABLE c", "-- orderddl:ignore\nCREATE TABLE legacy"},
+		},
+		{
+			name: "最初のテーブルより前の orderddl:ignore の文は残す",
+			src:  "-- orderddl:ignore\nCREATE TABLE legacy (id int);\nCREATE TABLE c (id int, p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			want: []string{"CREATE TABLE p", "CREATE TABLE legacy", "CREATE TABLE c"},
+		},
+		{
+			name:  "-views passthrough では最初のテーブルより前のビューを残す",
+			src:   "CREATE VIEW v AS SELECT 1;\nCREATE TABLE c (id int, p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			views: ViewsPassthrough,
+			want:  []string{"CREATE TABLE p", "CREATE VIEW v", "CREATE TABLE c"},
+		},
+		{
+			name:    "postgres ではバックスラッシュで文字列リテラルを閉じる",
+			src:     "CREATE TABLE c (path text DEFAULT 'C:\\', p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			dialect: "postgres",
+			want:    []string{"CREATE TABLE p", "CREATE TABLE c"},
+		},
+		{
+			name:    "postgres の E'...' ではバックスラッシュがエスケープになる",
+			src:     "CREATE TABLE c (s text DEFAULT E'it\\'s;', p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			dialect: "postgres",
+			want:    []string{"CREATE TABLE p", "CREATE TABLE c"},
+		},
+		{
+			name:    "mysql ではバックスラッシュがエスケープになる",
+			src:     "CREATE TABLE c (s varchar(10) DEFAULT 'it\\'s;', p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			dialect: "mysql",
+			want:    []string{"CREATE TABLE p", "CREATE TABLE c"},
+		},
+		{
+			name: "引用符で囲まない名前は大文字と小文字を区別せずに参照する",
+			src:  "CREATE TABLE c (id int, a_id int REFERENCES A(id));\nCREATE TABLE a (id int PRIMARY KEY);\n",
+			want: []string{"CREATE TABLE a", "CREATE TABLE c"},
+		},
+		{
+			name: "シノニムは対象の後、シノニムを使うテーブルの前に置く",
+			src:  "CREATE TABLE q (id int);\nCREATE SYNONYM s FOR app.target;\nCREATE TABLE r (id int, t int REFERENCES s(id));\nCREATE TABLE app.target (id int PRIMARY KEY);\n",
+			want: []string{"CREATE TABLE app.target", "CREATE SYNONYM s", "CREATE TABLE r"},
+		},
+		{
+			name: "ほかのテーブルのブロックにある CREATE INDEX と COMMENT ON TABLE は対象のテーブルのブロックに含める",
+			src:  "CREATE TABLE p (id int PRIMARY KEY);\nCREATE TABLE c (id int, q int REFERENCES q(id));\nCREATE INDEX p_idx ON p (id);\nCOMMENT ON TABLE p IS 'p';\nCREATE TABLE q (id int PRIMARY KEY, p int REFERENCES p(id));\n",
+			want: []string{"CREATE TABLE p", "CREATE INDEX p_idx", "COMMENT ON TABLE p", "CREATE TABLE q", "CREATE TABLE c"},
+		},
+		{
+			name: "対象のテーブルより前の ALTER TABLE は対象のテーブルのブロックに含める",
+			src:  "ALTER TABLE p ADD COLUMN y int;\nCREATE TABLE c (id int, p int REFERENCES p(id));\nALTER TABLE p ADD COLUMN x int;\nALTER TABLE ext ADD COLUMN z int;\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			want: []string{"CREATE TABLE p", "ALTER TABLE p ADD COLUMN y", "ALTER TABLE p ADD COLUMN x", "CREATE TABLE c", "ALTER TABLE ext"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Views: tt.views}
+			if tt.dialect != "" {
+				opts.Dialect = mustDialect(t, tt.dialect)
+			}
+			out, err := Order(tt.src, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertInOrder(t, out, tt.want...)
+		})
+	}
+
+	_, err := Order("CREATE TABLE a (\n  FOREIGN KEY (b_id) REFERENCES b(id)\n);\nCREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n", Options{})
+	if !errors.Is(err, ErrCycle) {
+		t.Errorf("循環依存の err = %v, want ErrCycle", err)
+	}
+}
+
+func TestTemporaryTables(t *testing.T) {
+	src := "CREATE TABLE c (id int, p int REFERENCES p(id));\nCREATE TEMPORARY TABLE tmp (id int);\nCREATE INDEX ix_tmp ON tmp (id);\nCREATE TABLE p (id int PRIMARY KEY);\n"
+
+	result, err := Analyze(src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Sorted, ","); got != "p,c" {
+		t.Errorf("Sorted = %s, want p,c", got)
+	}
+
+	out, err := Order(src, Options{Temporary: TemporaryKeep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertInOrder(t, out, "CREATE TABLE p", "CREATE TABLE c", "CREATE TEMPORARY TABLE tmp", "CREATE INDEX ix_tmp")
+
+	out, err = Order(src, Options{Temporary: TemporaryExclude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "tmp") {
+		t.Errorf("-temporary exclude で一時テーブルの文が残っています:\n%s", out)
+	}
+}
+
+func TestOrderStatementsOnOneLine(t *testing.T) {
+	src := "CREATE TABLE c (id int, p int REFERENCES p(id)); CREATE TABLE p (id int PRIMARY KEY);\n"
+	want := "CREATE TABLE p (id int PRIMARY KEY);\nCREATE TABLE c (id int, p int REFERENCES p(id));\n"
+	out, err := Order(src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != want {
+		t.Errorf("Order = %q, want %q", out, want)
+	}
+}
+
+func TestAnalyzeCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		cycles [][]string
+	}{
+		{
+			name: "自己参照",
+			src:  "CREATE TABLE a (id int PRIMARY KEY, parent_id int REFERENCES a(id));\n",
+		},
+		{
+			name:   "2つのテーブルの循環",
+			src:    "CREATE TABLE a (id int PRIMARY KEY, b_id int REFERENCES b(id));\nCREATE TABLE b (id int PRIMARY KEY, a_id int REFERENCES a(id));\n",
+			cycles: [][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Analyze(tt.src, Options{})
+			if tt.cycles == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrCycle) {
+				t.Fatalf("ErrCycle が返りませんでした: %v", err)
+			}
+			if got := result.Cycles; len(got) != len(tt.cycles) || strings.Join(got[0], ",") != strings.Join(tt.cycles[0], ",") {
+				t.Errorf("Cycles = %v, want %v", got, tt.cycles)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package ddl
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dependency は入力の SQL では表せない依存関係（トリガーで保つ関係など、Child を Parent より後に作成する）
+type Dependency struct {
+	Child  string
+	Parent string
+}
+
+func (d Dependency) String() string {
+	return d.Child + ":" + d.Parent
+}
+
+// ParseDependency は「子:親」の形式の指定を解析する（テーブル名を囲む引用符は除く）
+func ParseDependency(s string) (Dependency, error) {
+	child, parent, found := strings.Cut(s, ":")
+	child, parent = unquoteName(child), unquoteName(parent)
+	if !found || child == "" || parent == "" {
+		return Dependency{}, fmt.Errorf("依存関係は 子:親 の形式で指定してください: %s", s)
+	}
+	return Dependency{Child: child, Parent: parent}, nil
+}
+
+// ReadDependencies は child,parent[,constraint] の行の CSV（-format csv の出力など）から依存関係を読む。
+// 1行目が child,parent の見出しの場合は読み飛ばし、# で始まる行と3列目以降は無視する
+func ReadDependencies(r io.Reader) ([]Dependency, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var deps []Dependency
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return deps, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("依存関係の CSV を読み込めませんでした: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%d行目: 依存関係は 子,親 の形式で指定してください", line)
+		}
+		child, parent := unquoteName(record[0]), unquoteName(record[1])
+		if first && strings.EqualFold(child, "child") && strings.EqualFold(parent, "parent") {
+			continue
+		}
+		if child == "" || parent == "" {
+			return nil, fmt.Errorf("%d行目: 依存関係は 子,親 の形式で指定してください", line)
+		}
+		deps = append(deps, Dependency{Child: child, Parent: parent})
+	}
+}
+
+// Options.ExtraDependencies の依存関係（自己参照は除く）
+func (o Options) extraEdges() []Edge {
+	var edges []Edge
+	for _, dep := range o.ExtraDependencies {
+		if dep.Child != dep.Parent {
+			edges = append(edges, Edge{Parent: dep.Parent, Child: dep.Child, Extra: true})
+		}
+	}
+	return edges
+}
+
+// Options.IgnoredDependencies に一致する依存関係を除き、残した依存関係と除いた依存関係、一致するものがなかった指定を返す。
+// 指定の修飾されていないテーブル名は SearchPath と Resolution に従って解決する
+func (o Options) ignoreEdges(tables []string, edges []Edge) (kept, ignored []Edge, unused []Dependency) {
+	if len(o.IgnoredDependencies) == 0 {
+		return edges, nil, nil
+	}
+	defined := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		defined[table] = true
+	}
+	resolved := make([]Dependency, len(o.IgnoredDependencies))
+	matched := make([]bool, len(o.IgnoredDependencies))
+	for i, dep := range o.IgnoredDependencies {
+		resolved[i] = Dependency{Child: o.resolve(dep.Child, dep.Parent, defined), Parent: o.resolve(dep.Parent, dep.Child, defined)}
+	}
+
+	for _, edge := range edges {
+		ignore := false
+		for i, dep := range resolved {
+			if edge.Child == dep.Child && edge.Parent == dep.Parent {
+				ignore, matched[i] = true, true
+			}
+		}
+		if ignore {
+			ignored = append(ignored, edge)
+		} else {
+			kept = append(kept, edge)
+		}
+	}
+	for i, dep := range o.IgnoredDependencies {
+		if !matched[i] {
+			unused = append(unused, dep)
+		}
+	}
+	return kept, ignored, unused
+}
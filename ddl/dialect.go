@@ -0,0 +1,194 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Dialect はデータベースごとの構文の違い。RegisterDialect で登録すると名前で選べるようになる
+type Dialect interface {
+	// Name は -dialect などで指定する名前
+	Name() string
+	// QuoteIdentifier は識別子を引用符で囲む
+	QuoteIdentifier(name string) string
+	// Terminator は文の既定の終端文字
+	Terminator() string
+	// Dependencies は REFERENCES 以外に、文が依存するテーブルを返す（文を含むブロックのテーブルが依存する）
+	Dependencies(stmt Statement) []string
+}
+
+// ObjectDialect は CREATE TABLE 以外の文でも、テーブルと同じように並び替えるオブジェクトを定義できる方言
+type ObjectDialect interface {
+	Dialect
+	// Object は文が新しいオブジェクトを定義する場合にその名前と true を返す（文からそのオブジェクトのブロックが始まる）
+	Object(stmt Statement) (string, bool)
+}
+
+// TableDialect は方言に固有の CREATE TABLE の書き方（CREATE OR REPLACE TABLE など）を解釈できる方言
+type TableDialect interface {
+	Dialect
+	// CreateTable は文がテーブルを定義する場合にその名前と true を返す（外部キーは CREATE TABLE と同じように取り出す）
+	CreateTable(stmt Statement) (string, bool)
+}
+
+// InformationalDialect は外部キーをデータベースが検査しない方言
+type InformationalDialect interface {
+	Dialect
+	// InformationalConstraints が true の場合は、NOT ENFORCED などの外部キーも -soft-constraints にかかわらず作成順序に反映する
+	InformationalConstraints() bool
+}
+
+// EscapeDialect は文字列リテラルの中のバックスラッシュをエスケープとする方言（実装しない方言では引用符を重ねる書き方だけをエスケープとし、E'...' の中だけバックスラッシュを解釈する）
+type EscapeDialect interface {
+	Dialect
+	BackslashEscapes() bool
+}
+
+// FoldDialect は引用符で囲まない識別子の大文字と小文字をそろえる方言（実装しない方言ではそのままにする）
+type FoldDialect interface {
+	Dialect
+	// FoldIdentifier は引用符で囲まずに書いた識別子を、データベースが扱う名前にする
+	FoldIdentifier(name string) string
+}
+
+// ReferencedKeyDialect は外部キーが参照できるカラムの組を決める方言（実装しない方言では主キーまたは一意制約が必要）
+type ReferencedKeyDialect interface {
+	Dialect
+	// ReferencedKey は table の columns を外部キーで参照できるかどうかを返す
+	ReferencedKey(table *Table, columns []string) bool
+}
+
+// RuleDialect や Plugin で包んだ方言を Base から順にたどり、T を実装する方言を探す
+func findDialect[T any](d Dialect) (T, bool) {
+	for d != nil {
+		if found, ok := d.(T); ok {
+			return found, true
+		}
+		switch wrapper := d.(type) {
+		case *RuleDialect:
+			d = wrapper.Base
+		case *Plugin:
+			d = wrapper.Base
+		default:
+			d = nil
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = make(map[string]Dialect)
+)
+
+// RegisterDialect は方言を登録する。同じ名前で2回登録した場合は panic する
+func RegisterDialect(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	name := strings.ToLower(d.Name())
+	if _, exists := dialects[name]; exists {
+		panic("ddl: 方言が2回登録されました: " + name)
+	}
+	dialects[name] = d
+}
+
+// LookupDialect は登録された方言を名前で探す（大文字小文字は区別しない）
+func LookupDialect(name string) (Dialect, error) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	if d, exists := dialects[strings.ToLower(name)]; exists {
+		return d, nil
+	}
+	return nil, fmt.Errorf("不明な方言です: %s（%s）", name, strings.Join(dialectNames(), ", "))
+}
+
+// DialectNames は登録された方言の名前を名前順に返す
+func DialectNames() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	return dialectNames()
+}
+
+func dialectNames() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterDialect(mysqlDialect{})
+	RegisterDialect(postgresDialect{})
+}
+
+// MySQL / MariaDB
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) Terminator() string { return ";" }
+
+func (mysqlDialect) Dependencies(Statement) []string { return nil }
+
+func (mysqlDialect) BackslashEscapes() bool { return true }
+
+// InnoDB は参照先のカラムが先頭から同じ順に並ぶインデックスがあれば、一意でなくても外部キーを作成できる
+func (mysqlDialect) ReferencedKey(table *Table, columns []string) bool {
+	for _, key := range table.Keys {
+		if !key.Partial && len(key.Columns) >= len(columns) && sameColumnOrder(key.Columns[:len(columns)], columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// PostgreSQL
+type postgresDialect struct{}
+
+var (
+	reTableDefinition = regexp.MustCompile(TABLE_PATTERN)
+	// CREATE TABLE ... INHERITS (親, ...)
+	reInherits = regexp.MustCompile(`(?is)\)\s*INHERITS\s*\(([^()]*)\)`)
+	// CREATE TABLE ... PARTITION OF 親
+	rePartitionOf = regexp.MustCompile(`(?i)\bPARTITION\s+OF\s+` + QUALIFIED_NAME_PATTERN)
+)
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) Terminator() string { return ";" }
+
+func (postgresDialect) FoldIdentifier(name string) string { return foldASCII(name, unicode.ToLower) }
+
+// 継承元とパーティションの親は子より先に作成する必要がある
+func (postgresDialect) Dependencies(stmt Statement) []string {
+	if !reTableDefinition.MatchString(stmt.Code) {
+		return nil
+	}
+	var deps []string
+	if matches := rePartitionOf.FindStringSubmatch(stmt.Code); matches != nil {
+		deps = append(deps, qualifiedName(matches))
+	}
+	if matches := reInherits.FindStringSubmatch(stmt.Code); matches != nil {
+		for _, parent := range strings.Split(matches[1], ",") {
+			if parent = strings.Trim(strings.TrimSpace(parent), `"`); parent != "" {
+				deps = append(deps, strings.ReplaceAll(parent, `"."`, "."))
+			}
+		}
+	}
+	return deps
+}
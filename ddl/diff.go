@@ -0,0 +1,79 @@
+package ddl
+
+import (
+	"strings"
+)
+
+// SchemaDiff は2つのDDLの間で追加・削除されたテーブルと外部キー
+type SchemaDiff struct {
+	// 追加されたテーブル（新しいDDLでの作成順）
+	AddedTables []string
+	// 削除されたテーブル（古いDDLでの作成順）
+	RemovedTables []string
+	// 両方に存在するテーブルに追加・削除された外部キー
+	AddedForeignKeys   []ForeignKey
+	RemovedForeignKeys []ForeignKey
+}
+
+// Diff は古いDDLと新しいDDLを比較する
+func Diff(oldSrc, newSrc string, opts Options) (*SchemaDiff, error) {
+	oldResult, err := Analyze(oldSrc, opts)
+	if err != nil {
+		return nil, err
+	}
+	newResult, err := Analyze(newSrc, opts)
+	if err != nil {
+		return nil, err
+	}
+	oldFKs, err := ForeignKeys(strings.NewReader(oldSrc))
+	if err != nil {
+		return nil, err
+	}
+	newFKs, err := ForeignKeys(strings.NewReader(newSrc))
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SchemaDiff{}
+	for _, table := range newResult.Sorted {
+		if !contains(oldResult.Tables, table) {
+			diff.AddedTables = append(diff.AddedTables, table)
+		}
+	}
+	for _, table := range oldResult.Sorted {
+		if !contains(newResult.Tables, table) {
+			diff.RemovedTables = append(diff.RemovedTables, table)
+		}
+	}
+
+	// 追加・削除されたテーブルの外部キーはテーブルごと作成・削除される
+	kept := func(fk ForeignKey) bool {
+		return contains(oldResult.Tables, fk.Table) && contains(newResult.Tables, fk.Table)
+	}
+	diff.AddedForeignKeys = missingForeignKeys(newFKs, oldFKs, kept)
+	diff.RemovedForeignKeys = missingForeignKeys(oldFKs, newFKs, kept)
+	return diff, nil
+}
+
+// fks のうち others に含まれない外部キーを返す
+func missingForeignKeys(fks, others []ForeignKey, filter func(ForeignKey) bool) []ForeignKey {
+	seen := make(map[string]bool)
+	for _, fk := range others {
+		seen[fk.key()] = true
+	}
+
+	var missing []ForeignKey
+	for _, fk := range fks {
+		if !filter(fk) || seen[fk.key()] {
+			continue
+		}
+		seen[fk.key()] = true
+		missing = append(missing, fk)
+	}
+	return missing
+}
+
+// Empty は差分がないかどうかを返す
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.AddedForeignKeys) == 0 && len(d.RemovedForeignKeys) == 0
+}
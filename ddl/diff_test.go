@@ -0,0 +1,38 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldSrc := "CREATE TABLE a (id int PRIMARY KEY);\nCREATE TABLE b (id int, a_id int REFERENCES a(id));\nCREATE TABLE old (id int);\n"
+	newSrc := "CREATE TABLE c (id int PRIMARY KEY);\nCREATE TABLE b (id int, c_id int REFERENCES c(id));\nCREATE TABLE a (id int PRIMARY KEY, c_id int REFERENCES c(id));\nCREATE TABLE d (b_id int REFERENCES b(id));\n"
+	diff, err := Diff(oldSrc, newSrc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreignKeys := func(fks []ForeignKey) string {
+		var s []string
+		for _, fk := range fks {
+			s = append(s, fk.Table+"→"+fk.RefTable)
+		}
+		return strings.Join(s, ",")
+	}
+	for _, check := range []struct{ name, got, want string }{
+		{"AddedTables", strings.Join(diff.AddedTables, ","), "c,d"},
+		{"RemovedTables", strings.Join(diff.RemovedTables, ","), "old"},
+		{"AddedForeignKeys", foreignKeys(diff.AddedForeignKeys), "b→c,a→c"},
+		{"RemovedForeignKeys", foreignKeys(diff.RemovedForeignKeys), "b→a"},
+	} {
+		if check.got != check.want {
+			t.Errorf("%s = %s, want %s", check.name, check.got, check.want)
+		}
+	}
+	if diff.Empty() {
+		t.Error("Empty = true")
+	}
+	if same, err := Diff(oldSrc, oldSrc, Options{}); err != nil || !same.Empty() {
+		t.Errorf("同じDDLの差分 = %+v, %v", same, err)
+	}
+}
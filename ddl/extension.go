@@ -0,0 +1,114 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CREATE EXTENSION [IF NOT EXISTS] 名前 ...
+const EXTENSION_PATTERN = `(?is)^\s*CREATE\s+EXTENSION\s+(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?`
+
+var reCreateExtension = regexp.MustCompile(EXTENSION_PATTERN)
+
+// 拡張が定義する型と、その拡張の名前（Postgres のよく使われる拡張だけ）
+var extensionTypes = map[string]string{
+	"geometry":     "postgis",
+	"geography":    "postgis",
+	"box2d":        "postgis",
+	"box3d":        "postgis",
+	"raster":       "postgis_raster",
+	"topogeometry": "postgis_topology",
+	"hstore":       "hstore",
+	"citext":       "citext",
+	"ltree":        "ltree",
+	"lquery":       "ltree",
+	"cube":         "cube",
+	"earth":        "earthdistance",
+	"vector":       "vector",
+	"halfvec":      "vector",
+	"sparsevec":    "vector",
+	"isbn":         "isn",
+	"isbn13":       "isn",
+	"ean13":        "isn",
+	"issn":         "isn",
+	"upc":          "isn",
+	"lo":           "lo",
+	"seg":          "seg",
+	"semver":       "semver",
+	"h3index":      "h3",
+	"ip4r":         "ip4r",
+}
+
+// MissingExtension は入力のテーブルが型を使っているが、CREATE EXTENSION が入力にない拡張
+type MissingExtension struct {
+	Name string
+	// 拡張の型を使っているテーブル（入力に現れた順）
+	Tables []string
+}
+
+// 文が作成する拡張の名前
+func extensionDefinition(code string) (string, bool) {
+	if matches := reCreateExtension.FindStringSubmatch(code); matches != nil {
+		return strings.ToLower(matches[1]), true
+	}
+	return "", false
+}
+
+// 文のカラムなどの型が必要とする拡張の名前（MySQL の geometry などの組み込みの型と区別できないため、MySQL の方言では調べない）
+func (o Options) extensionUses(code string) []string {
+	if o.Dialect != nil && o.Dialect.Name() == "mysql" {
+		return nil
+	}
+	var names []string
+	for _, typ := range columnTypes(code) {
+		name := strings.ToLower(typ[strings.LastIndexByte(typ, '.')+1:])
+		if extension, exists := extensionTypes[name]; exists && !contains(names, extension) {
+			names = append(names, extension)
+		}
+	}
+	return names
+}
+
+// 拡張の型を使う文の依存関係の候補のうち、CREATE EXTENSION が入力にない拡張を返す
+func missingExtensions(uses []Edge, named map[string]bool) []MissingExtension {
+	var missing []MissingExtension
+	index := make(map[string]int)
+	for _, use := range uses {
+		if named[use.Parent] {
+			continue
+		}
+		i, exists := index[use.Parent]
+		if !exists {
+			i = len(missing)
+			index[use.Parent] = i
+			missing = append(missing, MissingExtension{Name: use.Parent})
+		}
+		if !contains(missing[i].Tables, use.Child) {
+			missing[i].Tables = append(missing[i].Tables, use.Child)
+		}
+	}
+	return missing
+}
+
+// AddExtensions は入力にない拡張を作成する文を src の末尾に加える（型を使うテーブルより前に並び替えられ、入力の行番号は変わらない）。
+// 最後の文に終端文字がない場合は終端文字の行を加える
+func AddExtensions(src string, missing []MissingExtension, opts Options) string {
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	var last Statement
+	for scanner.Scan() {
+		last = scanner.Statement()
+	}
+	terminator := scanner.delimiter
+
+	var out strings.Builder
+	out.WriteString(endLine(src))
+	if last.executable() && last.Terminator == "" {
+		out.WriteString(terminator + "\n")
+	}
+	for _, extension := range missing {
+		fmt.Fprintf(&out, "CREATE EXTENSION IF NOT EXISTS %s%s\n", extension.Name, terminator)
+	}
+	return out.String()
+}
@@ -0,0 +1,397 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ForeignKey は外部キー制約
+type ForeignKey struct {
+	// 制約名（CONSTRAINT で指定された場合）
+	Name string `json:"name,omitempty"`
+	// 参照元のテーブルとカラム
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	// 参照先のテーブルとカラム
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns,omitempty"`
+	// 参照動作（ON DELETE / ON UPDATE、指定がない場合は空）
+	OnDelete string `json:"on_delete,omitempty"`
+	OnUpdate string `json:"on_update,omitempty"`
+	// NOT VALID（Postgres）や NOT ENFORCED が指定された制約
+	NotValid    bool `json:"not_valid,omitempty"`
+	NotEnforced bool `json:"not_enforced,omitempty"`
+	// DEFERRABLE / INITIALLY DEFERRED の指定
+	Deferrable        bool `json:"deferrable,omitempty"`
+	InitiallyDeferred bool `json:"initially_deferred,omitempty"`
+	// 制約を定義している入力のテキスト（CREATE TABLE の1項目、ALTER TABLE では文全体）
+	Text string `json:"-"`
+	// 制約を取り除く場合の範囲（Analyze で取り出した外部キーだけが入力でのバイト位置を持つ）
+	clause clause
+	// 1つにまとめた同じ外部キーの制約
+	duplicates []ForeignKey
+}
+
+// 1つにまとめた同じ外部キーの制約の範囲
+func (fk ForeignKey) duplicateClauses() []clause {
+	clauses := make([]clause, 0, len(fk.duplicates))
+	for _, duplicate := range fk.duplicates {
+		clauses = append(clauses, duplicate.clause)
+	}
+	return clauses
+}
+
+// 外部キーの制約の範囲
+type clause struct {
+	// 取り除く範囲（表制約は区切りのカンマを含め、前後の空白とコメントは含めない）
+	start, end int
+	// 制約の定義（ALTER TABLE の ADD と ; は含めない）
+	definition [2]int
+	// 列制約のカラム名（引用符を含む、表制約では空）
+	column string
+	// 文全体（ALTER TABLE ... ADD CONSTRAINT だけの文）
+	statement bool
+	// Analyze が入力でのバイト位置にしたもの
+	located bool
+}
+
+// ALTER TABLE の項目の先頭の ADD
+var reAddKeyword = regexp.MustCompile(`(?i)^ADD\s+`)
+
+// start から end までの外部キーの制約の範囲を返す（column は列定義の中の REFERENCES 以降の場合のカラム名）
+func newClause(code string, start, end int, column string) clause {
+	c := clause{start: start, end: end, column: column}
+	switch {
+	case column != "":
+	case start == 0:
+		// ALTER TABLE の最初の項目は ALTER TABLE テーブル名 の後ろから
+		if loc := reAlterTable.FindStringIndex(code); loc != nil {
+			start = loc[1]
+		}
+		if end == len(code) {
+			c.statement = true
+		} else {
+			c.start, c.end = start, end+1
+		}
+	case code[start-1] == ',':
+		c.start = start - 1
+	case end < len(code) && code[end] == ',':
+		c.end = end + 1
+	}
+	// ALTER TABLE の最後の項目は文の終端文字を含めない
+	c.start, c.end = trimSpaceRange(code, c.start, c.end)
+	if !c.statement && c.end > c.start && code[c.end-1] == ';' {
+		c.start, c.end = trimSpaceRange(code, c.start, c.end-1)
+	}
+
+	start, end = trimSpaceRange(code, start, end)
+	if end > start && code[end-1] == ';' {
+		start, end = trimSpaceRange(code, start, end-1)
+	}
+	if loc := reAddKeyword.FindStringIndex(code[start:end]); loc != nil {
+		start += loc[1]
+	}
+	c.definition = [2]int{start, end}
+	return c
+}
+
+// 範囲の前後の空白を除く
+func trimSpaceRange(code string, start, end int) (int, int) {
+	for start < end && isSpaceByte(code[start]) {
+		start++
+	}
+	for end > start && isSpaceByte(code[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+// 入力でのバイト位置にする
+func (c *clause) locate(offset int) {
+	c.start += offset
+	c.end += offset
+	c.definition[0] += offset
+	c.definition[1] += offset
+	c.located = true
+}
+
+// Deferred はトランザクションの終わりまで検査が遅延される制約かどうかを返す
+func (fk ForeignKey) Deferred() bool {
+	return fk.Deferrable && fk.InitiallyDeferred
+}
+
+// Soft は作成時にデータベースが検査しない制約かどうかを返す
+func (fk ForeignKey) Soft() bool {
+	return fk.NotValid || fk.NotEnforced
+}
+
+// String は「参照元(カラム) → 参照先(カラム)」の形式で外部キーを表す
+func (fk ForeignKey) String() string {
+	s := fmt.Sprintf("%s(%s) → %s", fk.Table, strings.Join(fk.Columns, ", "), fk.RefTable)
+	if len(fk.RefColumns) > 0 {
+		s += "(" + strings.Join(fk.RefColumns, ", ") + ")"
+	}
+	if fk.Name != "" {
+		s = fk.Name + ": " + s
+	}
+	return s
+}
+
+// 同じ外部キーかどうかを判定するためのキー（制約名と参照動作は含めない）
+func (fk ForeignKey) key() string {
+	return strings.ToLower(fk.Table + "(" + strings.Join(fk.Columns, ",") + ")" + fk.RefTable + "(" + strings.Join(fk.RefColumns, ",") + ")")
+}
+
+const (
+	// 識別子の文字の並び（英数字と _ のほか、日本語・中国語・絵文字など ASCII 以外の文字。空白は含めない）
+	IDENTIFIER_CHARS   = `(?:\w|[^\x00-\x7F\p{Z}])+`
+	IDENTIFIER_PATTERN = "[`\"]?(" + IDENTIFIER_CHARS + ")[`\"]?"
+	// schema.table の形の名前（部分ごとに引用符で囲まれていてもよい）
+	QUALIFIED_NAME_PATTERN = IDENTIFIER_PATTERN + `(?:\.` + IDENTIFIER_PATTERN + `)?`
+	ALTER_PATTERN          = `(?i)^\s*ALTER\s+TABLE\s+(?:ONLY\s+)?(?:IF\s+EXISTS\s+)?` + QUALIFIED_NAME_PATTERN
+)
+
+var (
+	reAlterTable = regexp.MustCompile(ALTER_PATTERN)
+	reReferences = regexp.MustCompile(REFERENCES_PATTERN)
+	// FOREIGN KEY [名前] (カラム, ...) の直後に REFERENCES が続く表制約
+	reForeignKeyClause = regexp.MustCompile(`(?is)(?:\bCONSTRAINT\s+` + IDENTIFIER_PATTERN + `\s+)?\bFOREIGN\s+KEY\s*(?:` + IDENTIFIER_PATTERN + `\s*)?\(([^()]*)\)\s*$`)
+	// REFERENCES テーブル の直後のカラムリスト
+	reRefColumns = regexp.MustCompile(`^\s*\(([^()]*)\)`)
+	// 列制約の場合のカラム名（ALTER TABLE ... ADD [COLUMN] の後ろも含む）
+	reColumnName = regexp.MustCompile(`(?i)^\s*(?:ALTER\s+TABLE\s+.*?\s+)?(?:ADD\s+(?:COLUMN\s+)?)?` + IDENTIFIER_PATTERN)
+	// 列制約に付けられた制約名
+	reColumnConstraint = regexp.MustCompile(`(?i)\bCONSTRAINT\s+` + IDENTIFIER_PATTERN + `\s+$`)
+	// ON DELETE / ON UPDATE の参照動作
+	reReferentialAction = regexp.MustCompile(`(?i)\bON\s+(DELETE|UPDATE)\s+(CASCADE|RESTRICT|NO\s+ACTION|SET\s+NULL|SET\s+DEFAULT)\b`)
+	reNotValid          = regexp.MustCompile(`(?i)\bNOT\s+VALID\b`)
+	reNotEnforced       = regexp.MustCompile(`(?i)\bNOT\s+ENFORCED\b`)
+	reDeferrable        = regexp.MustCompile(`(?i)(\bNOT\s+)?\bDEFERRABLE\b`)
+	reInitiallyDeferred = regexp.MustCompile(`(?i)\bINITIALLY\s+DEFERRED\b`)
+	// 列制約の REFERENCES に続く参照動作などの指定（この後ろは別の列制約）
+	reReferenceOption = regexp.MustCompile(`(?i)^\s*(?:ON\s+(?:DELETE|UPDATE)\s+(?:CASCADE|RESTRICT|NO\s+ACTION|SET\s+NULL|SET\s+DEFAULT)|MATCH\s+(?:FULL|PARTIAL|SIMPLE)|(?:NOT\s+)?DEFERRABLE|INITIALLY\s+(?:DEFERRED|IMMEDIATE)|NOT\s+VALID|(?:NOT\s+)?ENFORCED)\b`)
+	// 式が続く CHECK (...) / DEFAULT (...) / [GENERATED ALWAYS] AS (...)
+	reExpression = regexp.MustCompile(`(?i)\b(?:CHECK|DEFAULT|AS)\s*\(`)
+)
+
+// ForeignKeys は入力に含まれる外部キー制約を抽出する
+func ForeignKeys(r io.Reader) ([]ForeignKey, error) {
+	return ForeignKeysWithOptions(r, Options{})
+}
+
+// ForeignKeysWithOptions は opts の方言の終端文字と文字列リテラルの書き方で文を区切る ForeignKeys
+func ForeignKeysWithOptions(r io.Reader, opts Options) ([]ForeignKey, error) {
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+	reTableStatement := regexp.MustCompile(TABLE_STATEMENT_PATTERN)
+
+	var fks []ForeignKey
+	var db database
+	scanner := opts.newScanner(context.Background(), r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		if db.use(code) {
+			continue
+		}
+		if !reTableStatement.MatchString(code) || ignored(stmt) {
+			continue
+		}
+
+		var table string
+		if matches := reCreateTable.FindStringSubmatch(code); len(matches) > 1 {
+			table = qualifiedName(matches)
+		} else if matches := reAlterTable.FindStringSubmatch(code); len(matches) > 1 {
+			table = qualifiedName(matches)
+		}
+		fks = append(fks, db.qualifyForeignKeys(statementForeignKeys(db.qualify(table), code))...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fks, nil
+}
+
+// 1つの文に含まれる外部キー制約を抽出する
+func statementForeignKeys(table, code string) []ForeignKey {
+	original := code
+	code = maskExpressions(code)
+
+	var fks []ForeignKey
+	for _, loc := range reReferences.FindAllStringSubmatchIndex(code, -1) {
+		fk := ForeignKey{
+			Table:    table,
+			RefTable: qualifiedName(submatches(code, loc)),
+		}
+		if matches := reRefColumns.FindStringSubmatch(code[loc[1]:]); matches != nil {
+			fk.RefColumns = splitColumns(matches[1])
+		}
+		after := code[loc[1]:itemEnd(code, loc[1])]
+		for _, matches := range reReferentialAction.FindAllStringSubmatch(after, -1) {
+			action := strings.ToUpper(strings.Join(strings.Fields(matches[2]), " "))
+			if strings.EqualFold(matches[1], "DELETE") {
+				fk.OnDelete = action
+			} else {
+				fk.OnUpdate = action
+			}
+		}
+		fk.NotValid = reNotValid.MatchString(after)
+		fk.NotEnforced = reNotEnforced.MatchString(after)
+		if matches := reDeferrable.FindStringSubmatch(after); matches != nil && matches[1] == "" {
+			fk.Deferrable = true
+		}
+		fk.InitiallyDeferred = reInitiallyDeferred.MatchString(after)
+
+		// REFERENCES の手前の定義（カンマ区切りの1項目）から参照元のカラムを取り出す
+		start, end := itemStart(code, loc[0]), itemEnd(code, loc[1])
+		fk.Text = strings.TrimSpace(original[start:end])
+		before := code[start:loc[0]]
+		if matches := reForeignKeyClause.FindStringSubmatch(before); matches != nil {
+			fk.Name = matches[1]
+			fk.Columns = splitColumns(matches[3])
+			fk.clause = newClause(code, start, end, "")
+		} else if matches := reColumnName.FindStringSubmatchIndex(before); matches != nil {
+			name := before[matches[2]:matches[3]]
+			fk.Columns = []string{name}
+			// 引用符で囲まれたカラム名は引用符ごと残す
+			if matches[2] > 0 && strings.IndexByte("`\"", before[matches[2]-1]) >= 0 && matches[3] < len(before) {
+				name = before[matches[2]-1 : matches[3]+1]
+			}
+			column := loc[0]
+			if constraint := reColumnConstraint.FindStringSubmatchIndex(before); constraint != nil {
+				fk.Name = before[constraint[2]:constraint[3]]
+				column = start + constraint[0]
+			}
+			refEnd := loc[1]
+			if matches := reRefColumns.FindStringIndex(code[refEnd:]); matches != nil {
+				refEnd += matches[1]
+			}
+			for {
+				option := reReferenceOption.FindStringIndex(code[refEnd:end])
+				if option == nil {
+					break
+				}
+				refEnd += option[1]
+			}
+			fk.clause = newClause(code, column, refEnd, name)
+		} else {
+			fk.clause = newClause(code, start, end, "")
+		}
+
+		fks = append(fks, fk)
+	}
+	return fks
+}
+
+// pos を含むカンマ区切りの項目の開始位置を返す（括弧の入れ子を考慮する）
+func itemStart(code string, pos int) int {
+	depth := 0
+	for i := pos - 1; i >= 0; i-- {
+		switch code[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth == 0 {
+				return i + 1
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// pos を含むカンマ区切りの項目の終了位置を返す（括弧の入れ子を考慮する）
+func itemEnd(code string, pos int) int {
+	depth := 0
+	for i := pos; i < len(code); i++ {
+		switch code[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(code)
+}
+
+// カラムリストを分割し、引用符を取り除く
+func splitColumns(list string) []string {
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		column = strings.Trim(strings.TrimSpace(column), "`\"")
+		if column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// CHECK 制約や DEFAULT などの式の中身を空白に置き換える（式の中の REFERENCES という語やサブクエリを外部キーとみなさない）
+func maskExpressions(code string) string {
+	var masked []byte
+	for start := 0; start < len(code); {
+		loc := reExpression.FindStringIndex(code[start:])
+		if loc == nil {
+			break
+		}
+		open := start + loc[1] - 1
+		end := closingParen(code, open)
+		if masked == nil {
+			masked = []byte(code)
+		}
+		for i := open + 1; i < end; i++ {
+			masked[i] = mask(masked[i])
+		}
+		start = end
+	}
+	if masked == nil {
+		return code
+	}
+	return string(masked)
+}
+
+// FindAllStringSubmatchIndex の結果を文字列に変換する
+func submatches(s string, loc []int) []string {
+	matches := make([]string, len(loc)/2)
+	for i := range matches {
+		if loc[2*i] >= 0 {
+			matches[i] = s[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return matches
+}
+
+// SoftDependencyWarnings は作成順序に反映しなかった外部キーのうち、参照先より先に作成されるものを警告する
+func SoftDependencyWarnings(fks []ForeignKey, sortedTables []string) []string {
+	position := make(map[string]int)
+	for i, table := range sortedTables {
+		position[table] = i
+	}
+
+	var warnings []string
+	for _, fk := range fks {
+		if !fk.Soft() || fk.Table == fk.RefTable {
+			continue
+		}
+		child, childOK := position[fk.Table]
+		parent, parentOK := position[fk.RefTable]
+		if childOK && parentOK && child < parent {
+			warnings = append(warnings, fmt.Sprintf("%s は参照先の %s より先に作成されます（検査されない外部キー）", fk.Table, fk.RefTable))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,105 @@
+package ddl
+
+import "sort"
+
+// Graph はテーブル間の依存関係（親 → 子）のグラフ。ゼロ値は空のグラフとして使える
+type Graph struct {
+	// 追加した順のテーブル
+	tables   []string
+	children map[string][]string
+}
+
+// NewGraph は空のグラフを返す
+func NewGraph() *Graph {
+	return &Graph{children: make(map[string][]string)}
+}
+
+// DependencyGraph は入力に定義されたテーブルと、その間の依存関係のグラフを返す
+func (r *Result) DependencyGraph() *Graph {
+	g := NewGraph()
+	for _, table := range r.Tables {
+		g.AddTable(table)
+	}
+	for _, table := range r.Tables {
+		for _, child := range r.Graph[table] {
+			g.AddEdge(child, table)
+		}
+	}
+	return g
+}
+
+// AddTable はテーブルを追加する（追加済みの場合は何もしない）
+func (g *Graph) AddTable(table string) {
+	if _, exists := g.children[table]; exists {
+		return
+	}
+	if g.children == nil {
+		g.children = make(map[string][]string)
+	}
+	g.tables = append(g.tables, table)
+	g.children[table] = []string{}
+}
+
+// AddEdge は child が parent に依存する関係を追加する（テーブルが追加されていない場合は追加する）。
+// 引数は Dependency や -extra-dep の child:parent と同じく、依存する側を先に指定する
+func (g *Graph) AddEdge(child, parent string) {
+	g.AddTable(parent)
+	g.AddTable(child)
+	g.children[parent] = append(g.children[parent], child)
+}
+
+// Tables は追加した順のテーブルを返す
+func (g *Graph) Tables() []string {
+	return append([]string{}, g.tables...)
+}
+
+// TopoSort は依存関係の順にテーブルを並べる。
+// 同時に置けるものは追加した順を保ち、循環依存がある場合は ErrCycle を返す
+func (g *Graph) TopoSort() ([]string, error) {
+	return StableSort(g.tables, g.children)
+}
+
+// Cycles は循環依存しているテーブルの組を返す
+func (g *Graph) Cycles() [][]string {
+	return Cycles(g.children)
+}
+
+// Levels はテーブルごとの段数を返す。循環依存がある場合は ErrCycle を返す
+func (g *Graph) Levels() (map[string]int, error) {
+	sorted, err := g.TopoSort()
+	if err != nil {
+		return nil, err
+	}
+	// 依存先のないテーブルも 0 段として含める
+	levels := Levels(sorted, g.children)
+	for _, table := range sorted {
+		if _, exists := levels[table]; !exists {
+			levels[table] = 0
+		}
+	}
+	return levels, nil
+}
+
+// Dependents は table に直接依存するテーブルを名前順に返す（自己参照は含めない）
+func (g *Graph) Dependents(table string) []string {
+	var dependents []string
+	for _, child := range g.children[table] {
+		if child != table && !contains(dependents, child) {
+			dependents = append(dependents, child)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Dependencies は table が直接依存するテーブルを名前順に返す（自己参照は含めない）
+func (g *Graph) Dependencies(table string) []string {
+	var dependencies []string
+	for _, parent := range g.tables {
+		if parent != table && contains(g.children[parent], table) {
+			dependencies = append(dependencies, parent)
+		}
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
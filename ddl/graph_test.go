@@ -0,0 +1,54 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraph(t *testing.T) {
+	// ゼロ値のグラフにもそのまま追加できる
+	var g Graph
+	g.AddTable("orders")
+	g.AddEdge("orders", "users")
+	g.AddEdge("items", "orders")
+
+	sorted, err := g.TopoSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(sorted, ","), "users,orders,items"; got != want {
+		t.Errorf("TopoSort = %s, want %s", got, want)
+	}
+	if got := g.Dependencies("orders"); strings.Join(got, ",") != "users" {
+		t.Errorf("Dependencies(orders) = %q, want [users]", got)
+	}
+	if got := g.Dependents("orders"); strings.Join(got, ",") != "items" {
+		t.Errorf("Dependents(orders) = %q, want [items]", got)
+	}
+	levels, err := g.Levels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels["users"] != 0 || levels["orders"] != 1 || levels["items"] != 2 {
+		t.Errorf("Levels = %v", levels)
+	}
+
+	g.AddEdge("users", "items")
+	if _, err := g.TopoSort(); err == nil {
+		t.Error("循環依存でエラーになりませんでした")
+	}
+	if cycles := g.Cycles(); len(cycles) != 1 || len(cycles[0]) != 3 {
+		t.Errorf("Cycles = %q", cycles)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	result, err := Analyze("CREATE TABLE b (a_id int REFERENCES a(id));\nCREATE TABLE a (id int PRIMARY KEY);\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := result.DependencyGraph()
+	if got := g.Dependencies("b"); strings.Join(got, ",") != "a" {
+		t.Errorf("Dependencies(b) = %q, want [a]", got)
+	}
+}
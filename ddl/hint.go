@@ -0,0 +1,65 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Hint は SQL 中の「-- orderddl:」で始まるコメントによる並び順の指定
+type Hint struct {
+	// 指定したテーブルより後に作成する（-- orderddl:after テーブル, ...）
+	After []string
+	// 指定したテーブルより前に作成する（-- orderddl:before テーブル, ...）
+	Before []string
+	// 依存関係が許す限り先頭に置く（-- orderddl:first）
+	First bool
+	// 依存関係が許す限り末尾に置く（-- orderddl:last）
+	Last bool
+}
+
+var reHint = regexp.MustCompile(`(?m)^[ \t]*--[ \t]*orderddl:(\w+)[ \t]*(.*?)[ \t\r]*$`)
+
+// 文の前に置かれたコメントから並び順の指定を読み取る
+func parseHint(stmt Statement) Hint {
+	var hint Hint
+	for _, matches := range reHint.FindAllStringSubmatch(leadingText(stmt), -1) {
+		switch strings.ToLower(matches[1]) {
+		case "after":
+			hint.After = append(hint.After, hintTables(matches[2])...)
+		case "before":
+			hint.Before = append(hint.Before, hintTables(matches[2])...)
+		case "first":
+			hint.First = true
+		case "last":
+			hint.Last = true
+		}
+	}
+	return hint
+}
+
+// ignored は文の前に「-- orderddl:ignore」があり、依存関係の解析から除外する文かどうかを返す
+func ignored(stmt Statement) bool {
+	for _, matches := range reHint.FindAllStringSubmatch(leadingText(stmt), -1) {
+		if strings.EqualFold(matches[1], "ignore") {
+			return true
+		}
+	}
+	return false
+}
+
+// 文の本体より前にある空白とコメントの部分を返す
+func leadingText(stmt Statement) string {
+	end := len(stmt.Code) - len(strings.TrimLeft(stmt.Code, " \t\r\n"))
+	return stmt.Text[:end]
+}
+
+// カンマまたは空白区切りのテーブル名を分割する（"order" のような引用符は除く）
+func hintTables(list string) []string {
+	tables := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for i, table := range tables {
+		tables[i] = unquoteName(table)
+	}
+	return tables
+}
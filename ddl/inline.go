@@ -0,0 +1,288 @@
+package ddl
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ALTER TABLE の ADD [CONSTRAINT 名前] PRIMARY KEY (...) / UNIQUE (...) の項目
+var reAddKey = regexp.MustCompile(`(?i)\bADD\s+(?:CONSTRAINT\s+` + "[`\"]?" + IDENTIFIER_CHARS + "[`\"]?" + `\s+)?(?:PRIMARY\s+KEY|UNIQUE(?:\s+KEY|\s+INDEX)?)\s*(?:` + "[`\"]?" + IDENTIFIER_CHARS + "[`\"]?" + `\s*)?\(([^)]*)\)`)
+
+// InlineForeignKeys は Analyze に渡した src の ALTER TABLE ... ADD で追加する外部キーの表制約を、
+// 参照元のテーブルの CREATE TABLE の最後の項目の後ろに移し、移した外部キーと主キー・一意制約の数を返す。
+// 参照先のキーが後から追加されると外部キーを作成できないため、ALTER TABLE で追加する主キーと一意制約も移す。
+// CREATE TABLE より前の ALTER TABLE、CREATE TABLE にないカラムの制約（ALTER TABLE で追加したカラムなど）と
+// NOT VALID の外部キー（CREATE TABLE では指定できない）、USING INDEX の制約はそのままにする。
+// 移さずに残った項目や外部キーがある ALTER TABLE は、ほかのテーブルのブロックにあると対象のテーブルより前に出力されることがあるため、
+// 対象のテーブルのブロックの末尾に移す
+func InlineForeignKeys(src string, edges []Edge, opts Options) (string, []ForeignKey, int) {
+	creates, alters, keys := tableStatements(src, opts)
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	inserted := make(map[*createStatement]string)
+	var insertOrder []*createStatement
+	// c を table の CREATE TABLE に移せる場合は移す
+	removed := make(map[int][][2]int)
+	inline := func(table string, columns []string, c clause) bool {
+		create := creates[table]
+		alter := alterIndex(alters, c.start)
+		if create == nil || alter < 0 || create.offset > c.start || !create.hasColumns(columns) {
+			return false
+		}
+		start, end := c.removal(src)
+		removed[alter] = append(removed[alter], [2]int{start, end})
+		if _, exists := inserted[create]; !exists {
+			insertOrder = append(insertOrder, create)
+		}
+		inserted[create] += create.separator + src[c.definition[0]:c.definition[1]]
+		return true
+	}
+
+	// 移さなかった外部キーを含む ALTER TABLE
+	remaining := make(map[int]bool)
+
+	movedKeys := 0
+	for _, key := range keys {
+		if inline(key.table, key.columns, key.clause) {
+			movedKeys++
+		}
+	}
+	var moved []ForeignKey
+	for _, edge := range edges {
+		fk := edge.ForeignKey
+		// 同じ外部キーの重複した制約も、ALTER TABLE にあるものは移す（NOT VALID の制約はそれぞれ残す）
+		for _, constraint := range append([]ForeignKey{fk}, fk.duplicates...) {
+			c := constraint.clause
+			if !c.located {
+				continue
+			}
+			notValid := reNotValid.MatchString(src[c.definition[0]:c.definition[1]])
+			if !notValid && c.column == "" && inline(fk.Table, fk.Columns, c) {
+				moved = append(moved, constraint)
+			} else if alter := alterIndex(alters, c.start); alter >= 0 {
+				remaining[alter] = true
+			}
+		}
+	}
+	// 対象のテーブルのブロックの末尾に移す ALTER TABLE の文
+	appended := make(map[*createStatement]string)
+	var appendOrder []*createStatement
+	relocate := func(alter alterStatement, text string) bool {
+		create := creates[alter.table]
+		if create == nil || alter.start < create.blockEnd {
+			return false
+		}
+		start, end := clause{start: alter.start, end: alter.end, statement: true}.removal(src)
+		edits = append(edits, edit{start: start, end: end})
+		if _, exists := appended[create]; !exists {
+			appendOrder = append(appendOrder, create)
+		}
+		appended[create] += "\n" + text
+		return true
+	}
+	// 入力の順に移す
+	for i, alter := range alters {
+		ranges, exists := removed[i]
+		if !exists {
+			if remaining[i] {
+				relocate(alter, src[alter.start:alter.end])
+			}
+			continue
+		}
+		// 同じ ALTER TABLE の隣り合う項目を取り除く範囲は区切りのカンマが重なるため、まとめてから取り除く
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+		merged := [][2]int{ranges[0]}
+		for _, r := range ranges[1:] {
+			if last := &merged[len(merged)-1]; r[0] <= last[1] {
+				last[1] = max(last[1], r[1])
+				continue
+			}
+			merged = append(merged, r)
+		}
+		// すべての項目を移した場合は文全体を取り除く
+		if len(merged) == 1 && merged[0][0] <= alter.items[0] && merged[0][1] >= alter.items[1] {
+			start, end := clause{start: alter.start, end: alter.end, statement: true}.removal(src)
+			merged[0] = [2]int{start, end}
+		} else {
+			// 残った項目は対象のテーブルのブロックに移す
+			var text strings.Builder
+			last := alter.start
+			for _, r := range merged {
+				text.WriteString(src[last:r[0]])
+				last = r[1]
+			}
+			text.WriteString(src[last:alter.end])
+			if relocate(alter, text.String()) {
+				continue
+			}
+		}
+		for _, r := range merged {
+			edits = append(edits, edit{start: r[0], end: r[1]})
+		}
+	}
+	for _, create := range insertOrder {
+		text := inserted[create]
+		// 項目ごとに改行していて最後の項目の後ろにコメントがある場合は、カンマだけを項目の直後に置き、制約はコメントの後ろに置く
+		if create.textEnd > create.end && create.separator != ", " {
+			edits = append(edits, edit{start: create.end, end: create.end, text: ","})
+			edits = append(edits, edit{start: create.textEnd, end: create.textEnd, text: text[1:]})
+			continue
+		}
+		edits = append(edits, edit{start: create.end, end: create.end, text: text})
+	}
+	for _, create := range appendOrder {
+		text := appended[create]
+		// ブロックが改行で終わる場合は、改行の後ろに加える
+		if strings.HasSuffix(src[:create.blockEnd], "\n") {
+			text = text[1:] + "\n"
+		}
+		edits = append(edits, edit{start: create.blockEnd, end: create.blockEnd, text: text})
+	}
+
+	// 後ろから書き換えて、前の位置がずれないようにする
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+	return src, moved, movedKeys
+}
+
+// ALTER TABLE の文の入力での位置
+type alterStatement struct {
+	// 対象のテーブル
+	table string
+	// 文の範囲（前のコメントと後ろの空白を含めず、終端文字を含める）
+	start, end int
+	// 項目の範囲（テーブル名の後ろから終端文字の前まで）
+	items [2]int
+}
+
+// ALTER TABLE で追加する主キー・一意制約
+type keyConstraint struct {
+	table   string
+	columns []string
+	clause  clause
+}
+
+// CREATE TABLE の文の入力での位置と項目
+type createStatement struct {
+	// 文の開始位置と、最後の項目の末尾（閉じ括弧の前の空白は含めず、textEnd はコメントを含める）
+	offset, end, textEnd int
+	// テーブルのブロックの終わり（次のブロックの最初の文の前置きのコメントの前）
+	blockEnd int
+	// 項目を加えるときの区切り（最初の項目と同じ字下げで改行するか、カンマと空白）
+	separator string
+	// カラム名（小文字）
+	columns map[string]bool
+}
+
+func (c *createStatement) hasColumns(columns []string) bool {
+	for _, column := range columns {
+		if !c.columns[strings.ToLower(strings.Trim(column, "`\""))] {
+			return false
+		}
+	}
+	return len(columns) > 0
+}
+
+// src の CREATE TABLE（テーブルごとに最初の定義）、ALTER TABLE の文の範囲と、ALTER TABLE で追加する主キー・一意制約
+func tableStatements(src string, opts Options) (map[string]*createStatement, []alterStatement, []keyConstraint) {
+	creates := make(map[string]*createStatement)
+	var alters []alterStatement
+	var keys []keyConstraint
+	var db database
+	// 直前の CREATE TABLE（次のブロックが始まるとブロックの終わりを決める）
+	var previous *createStatement
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		if _, isObject := opts.dialectObject(stmt); (isObject || reCreateTable.MatchString(code)) && !ignored(stmt) && previous != nil {
+			previous.blockEnd = stmt.Offset
+			previous = nil
+		}
+		if db.use(code) || reTemporaryTable.MatchString(code) {
+			continue
+		}
+		if matches := reAlterTable.FindStringSubmatch(code); matches != nil {
+			end := len(strings.TrimRight(code, " \t\r\n"))
+			itemsEnd := end
+			if stmt.Terminator != "" && strings.HasSuffix(code[:end], stmt.Terminator) {
+				itemsEnd -= len(stmt.Terminator)
+			}
+			itemsStart, itemsEnd := trimSpaceRange(code, len(matches[0]), itemsEnd)
+			alters = append(alters, alterStatement{
+				table: db.qualify(qualifiedName(matches)),
+				start: stmt.Offset + len(code) - len(strings.TrimLeft(code, " \t\r\n")),
+				end:   stmt.Offset + end,
+				items: [2]int{stmt.Offset + itemsStart, stmt.Offset + itemsEnd},
+			})
+			table := db.qualify(qualifiedName(matches))
+			masked := maskExpressions(code)
+			for _, loc := range reAddKey.FindAllStringSubmatchIndex(masked, -1) {
+				key := keyConstraint{table: table, columns: splitColumns(masked[loc[2]:loc[3]])}
+				key.clause = newClause(masked, itemStart(masked, loc[0]), itemEnd(masked, loc[1]), "")
+				key.clause.locate(stmt.Offset)
+				keys = append(keys, key)
+			}
+			continue
+		}
+		matches := reTableDefinition.FindStringSubmatch(code)
+		if matches == nil || ignored(stmt) {
+			continue
+		}
+		table := db.qualify(qualifiedName(matches))
+		prefix := len(matches[0])
+		open := strings.IndexByte(code[prefix:], '(')
+		if _, exists := creates[table]; exists || open < 0 || strings.TrimSpace(code[prefix:prefix+open]) != "" {
+			continue
+		}
+		open += prefix
+		closing := closingParen(code, open)
+		if closing == len(code) {
+			continue
+		}
+
+		body := code[open+1 : closing]
+		create := &createStatement{
+			offset:    stmt.Offset,
+			end:       stmt.Offset + open + 1 + len(strings.TrimRight(body, " \t\r\n")),
+			textEnd:   stmt.Offset + open + 1 + len(strings.TrimRight(stmt.Text[open+1:closing], " \t\r\n")),
+			blockEnd:  len(src),
+			separator: ", ",
+			columns:   make(map[string]bool),
+		}
+		previous = create
+		if leading := body[:len(body)-len(strings.TrimLeft(body, " \t\r\n"))]; strings.Contains(leading, "\n") {
+			newline := "\n"
+			if strings.Contains(leading, "\r\n") {
+				newline = "\r\n"
+			}
+			create.separator = "," + newline + leading[strings.LastIndexByte(leading, '\n')+1:]
+		}
+		for _, item := range splitItems(body) {
+			if name, _, _, ok := columnDefinition(item); ok && !reConstraintName.MatchString(item) && !reKeyItem.MatchString(item) {
+				create.columns[strings.ToLower(strings.Trim(name, "`\""))] = true
+			}
+		}
+		creates[table] = create
+	}
+	return creates, alters, keys
+}
+
+// offset を含む ALTER TABLE の文の位置（含む文がない場合は -1）
+func alterIndex(alters []alterStatement, offset int) int {
+	for i, alter := range alters {
+		if alter.start <= offset && offset < alter.end {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,97 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineForeignKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		want  string
+		moved []string
+		keys  int
+	}{
+		{
+			name: "ALTER TABLE の外部キーを CREATE TABLE に入れる",
+			src: "CREATE TABLE b (id INT PRIMARY KEY);\nCREATE TABLE c (id INT PRIMARY KEY, b_id INT);\n" +
+				"ALTER TABLE c ADD CONSTRAINT fk_c_b FOREIGN KEY (b_id) REFERENCES b (id);\n",
+			want:  "CREATE TABLE b (id INT PRIMARY KEY);\nCREATE TABLE c (id INT PRIMARY KEY, b_id INT, CONSTRAINT fk_c_b FOREIGN KEY (b_id) REFERENCES b (id));\n",
+			moved: []string{"fk_c_b"},
+		},
+		{
+			name: "主キーも CREATE TABLE に入れる",
+			src:  "CREATE TABLE a (id INT);\nALTER TABLE a ADD PRIMARY KEY (id);\n",
+			want: "CREATE TABLE a (id INT, PRIMARY KEY (id));\n",
+			keys: 1,
+		},
+		{
+			name: "残った項目の ALTER TABLE は対象のテーブルのブロックに移す",
+			src: "CREATE TABLE b (id INT PRIMARY KEY, a_id INT);\nCREATE TABLE a (id INT PRIMARY KEY);\nCREATE TABLE c (id INT PRIMARY KEY);\n" +
+				"ALTER TABLE b ADD KEY ix (a_id), ADD CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id);\n",
+			want: "CREATE TABLE b (id INT PRIMARY KEY, a_id INT, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id));\nALTER TABLE b ADD KEY ix (a_id);\n" +
+				"CREATE TABLE a (id INT PRIMARY KEY);\nCREATE TABLE c (id INT PRIMARY KEY);\n",
+			moved: []string{"fk_b_a"},
+		},
+		{
+			name: "循環する外部キーを移すと残った項目の ALTER TABLE も対象のテーブルのブロックに移す",
+			src: "CREATE TABLE c (id INT PRIMARY KEY, b_id INT);\nCREATE TABLE b (id INT PRIMARY KEY, a_id INT);\nCREATE TABLE a (id INT PRIMARY KEY);\n" +
+				"ALTER TABLE b ADD KEY ix (a_id), ADD CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id);\n" +
+				"ALTER TABLE c ADD CONSTRAINT fk_c_b FOREIGN KEY (b_id) REFERENCES b (id);\n",
+			want: "CREATE TABLE c (id INT PRIMARY KEY, b_id INT, CONSTRAINT fk_c_b FOREIGN KEY (b_id) REFERENCES b (id));\n" +
+				"CREATE TABLE b (id INT PRIMARY KEY, a_id INT, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id));\nALTER TABLE b ADD KEY ix (a_id);\n" +
+				"CREATE TABLE a (id INT PRIMARY KEY);\n",
+			moved: []string{"fk_b_a", "fk_c_b"},
+		},
+		{
+			name: "重複した外部キーはどちらも数える",
+			src: "CREATE TABLE c (id INT PRIMARY KEY, b_id INT);\nCREATE TABLE b (id INT PRIMARY KEY);\n" +
+				"ALTER TABLE c ADD CONSTRAINT f1 FOREIGN KEY (b_id) REFERENCES b (id);\nALTER TABLE c ADD CONSTRAINT f2 FOREIGN KEY (b_id) REFERENCES b (id);\n",
+			want:  "CREATE TABLE c (id INT PRIMARY KEY, b_id INT, CONSTRAINT f1 FOREIGN KEY (b_id) REFERENCES b (id), CONSTRAINT f2 FOREIGN KEY (b_id) REFERENCES b (id));\nCREATE TABLE b (id INT PRIMARY KEY);\n",
+			moved: []string{"f1", "f2"},
+		},
+		{
+			name:  "NOT VALID の外部キーは移さず、ALTER TABLE を対象のテーブルのブロックに移す",
+			src:   "CREATE TABLE b (id INT PRIMARY KEY, a_id INT);\nCREATE TABLE a (id INT PRIMARY KEY);\nALTER TABLE b ADD CONSTRAINT fk2 FOREIGN KEY (a_id) REFERENCES a(id) NOT VALID;\n",
+			want:  "CREATE TABLE b (id INT PRIMARY KEY, a_id INT);\nALTER TABLE b ADD CONSTRAINT fk2 FOREIGN KEY (a_id) REFERENCES a(id) NOT VALID;\nCREATE TABLE a (id INT PRIMARY KEY);\n",
+			moved: nil,
+		},
+		{
+			name: "重複した NOT VALID の外部キーは移さず、主キーは移す",
+			src: "CREATE TABLE b (id INT PRIMARY KEY, a_id INT);\nCREATE TABLE a (id INT);\nALTER TABLE a ADD PRIMARY KEY (id);\n" +
+				"ALTER TABLE b ADD CONSTRAINT f1 FOREIGN KEY (a_id) REFERENCES a(id);\nALTER TABLE b ADD CONSTRAINT f2 FOREIGN KEY (a_id) REFERENCES a(id) NOT VALID;\n",
+			want: "CREATE TABLE b (id INT PRIMARY KEY, a_id INT, CONSTRAINT f1 FOREIGN KEY (a_id) REFERENCES a(id));\nALTER TABLE b ADD CONSTRAINT f2 FOREIGN KEY (a_id) REFERENCES a(id) NOT VALID;\n" +
+				"CREATE TABLE a (id INT, PRIMARY KEY (id));\n",
+			moved: []string{"f1"},
+			keys:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Dialect: mustDialect(t, "postgres")}
+			result, err := Analyze(tt.src, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, moved, keys := InlineForeignKeys(tt.src, result.Edges, opts)
+			if got != tt.want {
+				t.Errorf("InlineForeignKeys =\n%s\nwant\n%s", got, tt.want)
+			}
+			var names []string
+			for _, fk := range moved {
+				names = append(names, fk.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.moved, ",") {
+				t.Errorf("移した外部キー = %v, want %v", names, tt.moved)
+			}
+			if keys != tt.keys {
+				t.Errorf("移した主キー・一意制約 = %d, want %d", keys, tt.keys)
+			}
+			// 移した後の入力は循環せずに並び替えられる
+			if _, err := Order(got, opts); err != nil {
+				t.Errorf("Order: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LintIssue は lint で見つかった問題
+type LintIssue struct {
+	Rule    string `json:"rule"`
+	Table   string `json:"table"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// LintRule は lint の規則
+type LintRule struct {
+	Name        string
+	Description string
+	check       func(schema map[string]*Table, tables []*Table, opts Options) []LintIssue
+}
+
+// LintRules は lint の規則の一覧
+var LintRules = []LintRule{
+	{Name: "no-primary-key", Description: "主キーのないテーブル", check: lintNoPrimaryKey},
+	{Name: "fk-target-key", Description: "参照先のカラムに主キーまたは一意制約（-dialect mysql ではインデックス）がなく、作成時に失敗する外部キー", check: lintForeignKeyTarget},
+	{Name: "fk-type-mismatch", Description: "参照元と参照先のカラムの型が異なる外部キー", check: lintForeignKeyType},
+	{Name: "fk-nullability", Description: "NULL の扱いが参照先や参照動作と合わない外部キー", check: lintForeignKeyNullability},
+	{Name: "constraint-name-collision", Description: "同じ名前の制約が複数ある", check: lintConstraintNames},
+}
+
+// Lint は有効な規則でテーブル定義を検査する。enabled が nil の場合はすべての規則を使う。
+// opts.Dialect で外部キーの参照先に必要なキーと、外部キーを検査するかどうかが変わる
+func Lint(tables []*Table, enabled map[string]bool, opts Options) []LintIssue {
+	schema := make(map[string]*Table)
+	for _, table := range tables {
+		schema[table.Name] = table
+	}
+
+	var issues []LintIssue
+	for _, rule := range LintRules {
+		if enabled != nil && !enabled[rule.Name] {
+			continue
+		}
+		issues = append(issues, rule.check(schema, tables, opts)...)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+	return issues
+}
+
+// LintRuleSet は規則名のカンマ区切りの指定から有効な規則を決める。enable が空の場合はすべての規則から disable を除く
+func LintRuleSet(enable, disable string) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	for _, rule := range LintRules {
+		enabled[rule.Name] = enable == ""
+	}
+	for _, name := range ruleNames(enable) {
+		if _, exists := enabled[name]; !exists {
+			return nil, fmt.Errorf("不明な lint 規則です: %s", name)
+		}
+		enabled[name] = true
+	}
+	for _, name := range ruleNames(disable) {
+		if _, exists := enabled[name]; !exists {
+			return nil, fmt.Errorf("不明な lint 規則です: %s", name)
+		}
+		enabled[name] = false
+	}
+	return enabled, nil
+}
+
+func ruleNames(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+func lintNoPrimaryKey(schema map[string]*Table, tables []*Table, _ Options) []LintIssue {
+	var issues []LintIssue
+	for _, table := range tables {
+		if table.PrimaryKey() == nil {
+			issues = append(issues, LintIssue{
+				Rule:    "no-primary-key",
+				Table:   table.Name,
+				Line:    table.Line,
+				Message: fmt.Sprintf("%s に主キーがありません", table.Name),
+			})
+		}
+	}
+	return issues
+}
+
+func lintForeignKeyTarget(schema map[string]*Table, tables []*Table, opts Options) []LintIssue {
+	// 外部キーを検査しない方言では、参照先にキーがなくても作成できる
+	if opts.InformationalConstraints() {
+		return nil
+	}
+	dialect, hasRule := findDialect[ReferencedKeyDialect](opts.Dialect)
+	var issues []LintIssue
+	for _, table := range tables {
+		for _, fk := range table.ForeignKeys {
+			parent, exists := schema[fk.RefTable]
+			if !exists {
+				continue
+			}
+			// 参照先のカラムを省略した場合は主キーを参照する
+			refColumns := fk.RefColumns
+			if len(refColumns) == 0 {
+				if parent.PrimaryKey() == nil {
+					issues = append(issues, LintIssue{
+						Rule:    "fk-target-key",
+						Table:   table.Name,
+						Line:    table.Line,
+						Message: fmt.Sprintf("%s の外部キーが参照する %s に主キーがありません", table.Name, fk.RefTable),
+					})
+				}
+				continue
+			}
+			switch {
+			case hasRule && !dialect.ReferencedKey(parent, refColumns):
+				issues = append(issues, LintIssue{
+					Rule:    "fk-target-key",
+					Table:   table.Name,
+					Line:    table.Line,
+					Message: fmt.Sprintf("%s の外部キーが参照する %s(%s) を先頭から同じ順に含むインデックスがありません", table.Name, fk.RefTable, strings.Join(refColumns, ", ")),
+				})
+			case !hasRule && !parent.HasUniqueKey(refColumns):
+				issues = append(issues, LintIssue{
+					Rule:    "fk-target-key",
+					Table:   table.Name,
+					Line:    table.Line,
+					Message: fmt.Sprintf("%s の外部キーが参照する %s(%s) に主キーまたは一意制約がありません", table.Name, fk.RefTable, strings.Join(refColumns, ", ")),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func lintForeignKeyType(schema map[string]*Table, tables []*Table, _ Options) []LintIssue {
+	var issues []LintIssue
+	eachForeignKeyColumn(schema, tables, func(table, parent *Table, fk ForeignKey, column, refColumn Column) {
+		if column.Type == "" || refColumn.Type == "" || normalizeType(column.Type) == normalizeType(refColumn.Type) {
+			return
+		}
+		issues = append(issues, LintIssue{
+			Rule:    "fk-type-mismatch",
+			Table:   table.Name,
+			Line:    table.Line,
+			Message: fmt.Sprintf("%s.%s (%s) と参照先の %s.%s (%s) の型が異なります", table.Name, column.Name, column.Type, parent.Name, refColumn.Name, refColumn.Type),
+		})
+	})
+	return issues
+}
+
+func lintForeignKeyNullability(schema map[string]*Table, tables []*Table, _ Options) []LintIssue {
+	var issues []LintIssue
+	eachForeignKeyColumn(schema, tables, func(table, parent *Table, fk ForeignKey, column, refColumn Column) {
+		// SET NULL の参照動作は NOT NULL のカラムでは実行時に失敗する
+		for _, action := range []struct{ event, action string }{{"DELETE", fk.OnDelete}, {"UPDATE", fk.OnUpdate}} {
+			if action.action == "SET NULL" && table.notNull(column) {
+				issues = append(issues, LintIssue{
+					Rule:    "fk-nullability",
+					Table:   table.Name,
+					Line:    table.Line,
+					Message: fmt.Sprintf("%s.%s は NOT NULL ですが、外部キーに ON %s SET NULL が指定されています", table.Name, column.Name, action.event),
+				})
+			}
+		}
+		if !parent.notNull(refColumn) {
+			issues = append(issues, LintIssue{
+				Rule:    "fk-nullability",
+				Table:   table.Name,
+				Line:    table.Line,
+				Message: fmt.Sprintf("%s.%s が参照する %s.%s は NULL を許容しています", table.Name, column.Name, parent.Name, refColumn.Name),
+			})
+		}
+	})
+	return issues
+}
+
+// ForeignKeyColumnWarnings は参照元と参照先がどちらも定義されている外部キーについて、カラムの型と NULL の扱いの不一致を返す
+func ForeignKeyColumnWarnings(tables []*Table) []LintIssue {
+	enabled := map[string]bool{"fk-type-mismatch": true, "fk-nullability": true}
+	return Lint(tables, enabled, Options{})
+}
+
+// 参照元と参照先のカラムがどちらも定義されている外部キーのカラムの組ごとに fn を呼ぶ
+func eachForeignKeyColumn(schema map[string]*Table, tables []*Table, fn func(table, parent *Table, fk ForeignKey, column, refColumn Column)) {
+	for _, table := range tables {
+		for _, fk := range table.ForeignKeys {
+			parent, exists := schema[fk.RefTable]
+			if !exists {
+				continue
+			}
+			// 参照先のカラムを省略した場合は主キーを参照する
+			refColumns := fk.RefColumns
+			if len(refColumns) == 0 {
+				refColumns = parent.PrimaryKey()
+			}
+			if len(fk.Columns) != len(refColumns) {
+				continue
+			}
+			for i, name := range fk.Columns {
+				column, ok := table.Column(name)
+				refColumn, refOK := parent.Column(refColumns[i])
+				if ok && refOK {
+					fn(table, parent, fk, column, refColumn)
+				}
+			}
+		}
+	}
+}
+
+func lintConstraintNames(schema map[string]*Table, tables []*Table, _ Options) []LintIssue {
+	var issues []LintIssue
+	owner := make(map[string]string)
+	for _, table := range tables {
+		for _, name := range table.Constraints {
+			key := strings.ToLower(SchemaOf(table.Name) + "." + name)
+			if first, exists := owner[key]; exists {
+				issues = append(issues, LintIssue{
+					Rule:    "constraint-name-collision",
+					Table:   table.Name,
+					Line:    table.Line,
+					Message: fmt.Sprintf("制約名 %s が %s と %s で重複しています", name, first, table.Name),
+				})
+				continue
+			}
+			owner[key] = table.Name
+		}
+	}
+	return issues
+}
+
+var (
+	// MySQL の整数型の表示幅（int(11) など）
+	reDisplayWidth = regexp.MustCompile(`^((?:tiny|small|medium|big)?int)\(\d+\)`)
+	// 型の別名
+	typeAliases = map[string]string{
+		"int":               "integer",
+		"int4":              "integer",
+		"serial":            "integer",
+		"serial4":           "integer",
+		"int8":              "bigint",
+		"bigserial":         "bigint",
+		"serial8":           "bigint",
+		"int2":              "smallint",
+		"smallserial":       "smallint",
+		"serial2":           "smallint",
+		"bool":              "boolean",
+		"character varying": "varchar",
+		"character":         "char",
+		"float8":            "double precision",
+		"float4":            "real",
+		"timestamptz":       "timestamp with time zone",
+	}
+)
+
+// 比較のために型の別名や表示幅をそろえる
+func normalizeType(typ string) string {
+	typ = reDisplayWidth.ReplaceAllString(typ, "$1")
+	suffix := ""
+	if strings.HasSuffix(typ, " unsigned") {
+		typ, suffix = strings.TrimSuffix(typ, " unsigned"), " unsigned"
+	}
+	base, rest := typ, ""
+	if i := strings.Index(typ, "("); i >= 0 {
+		base, rest = strings.TrimSpace(typ[:i]), typ[i:]
+	}
+	if alias, exists := typeAliases[base]; exists {
+		base = alias
+	}
+	return base + rest + suffix
+}
@@ -0,0 +1,144 @@
+package ddl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		dialect string
+		src     string
+		want    []string
+	}{
+		{
+			name: "主キーのないテーブル",
+			rule: "no-primary-key",
+			src:  "CREATE TABLE a (id int PRIMARY KEY);\nCREATE TABLE nopk (x int);\n",
+			want: []string{"nopk に主キーがありません"},
+		},
+		{
+			name: "参照先に一意制約がない",
+			rule: "fk-target-key",
+			src:  "CREATE TABLE a (id int PRIMARY KEY, code varchar(10));\nCREATE TABLE b (a_code varchar(10) REFERENCES a(code));\n",
+			want: []string{"b の外部キーが参照する a(code) に主キーまたは一意制約がありません"},
+		},
+		{
+			name: "参照先のカラムを省略した場合は主キーが必要",
+			rule: "fk-target-key",
+			src:  "CREATE TABLE a (id int);\nCREATE TABLE b (a_id int REFERENCES a);\n",
+			want: []string{"b の外部キーが参照する a に主キーがありません"},
+		},
+		{
+			name: "一意制約のあるカラムは参照できる",
+			rule: "fk-target-key",
+			src:  "CREATE TABLE a (id int PRIMARY KEY, code varchar(10) UNIQUE);\nCREATE TABLE b (a_code varchar(10) REFERENCES a(code));\n",
+		},
+		{
+			name:    "mysql ではインデックスがあれば参照できる",
+			rule:    "fk-target-key",
+			dialect: "mysql",
+			src:     "CREATE TABLE a (id int PRIMARY KEY, code varchar(10), KEY ix_code (code));\nCREATE TABLE b (a_code varchar(10) REFERENCES a(code));\n",
+		},
+		{
+			name:    "mysql でインデックスがない",
+			rule:    "fk-target-key",
+			dialect: "mysql",
+			src:     "CREATE TABLE a (id int PRIMARY KEY, code varchar(10));\nCREATE TABLE b (a_code varchar(10) REFERENCES a(code));\n",
+			want:    []string{"b の外部キーが参照する a(code) を先頭から同じ順に含むインデックスがありません"},
+		},
+		{
+			name:    "外部キーを検査しない方言",
+			rule:    "fk-target-key",
+			dialect: "snowflake",
+			src:     "CREATE TABLE a (id int, code varchar(10));\nCREATE TABLE b (a_code varchar(10) REFERENCES a(code));\n",
+		},
+		{
+			name: "型の不一致",
+			rule: "fk-type-mismatch",
+			src:  "CREATE TABLE a (id int PRIMARY KEY);\nCREATE TABLE c (a_id bigint REFERENCES a(id));\n",
+			want: []string{"c.a_id (bigint) と参照先の a.id (int) の型が異なります"},
+		},
+		{
+			name: "型の別名と表示幅は同じ型とみなす",
+			rule: "fk-type-mismatch",
+			src:  "CREATE TABLE a (id int(11) PRIMARY KEY);\nCREATE TABLE c (a_id integer REFERENCES a(id));\n",
+		},
+		{
+			name: "NOT NULL のカラムに SET NULL",
+			rule: "fk-nullability",
+			src:  "CREATE TABLE a (id int NOT NULL PRIMARY KEY);\nCREATE TABLE c (a_id int NOT NULL REFERENCES a(id) ON DELETE SET NULL);\n",
+			want: []string{"c.a_id は NOT NULL ですが、外部キーに ON DELETE SET NULL が指定されています"},
+		},
+		{
+			name: "参照先が NULL を許容する",
+			rule: "fk-nullability",
+			src:  "CREATE TABLE a (id int, code int UNIQUE);\nCREATE TABLE c (a_code int REFERENCES a(code));\n",
+			want: []string{"c.a_code が参照する a.code は NULL を許容しています"},
+		},
+		{
+			name: "同じスキーマで制約名が重複する",
+			rule: "constraint-name-collision",
+			src:  "CREATE TABLE c (id int, CONSTRAINT dup UNIQUE (id));\nCREATE TABLE d (id int, CONSTRAINT dup UNIQUE (id));\nCREATE TABLE other.e (id int, CONSTRAINT dup UNIQUE (id));\n",
+			want: []string{"制約名 dup が c と d で重複しています"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{}
+			if tt.dialect != "" {
+				opts.Dialect = mustDialect(t, tt.dialect)
+			}
+			tables, err := TablesWithOptions(strings.NewReader(tt.src), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, issue := range Lint(tables, map[string]bool{tt.rule: true}, opts) {
+				if issue.Rule != tt.rule {
+					t.Errorf("無効な規則 %s の問題が返りました", issue.Rule)
+				}
+				got = append(got, issue.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Lint = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintRuleSet(t *testing.T) {
+	tests := []struct {
+		enable, disable string
+		want            []string
+		wantErr         bool
+	}{
+		{want: []string{"no-primary-key", "fk-target-key", "fk-type-mismatch", "fk-nullability", "constraint-name-collision"}},
+		{disable: "no-primary-key, fk-nullability", want: []string{"fk-target-key", "fk-type-mismatch", "constraint-name-collision"}},
+		{enable: "fk-type-mismatch,fk-nullability", disable: "fk-nullability", want: []string{"fk-type-mismatch"}},
+		{enable: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		enabled, err := LintRuleSet(tt.enable, tt.disable)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("LintRuleSet(%q, %q) がエラーを返しませんでした", tt.enable, tt.disable)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, rule := range LintRules {
+			if enabled[rule.Name] {
+				got = append(got, rule.Name)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("LintRuleSet(%q, %q) = %v, want %v", tt.enable, tt.disable, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ddl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Manifest は出力の先頭にコメントで書く、出力を作ったときの情報
+type Manifest struct {
+	// orderddl のバージョン
+	Version string
+	// 入力のハッシュ（以前の Manifest のコメントは除いて計算する）
+	InputHash string
+	// 方言（指定がない場合は空）
+	Dialect string
+	// 出力したテーブルの順
+	Order []string
+}
+
+var reManifestComment = regexp.MustCompile(`(?m)^[ \t]*-- orderddl: manifest .*(?:\n|$)`)
+
+// String は「-- orderddl: manifest」で始まるコメントの行を返す
+func (m Manifest) String() string {
+	dialect := m.Dialect
+	if dialect == "" {
+		dialect = "none"
+	}
+	var b strings.Builder
+	b.WriteString("-- orderddl: manifest version=" + m.Version + "\n")
+	b.WriteString("-- orderddl: manifest input=" + m.InputHash + "\n")
+	b.WriteString("-- orderddl: manifest dialect=" + dialect + "\n")
+	b.WriteString("-- orderddl: manifest tables=" + strconv.Itoa(len(m.Order)) + "\n")
+	b.WriteString("-- orderddl: manifest order=" + strings.Join(m.Order, ", ") + "\n")
+	return b.String()
+}
+
+// StripManifest は以前に出力した Manifest のコメントの行を取り除く（ない場合は s をコピーせずに返す）
+func StripManifest(s string) string {
+	if !strings.Contains(s, "-- orderddl: manifest ") {
+		return s
+	}
+	return reManifestComment.ReplaceAllString(s, "")
+}
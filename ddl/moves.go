@@ -0,0 +1,304 @@
+package ddl
+
+import "sort"
+
+// Move は MinimalMoves で入力の位置から動かしたテーブル
+type Move struct {
+	Table string
+	// 移動先の直前のテーブル（空の場合は先頭）
+	After string
+}
+
+// 最小の移動を探す分岐の上限（超えた場合はそこまでに見つけた最良の結果を使う）
+const maxMoveSearch = 100000
+
+// MinimalMoves は tables（入力に現れた順）のうちできるだけ多くのテーブルを入力での順に残し、
+// 依存関係を満たすために動かす必要のあるテーブルだけを動かした作成順序と、その移動を返す。
+// 動かすテーブルは、依存先がすべて置かれた後のできるだけ入力に近い位置に置く
+func MinimalMoves(tables []string, graph map[string][]string) ([]string, []Move) {
+	index := make(map[string]int, len(tables))
+	for i, table := range tables {
+		index[table] = i
+	}
+
+	// 後に現れるテーブルに（間接的にでも）依存されているテーブルは、そのテーブルと同時には残せない
+	conflicts := make([][]int, len(tables))
+	for i, table := range tables {
+		for _, descendant := range descendants(table, graph) {
+			if j, defined := index[descendant]; defined && j < i {
+				conflicts[i] = append(conflicts[i], j)
+				conflicts[j] = append(conflicts[j], i)
+			}
+		}
+	}
+
+	// 動かすテーブルは、どの組でも少なくとも一方を動かす最小のテーブルの集合
+	moved := minimumCover(conflicts)
+
+	// 残すテーブルの入力での順を依存関係に加えて並べる
+	constrained := make(map[string][]string, len(graph))
+	for parent, children := range graph {
+		constrained[parent] = append([]string{}, children...)
+	}
+	previous := ""
+	for i, table := range tables {
+		if moved[i] {
+			continue
+		}
+		if previous != "" {
+			constrained[previous] = append(constrained[previous], table)
+		}
+		previous = table
+	}
+	sorted := sortTables(tables, constrained, nil, nil)
+
+	var moves []Move
+	for i, table := range sorted {
+		if !moved[index[table]] {
+			continue
+		}
+		move := Move{Table: table}
+		if i > 0 {
+			move.After = sorted[i-1]
+		}
+		moves = append(moves, move)
+	}
+	return sorted, moves
+}
+
+// table に（間接的にでも）依存するテーブル（table 自身は含めない）
+func descendants(table string, graph map[string][]string) []string {
+	visited := map[string]bool{table: true}
+	stack := []string{table}
+	var found []string
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, child := range graph[current] {
+			if !visited[child] {
+				visited[child] = true
+				found = append(found, child)
+				stack = append(stack, child)
+			}
+		}
+	}
+	return found
+}
+
+// minimumCover は conflicts（隣接リスト）のすべての組について少なくとも一方を含む、最小の頂点の集合を返す。
+// 次数の大きい頂点から「含める」「隣接する頂点をすべて含める」の2通りに分岐し、
+// 分岐が maxMoveSearch を超えた場合はそこまでに見つけた最良の結果を返す
+func minimumCover(conflicts [][]int) []bool {
+	n := len(conflicts)
+	cover := make([]bool, n)
+	best := greedyCover(conflicts)
+	bestSize := countTrue(best)
+	steps := 0
+
+	var search func(size int)
+	search = func(size int) {
+		steps++
+		if size >= bestSize || steps > maxMoveSearch {
+			return
+		}
+		// まだ覆われていない組が最も多い頂点
+		vertex, degree := -1, 0
+		for v := range n {
+			if cover[v] {
+				continue
+			}
+			d := uncoveredDegree(v, conflicts, cover)
+			if d > degree {
+				vertex, degree = v, d
+			}
+		}
+		if vertex < 0 {
+			best = append(best[:0:0], cover...)
+			bestSize = size
+			return
+		}
+
+		cover[vertex] = true
+		search(size + 1)
+		cover[vertex] = false
+
+		if degree > 1 {
+			var added []int
+			for _, u := range conflicts[vertex] {
+				if !cover[u] {
+					cover[u] = true
+					added = append(added, u)
+				}
+			}
+			search(size + len(added))
+			for _, u := range added {
+				cover[u] = false
+			}
+		}
+	}
+	search(0)
+	return best
+}
+
+// 覆われていない組が最も多い頂点から順に含める
+func greedyCover(conflicts [][]int) []bool {
+	cover := make([]bool, len(conflicts))
+	for {
+		vertices := make([]int, 0, len(conflicts))
+		for v := range conflicts {
+			if !cover[v] && uncoveredDegree(v, conflicts, cover) > 0 {
+				vertices = append(vertices, v)
+			}
+		}
+		if len(vertices) == 0 {
+			return cover
+		}
+		// 同じ数の場合は後に現れる頂点を含める
+		sort.SliceStable(vertices, func(i, j int) bool {
+			di, dj := uncoveredDegree(vertices[i], conflicts, cover), uncoveredDegree(vertices[j], conflicts, cover)
+			if di != dj {
+				return di > dj
+			}
+			return vertices[i] > vertices[j]
+		})
+		cover[vertices[0]] = true
+	}
+}
+
+func uncoveredDegree(v int, conflicts [][]int, cover []bool) int {
+	d := 0
+	for _, u := range conflicts[v] {
+		if !cover[u] {
+			d++
+		}
+	}
+	return d
+}
+
+func countTrue(values []bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// Relocation は入力の順から作成順序に並べ替えたときに動いたテーブル
+type Relocation struct {
+	Table string `json:"table"`
+	// 入力と出力での順番（1始まり）
+	From int `json:"from"`
+	To   int `json:"to"`
+	// 入力で後に現れる直接の依存先（このテーブルを後ろに動かす必要があった理由）
+	DependsOn []string `json:"depends_on,omitempty"`
+	// 入力で前に現れる、このテーブルに直接依存するテーブル（このテーブルを前に動かす必要があった理由）
+	RequiredBy []string `json:"required_by,omitempty"`
+}
+
+// Relocations は tables（入力に現れた順）を sortedTables の順にするために動かしたテーブルを作成順に返す。
+// 動かさなかったテーブルは入力での順を保つ最も多いテーブル（最長増加部分列、同じ数の場合は順番が変わらないテーブルを多く含むもの）で、
+// 順番が変わらないテーブルは含めない。DependsOn と RequiredBy がどちらも空のテーブルは依存関係ではなく並び順の指定などで動いたもの
+func Relocations(tables, sortedTables []string, graph map[string][]string) []Relocation {
+	from := make(map[string]int, len(tables))
+	for i, table := range tables {
+		from[table] = i
+	}
+	to := make(map[string]int, len(sortedTables))
+	for i, table := range sortedTables {
+		to[table] = i
+	}
+
+	var positions []int
+	var unchanged []bool
+	for j, table := range tables {
+		if i, sorted := to[table]; sorted {
+			positions = append(positions, i)
+			unchanged = append(unchanged, i == j)
+		}
+	}
+	kept := longestIncreasing(positions, unchanged)
+
+	deps := Dependencies(graph)
+	var relocations []Relocation
+	for i, table := range sortedTables {
+		j, defined := from[table]
+		if !defined || kept[i] || j == i {
+			continue
+		}
+		relocation := Relocation{Table: table, From: j + 1, To: i + 1}
+		for _, parent := range deps[table] {
+			if k, defined := from[parent]; defined && k > j {
+				relocation.DependsOn = append(relocation.DependsOn, parent)
+			}
+		}
+		for _, child := range graph[table] {
+			if k, defined := from[child]; defined && k < j && !contains(relocation.RequiredBy, child) {
+				relocation.RequiredBy = append(relocation.RequiredBy, child)
+			}
+		}
+		sort.Strings(relocation.RequiredBy)
+		relocations = append(relocations, relocation)
+	}
+	return relocations
+}
+
+// longestIncreasing は values（重複しない 0 以上の値）の最長増加部分列に含まれる値を返す。
+// 同じ長さの部分列が複数ある場合は preferred の値を多く含むものを、それも同じ場合は末尾の値が小さいものを選ぶ
+func longestIncreasing(values []int, preferred []bool) map[int]bool {
+	size := 0
+	for _, v := range values {
+		size = max(size, v+1)
+	}
+	// 値の区間ごとに、その区間の値で終わる最良の部分列を持つ Fenwick 木
+	tree := make([]subsequence, size+1)
+	previous := make([]int, len(values))
+	var best subsequence
+	for i, v := range values {
+		// v より小さい値で終わる最良の部分列に v を加える
+		var prefix subsequence
+		for k := v; k > 0; k -= k & -k {
+			if tree[k].better(prefix) {
+				prefix = tree[k]
+			}
+		}
+		previous[i] = prefix.last - 1
+		current := subsequence{length: prefix.length + 1, preferred: prefix.preferred, end: v, last: i + 1}
+		if preferred[i] {
+			current.preferred++
+		}
+		for k := v + 1; k <= size; k += k & -k {
+			if current.better(tree[k]) {
+				tree[k] = current
+			}
+		}
+		if current.better(best) {
+			best = current
+		}
+	}
+
+	kept := make(map[int]bool, best.length)
+	for i := best.last - 1; i >= 0; i = previous[i] {
+		kept[values[i]] = true
+	}
+	return kept
+}
+
+// longestIncreasing で比べる増加部分列
+type subsequence struct {
+	length, preferred int
+	// 末尾の値と、その values での添字 + 1（空の場合は 0）
+	end  int
+	last int
+}
+
+func (s subsequence) better(other subsequence) bool {
+	if s.length != other.length {
+		return s.length > other.length
+	}
+	if s.preferred != other.preferred {
+		return s.preferred > other.preferred
+	}
+	return s.end < other.end
+}
@@ -0,0 +1,77 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRelocations(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables []string
+		sorted []string
+		graph  map[string][]string
+		want   []string
+	}{
+		{
+			name:   "動かさない",
+			tables: []string{"a", "b"},
+			sorted: []string{"a", "b"},
+		},
+		{
+			name:   "2つのテーブルの入れ替えでは依存するテーブルを動かす",
+			tables: []string{"b", "a"},
+			sorted: []string{"a", "b"},
+			graph:  map[string][]string{"a": {"b"}},
+			want:   []string{"b 1→2 依存 a"},
+		},
+		{
+			name:   "同じ長さの場合は順番が変わらないテーブルを残す",
+			tables: []string{"b", "a", "c"},
+			sorted: []string{"c", "a", "b"},
+			graph:  map[string][]string{"c": {"a"}, "a": {"b"}},
+			want:   []string{"c 3→1 から a", "b 1→3 依存 a"},
+		},
+		{
+			name:   "順番が変わらないテーブルは含めない",
+			tables: []string{"a", "b", "c"},
+			sorted: []string{"c", "b", "a"},
+			graph:  map[string][]string{"c": {"b"}, "b": {"a"}},
+			want:   []string{"c 3→1 から b", "a 1→3 依存 b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range Relocations(tt.tables, tt.sorted, tt.graph) {
+				if r.From == r.To {
+					t.Errorf("%s の順番が変わらないのに動かしたテーブルに含まれています", r.Table)
+				}
+				s := fmt.Sprintf("%s %d→%d", r.Table, r.From, r.To)
+				if len(r.DependsOn) > 0 {
+					s += " 依存 " + strings.Join(r.DependsOn, ",")
+				}
+				if len(r.RequiredBy) > 0 {
+					s += " から " + strings.Join(r.RequiredBy, ",")
+				}
+				got = append(got, s)
+			}
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("Relocations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimalMoves(t *testing.T) {
+	tables := []string{"c", "a", "b"}
+	graph := map[string][]string{"a": {"c"}, "b": {}, "c": {}}
+	sorted, moves := MinimalMoves(tables, graph)
+	if got := strings.Join(sorted, ","); got != "a,c,b" {
+		t.Errorf("sorted = %s, want a,c,b", got)
+	}
+	if len(moves) != 1 || moves[0].Table != "a" || moves[0].After != "" {
+		t.Errorf("moves = %+v, want a を先頭に", moves)
+	}
+}
@@ -0,0 +1,307 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SoftConstraintPolicy は NOT VALID / NOT ENFORCED の外部キーの扱い
+type SoftConstraintPolicy string
+
+const (
+	// SoftConstraintsOrder は通常の外部キーと同じく作成順序に反映する
+	SoftConstraintsOrder SoftConstraintPolicy = "order"
+	// SoftConstraintsWarn は作成順序に反映せず、参照先より先に作成される場合に警告する
+	SoftConstraintsWarn SoftConstraintPolicy = "warn"
+	// SoftConstraintsIgnore は作成順序に反映しない
+	SoftConstraintsIgnore SoftConstraintPolicy = "ignore"
+)
+
+// ParseSoftConstraintPolicy は文字列から SoftConstraintPolicy を返す（空文字は order）
+func ParseSoftConstraintPolicy(s string) (SoftConstraintPolicy, error) {
+	switch SoftConstraintPolicy(s) {
+	case "", SoftConstraintsOrder:
+		return SoftConstraintsOrder, nil
+	case SoftConstraintsWarn, SoftConstraintsIgnore:
+		return SoftConstraintPolicy(s), nil
+	}
+	return "", fmt.Errorf("不明な soft-constraints の指定です: %s", s)
+}
+
+// Resolution はスキーマで修飾されていない参照先の解決方法
+type Resolution string
+
+const (
+	// ResolveSearchPath は SearchPath のスキーマを順に探す（Postgres の search_path と同じ）
+	ResolveSearchPath Resolution = "search-path"
+	// ResolveSameSchema は参照元と同じスキーマを先に探し、なければ SearchPath を探す（MySQL の同じデータベース内の参照と同じ）
+	ResolveSameSchema Resolution = "same-schema"
+)
+
+// ParseResolution は文字列から Resolution を返す（空文字は search-path）
+func ParseResolution(s string) (Resolution, error) {
+	switch Resolution(s) {
+	case "", ResolveSearchPath:
+		return ResolveSearchPath, nil
+	case ResolveSameSchema:
+		return ResolveSameSchema, nil
+	}
+	return "", fmt.Errorf("不明な resolve の指定です: %s", s)
+}
+
+// UnknownPlacement は組み込みの解析・方言のどちらでも種類を判断できない文の置き場所
+type UnknownPlacement string
+
+const (
+	// UnknownKeep は前後の文と同じ位置に残す（最初のテーブルより前の文は最初のテーブルのブロックに含める）
+	UnknownKeep UnknownPlacement = "keep"
+	// UnknownTop は出力の先頭にまとめる
+	UnknownTop UnknownPlacement = "top"
+	// UnknownBottom は出力の末尾にまとめる
+	UnknownBottom UnknownPlacement = "bottom"
+)
+
+// ParseUnknownPlacement は文字列から UnknownPlacement を返す（空文字はそのまま）
+func ParseUnknownPlacement(s string) (UnknownPlacement, error) {
+	switch UnknownPlacement(s) {
+	case "", UnknownKeep, UnknownTop, UnknownBottom:
+		return UnknownPlacement(s), nil
+	}
+	return "", fmt.Errorf("不明な unknown の指定です: %s", s)
+}
+
+// TemporaryPolicy は一時テーブルの文の扱い
+type TemporaryPolicy string
+
+const (
+	// TemporaryKeep は並び替えの対象にせず、前後の文と同じ位置にそのまま残す
+	TemporaryKeep TemporaryPolicy = "keep"
+	// TemporaryExclude は出力から除く
+	TemporaryExclude TemporaryPolicy = "exclude"
+)
+
+// ParseTemporaryPolicy は文字列から TemporaryPolicy を返す（空文字は keep）
+func ParseTemporaryPolicy(s string) (TemporaryPolicy, error) {
+	switch TemporaryPolicy(s) {
+	case "", TemporaryKeep:
+		return TemporaryKeep, nil
+	case TemporaryExclude:
+		return TemporaryExclude, nil
+	}
+	return "", fmt.Errorf("不明な temporary の指定です: %s", s)
+}
+
+// ViewPolicy は CREATE VIEW の扱い
+type ViewPolicy string
+
+const (
+	// ViewsSkip は出力から除く
+	ViewsSkip ViewPolicy = "skip"
+	// ViewsOrder はテーブルと同じように並び替え、FROM と JOIN で参照する入力のテーブルとビューの後に置く
+	// （入力に定義されていないテーブルへの参照は作成順序に影響しない）
+	ViewsOrder ViewPolicy = "order"
+	// ViewsPassthrough は並び替えの対象にせず、前後の文と同じ位置にそのまま残す
+	ViewsPassthrough ViewPolicy = "passthrough"
+)
+
+// ParseViewPolicy は文字列から ViewPolicy を返す（空文字はそのまま）
+func ParseViewPolicy(s string) (ViewPolicy, error) {
+	switch ViewPolicy(s) {
+	case "", ViewsSkip, ViewsOrder, ViewsPassthrough:
+		return ViewPolicy(s), nil
+	}
+	return "", fmt.Errorf("不明な views の指定です: %s", s)
+}
+
+// TieBreak は依存関係で順序が決まらないテーブルの並べ方
+type TieBreak string
+
+const (
+	// TieBreakInput は入力に現れた順に並べる
+	TieBreakInput TieBreak = "input"
+	// TieBreakAlpha はテーブル名の順に並べる
+	TieBreakAlpha TieBreak = "alpha"
+)
+
+// ParseTieBreak は文字列から TieBreak を返す（空文字は input）
+func ParseTieBreak(s string) (TieBreak, error) {
+	switch TieBreak(s) {
+	case "", TieBreakInput:
+		return TieBreakInput, nil
+	case TieBreakAlpha:
+		return TieBreakAlpha, nil
+	}
+	return "", fmt.Errorf("不明な tie-break の指定です: %s", s)
+}
+
+// CyclePolicy は DEFERRABLE INITIALLY DEFERRED の外部キーで解消できない循環依存の扱い
+type CyclePolicy string
+
+const (
+	// CyclesError は ErrCycle を返す（Options.ResolveCycle がある場合はそれで選ぶ）
+	CyclesError CyclePolicy = "error"
+	// CyclesComment は循環ごとに外部キーを1つ選んで作成順序の判断から除外し、CommentOutForeignKeys でコメントにする
+	CyclesComment CyclePolicy = "comment"
+	// CyclesFix は循環に含まれる外部キーをすべて作成順序の判断から除外し、FixForeignKeys で末尾の ALTER TABLE に移す
+	CyclesFix CyclePolicy = "fix"
+)
+
+// ParseCyclePolicy は文字列から CyclePolicy を返す（空文字は error）
+func ParseCyclePolicy(s string) (CyclePolicy, error) {
+	switch CyclePolicy(s) {
+	case "", CyclesError:
+		return CyclesError, nil
+	case CyclesComment, CyclesFix:
+		return CyclePolicy(s), nil
+	}
+	return "", fmt.Errorf("不明な cycles の指定です: %s", s)
+}
+
+// Options は解析と並び替えの設定
+type Options struct {
+	// NOT VALID / NOT ENFORCED の外部キーの扱い
+	SoftConstraints SoftConstraintPolicy
+	// 修飾されていないテーブル名を探すスキーマ（先頭のスキーマは修飾されていない CREATE TABLE のスキーマとみなす）
+	SearchPath []string
+	// 修飾されていない参照先の解決方法
+	Resolution Resolution
+	// 終端文字と方言に固有の依存関係（nil の場合は ; で区切り、REFERENCES だけを見る）
+	Dialect Dialect
+	// 文の既定の終端文字（空の場合は方言の終端文字）
+	Terminator string
+	// CREATE OR REPLACE で置き換えられる前の定義も出力する（false の場合は最後の定義だけを出力する）
+	KeepReplaced bool
+	// 一時テーブルの文の扱い（一時テーブルはどちらの場合もテーブルとして並び替えない）
+	Temporary TemporaryPolicy
+	// CREATE VIEW の扱い（空の場合はほかの種類を判断できない文と同じように扱う）
+	Views ViewPolicy
+	// 種類を判断できない文の置き場所（空の場合は前後の文と同じ位置に残し、最初のテーブルより前の文は出力しない）
+	Unknown UnknownPlacement
+	// 依存関係で順序が決まらないテーブルの並べ方（空の場合は入力に現れた順）
+	TieBreak TieBreak
+	// テーブル名のパターンごとの並び順の重み（依存関係で順序が決まらないテーブルは重みが小さいものから置く）
+	Weights []Weight
+	// 並べ直さずに、依存関係を満たすために必要な最小のテーブルだけを動かす（TieBreak と Weights、orderddl:first / orderddl:last は使わない）
+	MinimalMoves bool
+	// 出力する文の終端文字の揃え方（空の場合は入力のまま）
+	Terminators TerminatorStyle
+	// Analyze が1文を読むごとに、それまでに読んだバイト数と見つけたテーブルの数を渡して呼ぶ関数（nil の場合は呼ばない）
+	Progress func(read int64, tables int)
+	// Analyze が DEFERRABLE INITIALLY DEFERRED の外部キーで解消できない循環依存ごとに、循環しているテーブルと
+	// 循環に含まれる依存関係を渡して呼ぶ関数。返した位置の依存関係を作成順序の判断から除外して続け、
+	// エラーを返した場合はそのエラーで止める（nil の場合は ErrCycle を返す）
+	ResolveCycle func(cycle []string, candidates []Edge) (int, error)
+	// 循環依存の扱い（空の場合は error）
+	Cycles CyclePolicy
+	// 入力の依存関係に加える依存関係（修飾されていないテーブル名は SearchPath と Resolution に従って解決する）
+	ExtraDependencies []Dependency
+	// 作成順序の判断から除く依存関係（削除する予定の外部キーなど。子と親の間のすべての依存関係を除く）
+	IgnoredDependencies []Dependency
+}
+
+// 方言の終端文字で区切る StatementScanner を返す
+func (o Options) newScanner(ctx context.Context, r io.Reader) *StatementScanner {
+	scanner := NewStatementScannerContext(ctx, r)
+	switch {
+	case o.Terminator != "":
+		scanner.delimiter = o.Terminator
+	case o.Dialect != nil && o.Dialect.Terminator() != "":
+		scanner.delimiter = o.Dialect.Terminator()
+	}
+	if d, ok := findDialect[EscapeDialect](o.Dialect); ok {
+		scanner.backslashEscapes = d.BackslashEscapes()
+	}
+	return scanner
+}
+
+// 方言が定義するオブジェクト、並び替えるビュー、シノニム、ユーザー定義の型・照合順序・テーブルスペースと拡張の名前（方言に固有の書き方の CREATE TABLE を含む）
+func (o Options) dialectObject(stmt Statement) (string, bool) {
+	if ignored(stmt) || reTemporaryTable.MatchString(stmt.Code) {
+		return "", false
+	}
+	if name, isTable := o.dialectTable(stmt); isTable {
+		return name, true
+	}
+	if name, isView := o.orderedView(stmt); isView {
+		return name, true
+	}
+	if name, _, isSynonym := synonymDefinition(stmt.Code, ""); isSynonym {
+		return name, true
+	}
+	if name, isType := typeDefinition(stmt.Code); isType {
+		return name, true
+	}
+	if name, isCollation := collationDefinition(stmt.Code); isCollation {
+		return name, true
+	}
+	if name, isTablespace := tablespaceDefinition(stmt.Code); isTablespace {
+		return name, true
+	}
+	if name, isExtension := extensionDefinition(stmt.Code); isExtension {
+		return name, true
+	}
+	if d, ok := o.Dialect.(ObjectDialect); ok {
+		return d.Object(stmt)
+	}
+	return "", false
+}
+
+// 方言に固有の書き方の CREATE TABLE で定義するテーブルの名前
+func (o Options) dialectTable(stmt Statement) (string, bool) {
+	if d, ok := findDialect[TableDialect](o.Dialect); ok && !reTemporaryTable.MatchString(stmt.Code) {
+		return d.CreateTable(stmt)
+	}
+	return "", false
+}
+
+// InformationalConstraints は方言が外部キーを検査せず、すべての外部キーを作成順序に反映するかどうかを返す
+func (o Options) InformationalConstraints() bool {
+	d, ok := findDialect[InformationalDialect](o.Dialect)
+	return ok && d.InformationalConstraints()
+}
+
+// 方言に固有の依存関係
+func (o Options) dialectDependencies(stmt Statement) []string {
+	if _, isView := o.orderedView(stmt); isView {
+		return viewSources(stmt.Code)
+	}
+	// シノニムは対象を作成した後に作成する
+	if _, target, isSynonym := synonymDefinition(stmt.Code, ""); isSynonym {
+		return []string{target}
+	}
+	if o.Dialect == nil {
+		return nil
+	}
+	return o.Dialect.Dependencies(stmt)
+}
+
+// 組み込みの解析・方言のどちらでも種類を判断できない文かどうか（orderddl:ignore の文は含めない）
+func (o Options) unknown(stmt Statement) bool {
+	if recognized(stmt) || ignored(stmt) || o.Views != "" && reCreateView.MatchString(stmt.Code) {
+		return false
+	}
+	if _, isObject := o.dialectObject(stmt); isObject {
+		return false
+	}
+	return len(o.dialectDependencies(stmt)) == 0
+}
+
+// 依存関係で順序が決まらない場合に先に置く順のテーブル
+func (o Options) tieBreakOrder(tables []string) []string {
+	if o.TieBreak != TieBreakAlpha {
+		return tables
+	}
+	ordered := append([]string{}, tables...)
+	sort.Strings(ordered)
+	return ordered
+}
+
+// 作成順序に反映する外部キーかどうか
+func (o Options) ordersBy(fk ForeignKey) bool {
+	if !fk.Soft() || o.InformationalConstraints() {
+		return true
+	}
+	return o.SoftConstraints == "" || o.SoftConstraints == SoftConstraintsOrder
+}
@@ -0,0 +1,136 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Plan は並び替えたDDLを段ごとに適用するための計画
+type Plan struct {
+	// 段ごとのテーブル（同じ段のテーブルは互いに依存しないため並行して作成できる）
+	Stages [][]string `json:"stages"`
+	// テーブルごとの情報
+	Tables map[string]PlanTable `json:"tables"`
+}
+
+// PlanTable は計画の中の1つのテーブル
+type PlanTable struct {
+	Stage int `json:"stage"`
+	// 並び替えたDDLの中での文の番号（0始まり）
+	Statements []int    `json:"statements"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+}
+
+// BuildPlan は並び替えたDDLと、その適用計画を返す。
+// 文の番号は Statements が返す文の位置と一致する
+func BuildPlan(sortedTables []string, graph map[string][]string, ddlContent map[string]string) (*Plan, string) {
+	return BuildPlanWithOptions(sortedTables, graph, ddlContent, Options{})
+}
+
+// BuildPlanWithOptions は opts の方言の終端文字で文を区切る BuildPlan。
+// 文の番号は StatementsWithOptions に同じ opts を渡して返る文の位置と一致する
+func BuildPlanWithOptions(sortedTables []string, graph map[string][]string, ddlContent map[string]string, opts Options) (*Plan, string) {
+	levels := Levels(sortedTables, graph)
+	deps := Dependencies(graph)
+	plan := &Plan{Stages: [][]string{}, Tables: make(map[string]PlanTable)}
+
+	// ブロックの開始位置を記録しながら並び替えたDDLを組み立てる
+	var out strings.Builder
+	var tables []string
+	var starts []int
+	blocks := scopedBlocks(sortedTables, ddlContent)
+	for _, table := range sortedTables {
+		if _, exists := ddlContent[table]; !exists {
+			continue
+		}
+		tables = append(tables, table)
+		starts = append(starts, out.Len())
+		out.WriteString(blocks[len(tables)-1])
+
+		level := levels[table]
+		for len(plan.Stages) <= level {
+			plan.Stages = append(plan.Stages, []string{})
+		}
+		plan.Stages[level] = append(plan.Stages[level], table)
+		plan.Tables[table] = PlanTable{Stage: level, Statements: []int{}, DependsOn: deps[table]}
+	}
+	ordered := out.String()
+
+	index := 0
+	scanner := opts.newScanner(context.Background(), strings.NewReader(ordered))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		if !stmt.executable() {
+			continue
+		}
+		i := sort.SearchInts(starts, stmt.Offset+1) - 1
+		if i >= 0 {
+			entry := plan.Tables[tables[i]]
+			entry.Statements = append(entry.Statements, index)
+			plan.Tables[tables[i]] = entry
+		}
+		index++
+	}
+	return plan, ordered
+}
+
+// Statements はデータベースに送る文を、前置きの空白と終端文字を除いて順に返す
+func Statements(src string) ([]string, error) {
+	return StatementsWithOptions(src, Options{})
+}
+
+// StatementsWithOptions は opts の方言の終端文字で文を区切る Statements。
+// COPY ... FROM stdin のデータ行は文として送れないため、含まれている場合はエラーにする
+func StatementsWithOptions(src string, opts Options) ([]string, error) {
+	var statements []string
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		if stmt.CopyData {
+			return nil, fmt.Errorf("%d行目: COPY ... FROM stdin のデータ行はデータベースに文として送れません（データは psql などで読み込んでください）", stmt.Line)
+		}
+		if stmt.executable() {
+			statements = append(statements, stmt.SQL())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// ExecutableStatements は src を opts の終端文字で区切り、データベースに送る文を順に返す。
+// COPY ... FROM stdin とそのデータ行はクライアントが送るものであるため含めない
+func ExecutableStatements(src string, opts Options) ([]Statement, error) {
+	var statements []Statement
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		switch {
+		case stmt.CopyData:
+			// データ行の直前の文が COPY
+			if n := len(statements); n > 0 {
+				statements = statements[:n-1]
+			}
+		case stmt.executable():
+			statements = append(statements, stmt)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// 空白やコメントだけの文と DELIMITER コマンドはデータベースに送らない
+func (s Statement) executable() bool {
+	code := s.Code
+	if s.Terminator != "" {
+		if i := strings.LastIndex(code, s.Terminator); i >= 0 {
+			code = code[:i]
+		}
+	}
+	return !s.Directive && strings.TrimSpace(code) != ""
+}
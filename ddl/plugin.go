@@ -0,0 +1,161 @@
+package ddl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// PluginRequest はプラグインに送る文（1行に1つのJSON）
+type PluginRequest struct {
+	// 前置きの空白と終端文字を除いた文
+	SQL string `json:"sql"`
+	// 開始行（1始まり）
+	Line int `json:"line"`
+}
+
+// PluginResponse はプラグインから受け取る結果（1行に1つのJSON）
+type PluginResponse struct {
+	// 文が新しいオブジェクトを定義する場合はその名前（空の場合はオブジェクトを定義しない）
+	Name string `json:"name,omitempty"`
+	// 文（Name が空の場合は文を含むブロック）が依存するテーブル
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Plugin は外部コマンドに組み込みの解析で判断できない文を渡し、オブジェクトの定義と依存関係を受け取る方言。
+// 文ごとに PluginRequest を1行のJSONで標準入力に書き、PluginResponse を1行のJSONで標準出力から読む
+type Plugin struct {
+	// 識別子の引用符と終端文字、組み込みの依存関係に使う方言（nil の場合は ; で区切る）
+	Base Dialect
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+
+	mu sync.Mutex
+	// 同じ文を Analyze と Split で2回問い合わせないための結果
+	cache map[string]PluginResponse
+	err   error
+}
+
+// StartPlugin は外部コマンドをプラグインとして起動する（プラグインの標準エラー出力はそのまま表示する）
+func StartPlugin(name string, args ...string) (*Plugin, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("プラグインを起動できませんでした: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("プラグインを起動できませんでした: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("プラグインを起動できませんでした: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &Plugin{cmd: cmd, stdin: stdin, stdout: scanner, cache: make(map[string]PluginResponse)}, nil
+}
+
+// Close はプラグインの標準入力を閉じ、終了を待つ
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("プラグインが異常終了しました: %w", err)
+	}
+	return nil
+}
+
+// Err はプラグインとのやり取りで最初に起きたエラーを返す（エラーの後はプラグインに問い合わせない）
+func (p *Plugin) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *Plugin) Name() string { return p.cmd.Path }
+
+func (p *Plugin) QuoteIdentifier(name string) string {
+	if p.Base != nil {
+		return p.Base.QuoteIdentifier(name)
+	}
+	return name
+}
+
+func (p *Plugin) Terminator() string {
+	if p.Base != nil {
+		return p.Base.Terminator()
+	}
+	return ";"
+}
+
+func (p *Plugin) Dependencies(stmt Statement) []string {
+	var deps []string
+	if p.Base != nil {
+		deps = p.Base.Dependencies(stmt)
+	}
+	return append(deps, p.query(stmt).Dependencies...)
+}
+
+func (p *Plugin) Object(stmt Statement) (string, bool) {
+	if d, ok := p.Base.(ObjectDialect); ok {
+		if name, ok := d.Object(stmt); ok {
+			return name, true
+		}
+	}
+	name := p.query(stmt).Name
+	return name, name != ""
+}
+
+// 組み込みの解析で判断できない文をプラグインに問い合わせる
+func (p *Plugin) query(stmt Statement) PluginResponse {
+	if recognized(stmt) {
+		return PluginResponse{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return PluginResponse{}
+	}
+	sql := stmt.SQL()
+	if resp, exists := p.cache[sql]; exists {
+		return resp
+	}
+
+	resp, err := p.exchange(PluginRequest{SQL: sql, Line: stmt.Line + strings.Count(leadingText(stmt), "\n")})
+	if err != nil {
+		p.err = err
+		return PluginResponse{}
+	}
+	p.cache[sql] = resp
+	return resp
+}
+
+func (p *Plugin) exchange(req PluginRequest) (PluginResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return PluginResponse{}, err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return PluginResponse{}, fmt.Errorf("プラグインに書き込めませんでした: %w", err)
+	}
+	if !p.stdout.Scan() {
+		if err := p.stdout.Err(); err != nil {
+			return PluginResponse{}, fmt.Errorf("プラグインから読み込めませんでした: %w", err)
+		}
+		return PluginResponse{}, fmt.Errorf("プラグインが応答せずに終了しました（%d行目の文）", req.Line)
+	}
+	var resp PluginResponse
+	if err := json.Unmarshal(p.stdout.Bytes(), &resp); err != nil {
+		return PluginResponse{}, fmt.Errorf("プラグインの応答を解析できませんでした（%d行目の文）: %w", req.Line, err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,48 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// CREATE [LOCAL] [TEMP | TEMPORARY] TABLE [IF NOT EXISTS] 名前
+	reRedshiftTable = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:LOCAL\s+)?(?:(?:TEMP|TEMPORARY)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + DIALECT_NAME_PATTERN)
+	// CREATE TABLE 名前 (LIKE 元 ...)
+	reRedshiftLike = regexp.MustCompile(`(?is)^\s*CREATE\b[^(]*?\bTABLE\b[^(]*\(\s*LIKE\s+` + DIALECT_NAME_PATTERN)
+)
+
+func init() {
+	RegisterDialect(redshiftDialect{})
+}
+
+// Amazon Redshift。外部キーは検査されない情報としての制約だが、作成順序には反映する
+type redshiftDialect struct{}
+
+func (redshiftDialect) Name() string { return "redshift" }
+
+func (redshiftDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (redshiftDialect) Terminator() string { return ";" }
+
+func (redshiftDialect) FoldIdentifier(name string) string { return foldASCII(name, unicode.ToLower) }
+
+// LIKE の元のテーブルは先に作成する必要がある
+func (redshiftDialect) Dependencies(stmt Statement) []string {
+	if matches := reRedshiftLike.FindStringSubmatch(stmt.Code); matches != nil {
+		return []string{dialectTableName(matches[1])}
+	}
+	return nil
+}
+
+func (redshiftDialect) CreateTable(stmt Statement) (string, bool) {
+	if matches := reRedshiftTable.FindStringSubmatch(stmt.Code); matches != nil {
+		return dialectTableName(matches[1]), true
+	}
+	return "", false
+}
+
+func (redshiftDialect) InformationalConstraints() bool { return true }
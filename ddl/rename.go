@@ -0,0 +1,149 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const TRIGGER_PATTERN = `(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:DEFINER\s*=\s*\S+\s+)?(?:CONSTRAINT\s+)?TRIGGER\s+.*?\bON\s+` + QUALIFIED_NAME_PATTERN
+
+var (
+	reCreateTrigger = regexp.MustCompile(TRIGGER_PATTERN)
+	// COMMENT ON TABLE の対象
+	reCommentOnTable = regexp.MustCompile(`(?is)^\s*COMMENT\s+ON\s+TABLE\s+` + QUALIFIED_NAME_PATTERN)
+	// GRANT / REVOKE ... ON [TABLE] の対象
+	reGrantOn = regexp.MustCompile(`(?is)^\s*(?:GRANT|REVOKE)\s+.*?\bON\s+(?:TABLE\s+)?` + QUALIFIED_NAME_PATTERN)
+	// LOCK TABLES の2つ目以降のテーブル
+	reLockedTable = regexp.MustCompile(`,\s*` + QUALIFIED_NAME_PATTERN)
+)
+
+// RewriteTableNames は CREATE TABLE・ALTER TABLE・CREATE INDEX・CREATE TRIGGER・COMMENT ON TABLE・GRANT / REVOKE・LOCK TABLES と
+// INSERT / COPY の対象、REFERENCES の参照先と、ビューが FROM / JOIN で参照するテーブル名を rewrite の結果に書き換える（src で定義したビューの名前は書き換えない）
+func RewriteTableNames(src string, rewrite func(table string) string) (string, error) {
+	var statements []Statement
+	views := make(map[string]bool)
+	scanner := NewStatementScanner(strings.NewReader(src))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		if matches := reCreateView.FindStringSubmatch(stmt.Code); matches != nil {
+			views[qualifiedName(matches)] = true
+		}
+		statements = append(statements, stmt)
+	}
+	var out strings.Builder
+	for _, stmt := range statements {
+		out.WriteString(rewriteTableNames(stmt, func(table string) string {
+			if views[table] {
+				return table
+			}
+			return rewrite(table)
+		}).Text)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// RenameTables は renames（変更前 → 変更後）に従ってテーブル名を書き換える
+func RenameTables(src string, renames map[string]string) (string, error) {
+	return RewriteTableNames(src, func(table string) string {
+		if renamed, exists := renames[table]; exists {
+			return renamed
+		}
+		return table
+	})
+}
+
+// ParseRename は「変更前=変更後」の形式の指定を解析する（テーブル名を囲む引用符は除く）
+func ParseRename(s string) (from, to string, err error) {
+	from, to, found := strings.Cut(s, "=")
+	from, to = unquoteName(from), unquoteName(to)
+	if !found || from == "" || to == "" {
+		return "", "", fmt.Errorf("テーブル名の変更は 変更前=変更後 の形式で指定してください: %s", s)
+	}
+	return from, to, nil
+}
+
+// 1つの文に現れるテーブル名を書き換える
+func rewriteTableNames(stmt Statement, rewrite func(table string) string) Statement {
+	type span struct {
+		start, end int
+		name       string
+	}
+	var spans []span
+	// group はマッチ結果のうち schema.table の1つ目のグループの番号
+	add := func(loc []int, group int) {
+		if loc == nil || loc[2*group] < 0 {
+			return
+		}
+		start, end := loc[2*group], max(loc[2*group+1], loc[2*group+3])
+		name := stmt.Code[loc[2*group]:loc[2*group+1]]
+		separator := "."
+		if loc[2*group+2] >= 0 {
+			// 引用符で囲まれている場合は区切りを `.` のまま残す
+			separator = stmt.Code[loc[2*group+1]:loc[2*group+2]]
+			name += "." + stmt.Code[loc[2*group+2]:loc[2*group+3]]
+		}
+		if renamed := rewrite(name); renamed != name {
+			spans = append(spans, span{start, end, strings.Replace(renamed, ".", separator, 1)})
+		}
+	}
+
+	add(reTableDefinition.FindStringSubmatchIndex(stmt.Code), 1)
+	add(reAlterTable.FindStringSubmatchIndex(stmt.Code), 1)
+	add(reCreateIndex.FindStringSubmatchIndex(stmt.Code), 3)
+	add(reCreateTrigger.FindStringSubmatchIndex(stmt.Code), 1)
+	add(reDataTable.FindStringSubmatchIndex(stmt.Code), 1)
+	add(reCommentOnTable.FindStringSubmatchIndex(stmt.Code), 1)
+	add(reGrantOn.FindStringSubmatchIndex(stmt.Code), 1)
+	if loc := reLockTables.FindStringSubmatchIndex(stmt.Code); loc != nil {
+		add(loc, 1)
+		// READ / WRITE などを除いた、カンマで区切ったテーブル
+		for _, item := range reLockedTable.FindAllStringSubmatchIndex(stmt.Code[loc[1]:], -1) {
+			add(shiftLocation(item, loc[1]), 1)
+		}
+	}
+	if loc := reCreateView.FindStringSubmatchIndex(stmt.Code); loc != nil {
+		for _, source := range reViewSource.FindAllStringSubmatchIndex(stmt.Code[loc[1]:], -1) {
+			// FROM a x, b AS y のようにカンマで区切ったテーブルも書き換える
+			start, end := loc[1]+source[2], loc[1]+source[3]
+			for start < end {
+				itemEnd := strings.IndexByte(stmt.Code[start:end], ',')
+				if itemEnd < 0 {
+					itemEnd = end - start
+				}
+				itemStart := start + len(stmt.Code[start:start+itemEnd]) - len(strings.TrimLeft(stmt.Code[start:start+itemEnd], " \t\r\n"))
+				if name := reSourceName.FindStringSubmatchIndex(stmt.Code[itemStart : start+itemEnd]); name != nil {
+					add(shiftLocation(name, itemStart), 1)
+				}
+				start += itemEnd + 1
+			}
+		}
+	}
+	for _, loc := range reReferences.FindAllStringSubmatchIndex(stmt.Code, -1) {
+		add(loc, 1)
+	}
+
+	// 後ろから置き換えて位置がずれないようにする
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	for _, s := range spans {
+		stmt.Text = stmt.Text[:s.start] + s.name + stmt.Text[s.end:]
+		stmt.Code = stmt.Code[:s.start] + s.name + stmt.Code[s.end:]
+	}
+	return stmt
+}
+
+// 部分文字列でのマッチ結果の位置を、offset だけずらして文全体での位置にする
+func shiftLocation(loc []int, offset int) []int {
+	shifted := make([]int, len(loc))
+	for i, pos := range loc {
+		shifted[i] = pos
+		if pos >= 0 {
+			shifted[i] += offset
+		}
+	}
+	return shifted
+}
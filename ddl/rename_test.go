@@ -0,0 +1,47 @@
+package ddl
+
+import "testing"
+
+func TestRenameTables(t *testing.T) {
+	renames := map[string]string{"users": "accounts"}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "CREATE TABLE と REFERENCES", src: "CREATE TABLE users (id int);\nCREATE TABLE orders (u int REFERENCES users(id));\n", want: "CREATE TABLE accounts (id int);\nCREATE TABLE orders (u int REFERENCES accounts(id));\n"},
+		{name: "ALTER TABLE", src: "ALTER TABLE users ADD COLUMN name text;\n", want: "ALTER TABLE accounts ADD COLUMN name text;\n"},
+		{name: "CREATE INDEX", src: "CREATE INDEX ix ON users (id);\n", want: "CREATE INDEX ix ON accounts (id);\n"},
+		{name: "ビューの FROM と JOIN", src: "CREATE VIEW v AS SELECT * FROM users u JOIN orders o ON o.u = u.id;\n", want: "CREATE VIEW v AS SELECT * FROM accounts u JOIN orders o ON o.u = u.id;\n"},
+		{name: "ビューのカンマで区切った FROM", src: "CREATE VIEW w AS SELECT * FROM orders o, users AS x;\n", want: "CREATE VIEW w AS SELECT * FROM orders o, accounts AS x;\n"},
+		{name: "INSERT", src: "INSERT INTO users VALUES (1);\n", want: "INSERT INTO accounts VALUES (1);\n"},
+		{name: "COPY", src: "COPY users FROM '/tmp/u.csv';\n", want: "COPY accounts FROM '/tmp/u.csv';\n"},
+		{name: "COMMENT ON TABLE", src: "COMMENT ON TABLE users IS 'x';\n", want: "COMMENT ON TABLE accounts IS 'x';\n"},
+		{name: "GRANT", src: "GRANT SELECT ON TABLE users TO app;\n", want: "GRANT SELECT ON TABLE accounts TO app;\n"},
+		{name: "LOCK TABLES", src: "LOCK TABLES orders READ, users WRITE;\n", want: "LOCK TABLES orders READ, accounts WRITE;\n"},
+		{name: "文字列リテラルとコメントは書き換えない", src: "-- users\nINSERT INTO orders VALUES ('users');\n", want: "-- users\nINSERT INTO orders VALUES ('users');\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenameTables(tt.src, renames)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenameTables =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteTableNamesKeepsViews(t *testing.T) {
+	src := "CREATE TABLE users (id int);\nCREATE VIEW active AS SELECT * FROM users;\nCREATE VIEW recent AS SELECT * FROM active;\n"
+	want := "CREATE TABLE p_users (id int);\nCREATE VIEW active AS SELECT * FROM p_users;\nCREATE VIEW recent AS SELECT * FROM active;\n"
+	got, err := RewriteTableNames(src, func(table string) string { return "p_" + table })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("RewriteTableNames =\n%s\nwant\n%s", got, want)
+	}
+}
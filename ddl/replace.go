@@ -0,0 +1,31 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CREATE OR REPLACE [修飾子 ...] 種類 名前
+const OR_REPLACE_PATTERN = `(?is)^\s*CREATE\s+OR\s+REPLACE\s+(?:(?:TEMP|TEMPORARY|TRANSIENT|SECURE|MATERIALIZED|RECURSIVE|ALGORITHM\s*=\s*\w+|DEFINER\s*=\s*\S+|SQL\s+SECURITY\s+\w+)\s+)*` +
+	`(TABLE|VIEW|FUNCTION|PROCEDURE|TRIGGER)\s+(?:IF\s+NOT\s+EXISTS\s+)?` + QUALIFIED_NAME_PATTERN
+
+var reOrReplace = regexp.MustCompile(OR_REPLACE_PATTERN)
+
+// replacedKey は CREATE OR REPLACE で定義するオブジェクトを識別する文字列を返す（CREATE OR REPLACE でない場合は空文字）。
+// 関数とプロシージャはオーバーロードを区別するため引数の並びを含める
+func replacedKey(code string, db database) (kind, key string) {
+	loc := reOrReplace.FindStringSubmatchIndex(code)
+	if loc == nil {
+		return "", ""
+	}
+	matches := submatches(code, loc)
+	kind = strings.ToUpper(matches[1])
+	key = kind + " " + db.qualify(qualifiedName(matches[1:]))
+	if kind == "FUNCTION" || kind == "PROCEDURE" {
+		rest := code[loc[1]:]
+		if open := strings.Index(rest, "("); open >= 0 && strings.TrimSpace(rest[:open]) == "" {
+			key += strings.ToLower(strings.Join(strings.Fields(rest[open:min(closingParen(rest, open)+1, len(rest))]), " "))
+		}
+	}
+	return kind, key
+}
@@ -0,0 +1,140 @@
+package ddl
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 標準SQL・MySQL・PostgreSQL のいずれかで予約語のため、テーブル名に使う場合は引用符で囲む必要がある語
+var reservedWords = map[string]bool{
+	"add": true, "all": true, "alter": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "between": true, "both": true, "by": true, "call": true, "case": true,
+	"cast": true, "check": true, "collate": true, "column": true, "condition": true, "constraint": true,
+	"create": true, "cross": true, "current": true, "current_date": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "cursor": true, "database": true, "default": true,
+	"delete": true, "desc": true, "describe": true, "distinct": true, "do": true, "drop": true,
+	"else": true, "end": true, "except": true, "exists": true, "explain": true, "false": true,
+	"fetch": true, "for": true, "foreign": true, "from": true, "full": true, "grant": true,
+	"group": true, "having": true, "in": true, "index": true, "inner": true, "insert": true,
+	"intersect": true, "interval": true, "into": true, "is": true, "join": true, "key": true,
+	"keys": true, "leading": true, "left": true, "like": true, "limit": true, "lock": true,
+	"natural": true, "not": true, "null": true, "of": true, "offset": true, "on": true, "or": true,
+	"order": true, "outer": true, "partition": true, "primary": true, "range": true, "references": true,
+	"release": true, "rename": true, "replace": true, "returning": true, "revoke": true, "right": true,
+	"row": true, "rows": true, "schema": true, "select": true, "session_user": true, "set": true,
+	"show": true, "some": true, "table": true, "then": true, "to": true, "trailing": true,
+	"trigger": true, "true": true, "union": true, "unique": true, "update": true, "usage": true,
+	"use": true, "user": true, "using": true, "values": true, "when": true, "where": true,
+	"window": true, "with": true,
+}
+
+// 引用符で囲まずに書ける識別子（先頭が数字でなく、ASCII の英数字と _ だけからなる）。
+// ASCII 以外の文字を引用符なしで書けるかはデータベースと文字コードによって違うため、常に引用符で囲む
+var reBareIdentifier = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// NeedsQuote は識別子を引用符で囲まないと SQL に書けない（予約語か、ASCII の英数字と _ 以外の文字を含む）かどうかを返す
+func NeedsQuote(name string) bool {
+	return reservedWords[strings.ToLower(name)] || !reBareIdentifier.MatchString(name)
+}
+
+// QuoteTable は schema.table の形のテーブル名を SQL に書ける形にする。
+// 方言がある場合はスキーマとテーブルごとに方言の引用符で囲み、ない場合は予約語などの引用符が必要な部分だけを二重引用符で囲む
+func QuoteTable(d Dialect, table string) string {
+	return Spellings{}.Quote(d, table)
+}
+
+// Spellings は入力の CREATE TABLE でのテーブル名の書き方（部分ごとに囲んだ引用符）
+type Spellings struct {
+	// テーブルごとの、部分ごとの引用符（囲まずに書いた部分は空文字）
+	quotes map[string][]string
+	// 入力で使われている引用符（使われていない場合は空文字）
+	style string
+}
+
+// TableSpellings は src の CREATE TABLE でのテーブル名の書き方を返す
+func TableSpellings(src string, opts Options) Spellings {
+	spellings := Spellings{quotes: make(map[string][]string)}
+	var db database
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	for scanner.Scan() {
+		code := scanner.Statement().Code
+		if db.use(code) {
+			continue
+		}
+		loc := reTableDefinition.FindStringSubmatchIndex(code)
+		if loc == nil {
+			continue
+		}
+		var quotes, parts []string
+		for group := 1; group <= 2 && loc[2*group] >= 0; group++ {
+			start, end := loc[2*group], loc[2*group+1]
+			quote := ""
+			if start > 0 && end < len(code) && (code[start-1] == '`' || code[start-1] == '"') && code[end] == code[start-1] {
+				quote = code[start-1 : start]
+				if spellings.style == "" {
+					spellings.style = quote
+				}
+			}
+			quotes = append(quotes, quote)
+			parts = append(parts, code[start:end])
+		}
+		table := db.qualify(strings.Join(parts, "."))
+		if _, exists := spellings.quotes[table]; !exists {
+			spellings.quotes[table] = quotes
+		}
+	}
+	return spellings
+}
+
+// Quote は schema.table の形のテーブル名を、入力での書き方に合わせて SQL に書ける形にする。
+// 入力で引用符で囲んだ部分は方言の引用符で（方言がない場合は入力と同じ引用符で）囲み、囲まずに書いた部分は引用符が必要な場合だけ囲む。
+// 入力にないテーブルは、方言がある場合はすべての部分を方言の引用符で囲み、ない場合は引用符が必要な部分だけを入力で使われている引用符で囲む
+func (s Spellings) Quote(d Dialect, table string) string {
+	parts := strings.Split(table, ".")
+	quotes, known := s.quotes[table]
+	for i, part := range parts {
+		// 部分は後ろからそろえ、USE で修飾したデータベース名など入力の名前にない部分は囲まずに書いたものとする
+		quote, written := "", known
+		if j := i - (len(parts) - len(quotes)); known && j >= 0 {
+			quote = quotes[j]
+		}
+		switch {
+		case quote != "" && d != nil:
+			parts[i] = d.QuoteIdentifier(part)
+		case quote != "":
+			parts[i] = quoteIdentifier(quote, part)
+		case written && !NeedsQuote(part):
+		case written && d != nil:
+			// 引用符で囲むと大文字と小文字が区別されるため、データベースが扱う名前にそろえてから囲む
+			if fold, ok := findDialect[FoldDialect](d); ok {
+				part = fold.FoldIdentifier(part)
+			}
+			parts[i] = d.QuoteIdentifier(part)
+		case d != nil:
+			parts[i] = d.QuoteIdentifier(part)
+		case NeedsQuote(part):
+			parts[i] = quoteIdentifier(s.style, part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// 識別子を quote の引用符で囲む（quote が空の場合は二重引用符）
+func quoteIdentifier(quote, name string) string {
+	if quote == "" {
+		quote = `"`
+	}
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+// ASCII の英字だけを fold で大文字か小文字にする
+func foldASCII(name string, fold func(rune) rune) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII {
+			return fold(r)
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,77 @@
+package ddl
+
+import "testing"
+
+func TestNeedsQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"users", false},
+		{"Users", false},
+		{"_tmp1", false},
+		{"order", true},
+		{"ORDER", true},
+		{"1st", true},
+		{"my-table", true},
+		{"テーブル", true},
+		{"😀", true},
+	}
+	for _, tt := range tests {
+		if got := NeedsQuote(tt.name); got != tt.want {
+			t.Errorf("NeedsQuote(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpellingsQuote(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dialect string
+		table   string
+		want    string
+	}{
+		{name: "引用符で囲んだ名前は大文字と小文字を保つ", src: `CREATE TABLE "Orders" (id int);`, dialect: "postgres", table: "Orders", want: `"Orders"`},
+		{name: "囲まずに書いた名前はそのまま", src: "CREATE TABLE Users (id int);", dialect: "postgres", table: "Users", want: "Users"},
+		{name: "方言がない場合は入力の引用符を使う", src: "USE mydb;\nCREATE TABLE `order` (id int);", table: "mydb.order", want: "mydb.`order`"},
+		{name: "入力にないテーブルも入力の引用符を使う", src: "CREATE TABLE `order` (id int);", table: "other.order", want: "other.`order`"},
+		{name: "ASCII 以外の名前は囲む", src: `CREATE TABLE "😀" (id int);`, table: "😀", want: `"😀"`},
+		{name: "予約語は方言の大文字と小文字にそろえて囲む", src: "CREATE TABLE public.order (id int);", dialect: "snowflake", table: "public.order", want: `public."ORDER"`},
+		{name: "方言があり入力にないテーブルはすべて囲む", dialect: "postgres", table: "x.order", want: `"x"."order"`},
+		{name: "方言も入力もない場合は必要な部分だけ二重引用符で囲む", table: "x.order", want: `x."order"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{}
+			if tt.dialect != "" {
+				opts.Dialect = mustDialect(t, tt.dialect)
+			}
+			if got := TableSpellings(tt.src, opts).Quote(opts.Dialect, tt.table); got != tt.want {
+				t.Errorf("Quote(%q) = %s, want %s", tt.table, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteTable(t *testing.T) {
+	tests := []struct {
+		dialect string
+		table   string
+		want    string
+	}{
+		{table: "app.users", want: "app.users"},
+		{table: "app.order", want: `app."order"`},
+		{table: `my"table`, want: `"my""table"`},
+		{dialect: "mysql", table: "app.order", want: "`app`.`order`"},
+	}
+	for _, tt := range tests {
+		var d Dialect
+		if tt.dialect != "" {
+			d = mustDialect(t, tt.dialect)
+		}
+		if got := QuoteTable(d, tt.table); got != tt.want {
+			t.Errorf("QuoteTable(%s, %q) = %s, want %s", tt.dialect, tt.table, got, tt.want)
+		}
+	}
+}
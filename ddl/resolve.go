@@ -0,0 +1,67 @@
+package ddl
+
+import "strings"
+
+// resolve は参照先のテーブル名を、入力に定義されたテーブルの名前に解決する。解決できない場合はそのまま返す。
+// 名前が同じものがない場合は、引用符で囲まない識別子と同じように大文字と小文字を区別せずに探す
+func (o Options) resolve(ref, child string, defined map[string]bool) string {
+	resolved := o.resolveName(ref, child, defined)
+	if defined[resolved] {
+		return resolved
+	}
+	found := ""
+	for table := range defined {
+		if strings.EqualFold(table, resolved) {
+			if found != "" {
+				// 大文字と小文字だけが違うテーブルが複数ある場合は決められない
+				return resolved
+			}
+			found = table
+		}
+	}
+	if found != "" {
+		return found
+	}
+	return resolved
+}
+
+// 探索順に従って、参照先のテーブル名を入力に定義されたテーブルの名前に解決する
+func (o Options) resolveName(ref, child string, defined map[string]bool) string {
+	if defined[ref] {
+		return ref
+	}
+
+	schema := SchemaOf(ref)
+	if schema != "" {
+		// 修飾されていない CREATE TABLE は search_path の先頭のスキーマに作成される
+		name := strings.TrimPrefix(ref, schema+".")
+		if len(o.SearchPath) > 0 && o.SearchPath[0] == schema && defined[name] {
+			return name
+		}
+		return ref
+	}
+
+	if o.Resolution == ResolveSameSchema {
+		if childSchema := SchemaOf(child); childSchema != "" && defined[childSchema+"."+ref] {
+			return childSchema + "." + ref
+		}
+	}
+	for _, searchSchema := range o.SearchPath {
+		if defined[searchSchema+"."+ref] {
+			return searchSchema + "." + ref
+		}
+	}
+	return ref
+}
+
+// 依存関係の参照先と参照元を入力に定義されたテーブルの名前に解決する
+func (o Options) resolveEdges(tables []string, edges []Edge) {
+	defined := make(map[string]bool)
+	for _, table := range tables {
+		defined[table] = true
+	}
+	for i := range edges {
+		edges[i].Parent = o.resolve(edges[i].Parent, edges[i].Child, defined)
+		edges[i].Child = o.resolve(edges[i].Child, edges[i].Parent, defined)
+	}
+}
@@ -0,0 +1,112 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule は正規表現に一致する文から、オブジェクトの名前と依存するテーブルを取り出す規則。
+// Name と Dependencies には $1 や ${name} でサブマッチを書ける
+type Rule struct {
+	// 文に一致させる正規表現（大文字小文字を区別しない場合は (?i) を付ける）
+	Pattern string `json:"pattern"`
+	// 文が新しいオブジェクトを定義する場合はその名前（空の場合はオブジェクトを定義しない）
+	Name string `json:"name,omitempty"`
+	// 文（Name が空の場合は文を含むブロック）が依存するテーブル（展開した結果がカンマ区切りの場合は複数のテーブル）
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// RuleDialect は Rule で方言を補う。Base の判断を先に使い、規則は一致したものをすべて適用する
+type RuleDialect struct {
+	// 識別子の引用符と終端文字、組み込みの依存関係に使う方言（nil の場合は ; で区切る）
+	Base  Dialect
+	rules []Rule
+}
+
+// NewRuleDialect は規則の正規表現をコンパイルし、Base を補う方言を返す
+func NewRuleDialect(base Dialect, rules []Rule) (*RuleDialect, error) {
+	d := &RuleDialect{Base: base, rules: make([]Rule, len(rules))}
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%d番目の規則の pattern が正しくありません: %w", i+1, err)
+		}
+		rule.re = re
+		d.rules[i] = rule
+	}
+	return d, nil
+}
+
+func (d *RuleDialect) Name() string {
+	if d.Base != nil {
+		return d.Base.Name()
+	}
+	return "rules"
+}
+
+func (d *RuleDialect) QuoteIdentifier(name string) string {
+	if d.Base != nil {
+		return d.Base.QuoteIdentifier(name)
+	}
+	return name
+}
+
+func (d *RuleDialect) Terminator() string {
+	if d.Base != nil {
+		return d.Base.Terminator()
+	}
+	return ";"
+}
+
+func (d *RuleDialect) Dependencies(stmt Statement) []string {
+	var deps []string
+	if d.Base != nil {
+		deps = d.Base.Dependencies(stmt)
+	}
+	for _, rule := range d.rules {
+		matches := rule.re.FindStringSubmatchIndex(stmt.Code)
+		if matches == nil {
+			continue
+		}
+		for _, template := range rule.Dependencies {
+			expanded := string(rule.re.ExpandString(nil, template, stmt.Code, matches))
+			for _, table := range strings.Split(expanded, ",") {
+				if table = unquoteName(table); table != "" {
+					deps = append(deps, table)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+func (d *RuleDialect) Object(stmt Statement) (string, bool) {
+	if base, ok := d.Base.(ObjectDialect); ok {
+		if name, ok := base.Object(stmt); ok {
+			return name, true
+		}
+	}
+	for _, rule := range d.rules {
+		if rule.Name == "" {
+			continue
+		}
+		if matches := rule.re.FindStringSubmatchIndex(stmt.Code); matches != nil {
+			if name := unquoteName(string(rule.re.ExpandString(nil, rule.Name, stmt.Code, matches))); name != "" {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 前後の空白と識別子の引用符を除く（schema.table の形は保つ）
+func unquoteName(name string) string {
+	parts := strings.Split(strings.TrimSpace(name), ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), "`\"[]")
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,536 @@
+package ddl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Statement は終端文字で区切られた1つのSQL文
+type Statement struct {
+	// 前置きの空白・コメントと終端文字を含む元のテキスト
+	Text string
+	// 文字列リテラルの中身とコメントを空白に置き換えたテキスト（Text と同じバイト長）
+	Code string
+	// 入力の先頭からのバイト位置
+	Offset int
+	// 開始行（1始まり）
+	Line int
+	// 文を終えた終端文字（終端文字のない最後の文では空）
+	Terminator string
+	// MySQL の DELIMITER コマンドと DB2 の --#SET TERMINATOR（データベースには送らない）
+	Directive bool
+	// COPY ... FROM stdin に続くデータ行（\. の行まで）
+	CopyData bool
+	// 文字列リテラルやコメントが閉じられていなかった文（行末の終端文字で区切り直し、そのまま出力する）
+	Malformed *Warning
+}
+
+// Warning は解析を続けたが、入力に問題があった箇所
+type Warning struct {
+	// 問題のある行（1始まり）
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// SQL は前置きの空白と、終端文字およびその後ろを除いた文を返す
+func (s Statement) SQL() string {
+	text := s.Text
+	if s.Terminator != "" {
+		if i := strings.LastIndex(s.Code, s.Terminator); i >= 0 {
+			text = text[:i]
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// 字句解析の状態
+type scanState int
+
+const (
+	stateNormal       scanState = iota
+	stateString                 // '...'
+	stateQuotedIdent            // "..."
+	stateBacktick               // `...`
+	stateLineComment            // -- ...
+	stateBlockComment           // /* ... */
+	stateDollarQuote            // $tag$ ... $tag$
+)
+
+// StatementScanner は文字列リテラルとコメントを考慮して入力をSQL文ごとに分割する
+type StatementScanner struct {
+	r      *bufio.Reader
+	stmt   Statement
+	err    error
+	offset int
+	line   int
+	// 現在の終端文字（MySQL の DELIMITER で切り替わる）
+	delimiter string
+	// 直前の文が COPY ... FROM stdin で、次にデータ行が続く
+	copyData bool
+	// 取り消された場合は次の文を読み込まずに終える
+	ctx context.Context
+	// 文ごとに使い回す Text と Code のバッファ
+	text, code []byte
+	// 次に読むバイトが行の先頭
+	lineStart bool
+	// '...' の中のバックスラッシュをエスケープとする（MySQL の方言。標準SQLでは E'...' だけ）
+	backslashEscapes bool
+}
+
+var reCopyFromStdin = regexp.MustCompile(`(?is)^\s*COPY\b.*\bFROM\s+STDIN\b`)
+
+// NewStatementScanner は r から読み込む StatementScanner を返す
+func NewStatementScanner(r io.Reader) *StatementScanner {
+	return NewStatementScannerContext(context.Background(), r)
+}
+
+// NewStatementScannerContext は ctx が取り消されるまで r から読み込む StatementScanner を返す（取り消された場合は Err が ctx のエラーを返す）
+func NewStatementScannerContext(ctx context.Context, r io.Reader) *StatementScanner {
+	return &StatementScanner{r: bufio.NewReader(r), line: 1, delimiter: ";", ctx: ctx, lineStart: true}
+}
+
+// Statement は直前の Scan で読み込んだSQL文を返す
+func (s *StatementScanner) Statement() Statement {
+	return s.stmt
+}
+
+// Err は読み込み中に発生したエラーを返す
+func (s *StatementScanner) Err() error {
+	return s.err
+}
+
+// Scan は次のSQL文を読み込む。入力の終わりに達した場合は false を返す
+func (s *StatementScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	if s.copyData {
+		s.copyData = false
+		return s.scanCopyData()
+	}
+
+	// 前の文のバッファを使い回す（Statement には文字列にコピーして渡す）
+	text, code := s.text[:0], s.code[:0]
+	defer func() { s.text, s.code = text[:0], code[:0] }()
+	offset, line := s.offset, s.line
+	state := stateNormal
+	var closing []byte // ドル引用符の終了タグ
+	prevIdent := false
+	opened := 0      // 文字列リテラルやコメントを開始した位置
+	blank := true    // ここまでの code が空白とコメントだけ
+	escapes := false // 読んでいる文字列リテラルでバックスラッシュがエスケープになる
+
+	for {
+		atLineStart := s.lineStart
+		b, err := s.readByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.err = fmt.Errorf("ファイル読み込みエラー: %w", err)
+			return false
+		}
+		text = append(text, b)
+
+		switch state {
+		case stateNormal:
+			opened = len(text) - 1
+			switch {
+			case (b == 'G' || b == 'g') && atLineStart && s.peekGoCommand():
+				// SQL Server の GO は行全体でバッチを区切る終端文字とする（GO の後の回数を含めて行末まで）
+				start := len(text) - 1
+				text = s.readLine(text)
+				code = append(code, text[start:]...)
+				s.stmt = newStatement(text, code, offset, line)
+				s.stmt.Terminator = string(text[start : start+2])
+				return true
+			case b == s.delimiter[0] && s.peekIs(s.delimiter[1:]):
+				s.discard(len(s.delimiter) - 1)
+				text = append(text, s.delimiter[1:]...)
+				code = append(code, s.delimiter...)
+				text, code = s.consumeTrailing(text, code)
+				s.stmt = newStatement(text, code, offset, line)
+				s.stmt.Terminator = s.delimiter
+				s.copyData = reCopyFromStdin.Match(code)
+				return true
+			case (b == 'D' || b == 'd') && blank && s.peekDelimiterCommand():
+				// DELIMITER は終端文字を持たず、行末までで1つの文とする
+				start := len(code)
+				text = s.readLine(text)
+				code = append(code, text[start:]...)
+				if fields := bytes.Fields(text[start:]); len(fields) > 1 {
+					s.delimiter = string(fields[1])
+				}
+				s.stmt = newStatement(text, code, offset, line)
+				s.stmt.Directive = true
+				return true
+			case b == '-' && blank && s.peekTerminatorCommand():
+				// DB2 の CLP は --#SET TERMINATOR で終端文字を切り替える
+				start := len(code)
+				text = s.readLine(text)
+				code = append(code, text[start:]...)
+				if fields := bytes.Fields(text[start:]); len(fields) > 2 {
+					s.delimiter = string(fields[2])
+				}
+				s.stmt = newStatement(text, code, offset, line)
+				s.stmt.Directive = true
+				return true
+			case b == '\'':
+				state = stateString
+				escapes = s.backslashEscapes || escapeStringPrefix(text[:len(text)-1])
+				code = append(code, b)
+			case b == '"':
+				state = stateQuotedIdent
+				code = append(code, b)
+			case b == '`':
+				state = stateBacktick
+				code = append(code, b)
+			case b == '-' && s.peekIs("-"):
+				state = stateLineComment
+				code = append(code, ' ')
+			case b == '/' && s.peekIs("*"):
+				state = stateBlockComment
+				next, _ := s.readByte()
+				text = append(text, next)
+				code = append(code, ' ', ' ')
+			case b == '$' && !prevIdent && s.dollarTagLen() > 0:
+				// 終了タグはバッファを使い回しても残るようにコピーする
+				n := len(text) - 1
+				text = s.readN(text, s.dollarTagLen()-1)
+				closing = append([]byte{}, text[n:]...)
+				code = append(code, closing...)
+				state = stateDollarQuote
+			default:
+				code = append(code, b)
+			}
+			prevIdent = isIdentByte(b)
+			if state != stateLineComment && state != stateBlockComment && !isSpaceByte(b) {
+				blank = false
+			}
+
+		case stateString:
+			switch {
+			case b == '\\' && escapes:
+				// バックスラッシュによるエスケープ
+				code = append(code, ' ')
+				if next, err := s.readByte(); err == nil {
+					text = append(text, next)
+					code = append(code, mask(next))
+				}
+			case b == '\'' && s.peekIs("'"):
+				next, _ := s.readByte()
+				text = append(text, next)
+				code = append(code, ' ', ' ')
+			case b == '\'':
+				state = stateNormal
+				code = append(code, b)
+			default:
+				code = append(code, mask(b))
+			}
+
+		case stateQuotedIdent, stateBacktick:
+			// 引用符で囲まれた識別子はそのまま残す
+			code = append(code, b)
+			if (state == stateQuotedIdent && b == '"') || (state == stateBacktick && b == '`') {
+				state = stateNormal
+			}
+
+		case stateLineComment:
+			code = append(code, mask(b))
+			if b == '\n' {
+				state = stateNormal
+			}
+
+		case stateBlockComment:
+			code = append(code, mask(b))
+			if b == '*' && s.peekIs("/") {
+				next, _ := s.readByte()
+				text = append(text, next)
+				code = append(code, ' ')
+				state = stateNormal
+			}
+
+		case stateDollarQuote:
+			code = append(code, mask(b))
+			if bytes.HasSuffix(text, closing) {
+				copy(code[len(code)-len(closing):], closing)
+				state = stateNormal
+			}
+		}
+	}
+
+	// 閉じられていないまま入力の終わりに達した場合は、開始した位置より後の行末の終端文字で区切り直す
+	if state != stateNormal && state != stateLineComment {
+		s.resync(text, code, offset, line, opened, state)
+		return true
+	}
+
+	// 終端文字のない最後の文
+	if len(text) == 0 {
+		return false
+	}
+	s.stmt = newStatement(text, code, offset, line)
+	return true
+}
+
+// text と code を1回の割り当てで文字列にした Statement を返す（text のバッファは code の分だけ伸びることがある）
+func newStatement(text, code []byte, offset, line int) Statement {
+	n := len(text)
+	both := string(append(text, code...))
+	return Statement{Text: both[:n], Code: both[n:], Offset: offset, Line: line}
+}
+
+// 閉じられていないものの名前
+var unterminated = map[scanState]string{
+	stateString:       "文字列リテラル",
+	stateQuotedIdent:  "引用符で囲まれた識別子",
+	stateBacktick:     "バッククォートで囲まれた識別子",
+	stateBlockComment: "ブロックコメント",
+	stateDollarQuote:  "ドル引用符で囲まれた文字列",
+}
+
+// opened の位置から閉じられていない文を、その後で最初に行末にある終端文字までで区切り、残りを読み直す
+func (s *StatementScanner) resync(text, code []byte, offset, line, opened int, state scanState) {
+	end, terminator := len(text), ""
+	for i := opened + 1; i < len(text); {
+		n := bytes.Index(text[i:], []byte(s.delimiter))
+		if n < 0 {
+			break
+		}
+		i += n + len(s.delimiter)
+		rest := text[i:]
+		if nl := bytes.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[:nl+1]
+		}
+		if len(bytes.TrimSpace(rest)) == 0 {
+			end, terminator = i+len(rest), s.delimiter
+			copy(code[i-len(s.delimiter):], s.delimiter)
+			break
+		}
+	}
+
+	// バッファは次の文で使い回すため、読み直す残りは先にコピーする
+	rest := append([]byte{}, text[end:]...)
+	s.stmt = newStatement(text[:end], code[:end], offset, line)
+	s.stmt.Terminator = terminator
+	s.stmt.Malformed = &Warning{
+		Line:    line + bytes.Count(text[:opened], []byte("\n")),
+		Message: fmt.Sprintf("閉じられていない%sがあるため、次の行末の終端文字までを1つの文としてそのまま出力します", unterminated[state]),
+	}
+	// 入力の終わりまで読み込んでいるため、残りを読み直す
+	s.r = bufio.NewReader(bytes.NewReader(rest))
+	s.offset = offset + end
+	s.line = line + bytes.Count(text[:end], []byte("\n"))
+}
+
+// COPY ... FROM stdin に続くデータ行を \. の行まで読み込む（データの中身はキーワードとして扱わない）
+func (s *StatementScanner) scanCopyData() bool {
+	offset, line := s.offset, s.line
+	text, code := s.text[:0], s.code[:0]
+	defer func() { s.text, s.code = text[:0], code[:0] }()
+	for {
+		start := len(text)
+		text = s.readLine(text)
+		if len(text) == start || string(bytes.TrimRight(text[start:], "\r\n")) == `\.` {
+			break
+		}
+	}
+	if len(text) == 0 {
+		return false
+	}
+	for _, b := range text {
+		code = append(code, mask(b))
+	}
+	s.stmt = newStatement(text, code, offset, line)
+	s.stmt.CopyData = true
+	return true
+}
+
+// 1バイト読み込み、位置と行番号を進める
+func (s *StatementScanner) readByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.offset++
+	if b == '\n' {
+		s.line++
+	}
+	s.lineStart = b == '\n'
+	return b, nil
+}
+
+// n バイト読み込んで text に追加する
+func (s *StatementScanner) readN(text []byte, n int) []byte {
+	for i := 0; i < n; i++ {
+		b, err := s.readByte()
+		if err != nil {
+			break
+		}
+		text = append(text, b)
+	}
+	return text
+}
+
+// n バイト読み飛ばす
+func (s *StatementScanner) discard(n int) {
+	for i := 0; i < n; i++ {
+		if _, err := s.readByte(); err != nil {
+			return
+		}
+	}
+}
+
+// 次に続くバイト列が prefix と一致するかどうか
+func (s *StatementScanner) peekIs(prefix string) bool {
+	p, _ := s.r.Peek(len(prefix))
+	return string(p) == prefix
+}
+
+// 直前に読み込んだ D から始まる DELIMITER コマンドかどうか
+func (s *StatementScanner) peekDelimiterCommand() bool {
+	p, _ := s.r.Peek(len("ELIMITER") + 1)
+	if len(p) < len("ELIMITER")+1 {
+		return false
+	}
+	return bytes.EqualFold(p[:len("ELIMITER")], []byte("ELIMITER")) && (p[len(p)-1] == ' ' || p[len(p)-1] == '\t')
+}
+
+// 直前に読み込んだ G から始まる GO だけの行（GO 回数 を含む）かどうか
+func (s *StatementScanner) peekGoCommand() bool {
+	p, _ := s.r.Peek(32)
+	if len(p) == 0 || (p[0] != 'O' && p[0] != 'o') {
+		return false
+	}
+	rest := bytes.TrimLeft(bytes.TrimLeft(bytes.TrimLeft(p[1:], " \t"), "0123456789"), " \t")
+	return len(rest) == 0 || rest[0] == '\n' || (rest[0] == '\r' && (len(rest) == 1 || rest[1] == '\n'))
+}
+
+// 直前に読み込んだ - から始まる --#SET TERMINATOR かどうか
+func (s *StatementScanner) peekTerminatorCommand() bool {
+	const command = "-#SET TERMINATOR"
+	p, _ := s.r.Peek(len(command) + 1)
+	if len(p) < len(command)+1 {
+		return false
+	}
+	return bytes.EqualFold(p[:len(command)], []byte(command)) && (p[len(p)-1] == ' ' || p[len(p)-1] == '\t')
+}
+
+// 改行まで読み込んで text に追加する
+func (s *StatementScanner) readLine(text []byte) []byte {
+	for {
+		b, err := s.readByte()
+		if err != nil {
+			return text
+		}
+		text = append(text, b)
+		if b == '\n' {
+			return text
+		}
+	}
+}
+
+// 直前に読み込んだ $ から始まるドル引用符のタグ（$tag$）の長さを返す。タグでない場合は 0
+func (s *StatementScanner) dollarTagLen() int {
+	p, _ := s.r.Peek(64)
+	for i, b := range p {
+		switch {
+		case b == '$':
+			return i + 2
+		case b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || b >= 0x80:
+		case '0' <= b && b <= '9' && i > 0:
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// 終端文字の後ろに続く空白・行末コメント・改行を文に含める
+func (s *StatementScanner) consumeTrailing(text, code []byte) ([]byte, []byte) {
+	n := 0
+	for {
+		p, _ := s.r.Peek(n + 1)
+		if len(p) <= n || (p[n] != ' ' && p[n] != '\t') {
+			break
+		}
+		n++
+	}
+
+	p, _ := s.r.Peek(n + 2)
+	rest := p[min(n, len(p)):]
+	switch {
+	case len(rest) == 0:
+	case rest[0] == '\n':
+		n++
+	case rest[0] == '\r' && len(rest) > 1 && rest[1] == '\n':
+		n += 2
+	case bytes.HasPrefix(rest, []byte("--")):
+		// 行末コメントは改行まで含める
+		for {
+			b, err := s.readByte()
+			if err != nil {
+				return text, code
+			}
+			text = append(text, b)
+			if n > 0 {
+				code = append(code, b)
+				n--
+				continue
+			}
+			code = append(code, mask(b))
+			if b == '\n' {
+				return text, code
+			}
+		}
+	default:
+		// 同じ行に次の文が続く場合は空白を含めない
+		return text, code
+	}
+
+	for i := 0; i < n; i++ {
+		b, err := s.readByte()
+		if err != nil {
+			break
+		}
+		text = append(text, b)
+		code = append(code, b)
+	}
+	return text, code
+}
+
+// コメントや文字列リテラルの中身を空白に置き換える（改行は残す）
+func mask(b byte) byte {
+	if b == '\n' || b == '\r' {
+		return b
+	}
+	return ' '
+}
+
+// 空白とみなすバイトかどうか（bytes.TrimSpace と同じ ASCII の空白）
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// 文字列リテラルの直前が PostgreSQL の E'...' の E かどうか（識別子の末尾の E は除く）
+func escapeStringPrefix(text []byte) bool {
+	n := len(text)
+	if n == 0 || text[n-1] != 'E' && text[n-1] != 'e' {
+		return false
+	}
+	return n == 1 || !isIdentByte(text[n-2])
+}
+
+// 識別子を構成するバイトかどうか
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || b >= 0x80
+}
@@ -0,0 +1,153 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStatementScanner(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		dialect string
+		want    []string
+	}{
+		{
+			name: "1行に複数の文",
+			src:  "CREATE TABLE a (id int); CREATE TABLE b (id int);\n",
+			want: []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+		},
+		{
+			name: "文字列リテラルとコメントの中の終端文字",
+			src:  "INSERT INTO a VALUES ('x;y'); -- ;\n/* ; */ SELECT 1;\n",
+			want: []string{"INSERT INTO a VALUES ('x;y')", "/* ; */ SELECT 1"},
+		},
+		{
+			name: "標準SQLでは '' で引用符を書き、バックスラッシュはエスケープにしない",
+			src:  "INSERT INTO a VALUES ('C:\\', 'it''s');\nSELECT 1;\n",
+			want: []string{"INSERT INTO a VALUES ('C:\\', 'it''s')", "SELECT 1"},
+		},
+		{
+			name:    "postgres の E'...' ではバックスラッシュがエスケープになる",
+			src:     "INSERT INTO a VALUES (E'it\\'s;', 'C:\\');\nSELECT 1;\n",
+			dialect: "postgres",
+			want:    []string{"INSERT INTO a VALUES (E'it\\'s;', 'C:\\')", "SELECT 1"},
+		},
+		{
+			name:    "mysql ではバックスラッシュがエスケープになる",
+			src:     "INSERT INTO a VALUES ('it\\'s;');\nSELECT 1;\n",
+			dialect: "mysql",
+			want:    []string{"INSERT INTO a VALUES ('it\\'s;')", "SELECT 1"},
+		},
+		{
+			name:    "DELIMITER で終端文字を切り替える",
+			src:     "DELIMITER //\nCREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN SET NEW.id = 1; END//\nDELIMITER ;\nSELECT 1;\n",
+			dialect: "mysql",
+			want:    []string{"DELIMITER //", "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN SET NEW.id = 1; END", "DELIMITER ;", "SELECT 1"},
+		},
+		{
+			name: "COPY ... FROM stdin のデータ行",
+			src:  "COPY a (id) FROM stdin;\n1\n2\n\\.\nSELECT 1;\n",
+			want: []string{"COPY a (id) FROM stdin", "1\n2\n\\.", "SELECT 1"},
+		},
+		{
+			name: "閉じられていない文字列リテラルは行末の終端文字で区切り直す",
+			src:  "INSERT INTO a VALUES ('x);\nCREATE TABLE b (id int);\n",
+			want: []string{"INSERT INTO a VALUES ('x)", "CREATE TABLE b (id int)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{}
+			if tt.dialect != "" {
+				opts.Dialect = mustDialect(t, tt.dialect)
+			}
+			scanner := opts.newScanner(context.Background(), strings.NewReader(tt.src))
+			var got []string
+			for scanner.Scan() {
+				if sql := scanner.Statement().SQL(); sql != "" {
+					got = append(got, sql)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "\x00") != strings.Join(tt.want, "\x00") {
+				t.Errorf("文 = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementCode(t *testing.T) {
+	scanner := NewStatementScanner(strings.NewReader("CREATE TABLE a (s text DEFAULT 'REFERENCES b', -- REFERENCES c\n  id int);\n"))
+	if !scanner.Scan() {
+		t.Fatal("文を読み込めませんでした")
+	}
+	stmt := scanner.Statement()
+	if len(stmt.Code) != len(stmt.Text) {
+		t.Fatalf("Code の長さ %d が Text の長さ %d と異なります", len(stmt.Code), len(stmt.Text))
+	}
+	if strings.Contains(stmt.Code, "REFERENCES") {
+		t.Errorf("文字列リテラルとコメントが空白に置き換えられていません: %q", stmt.Code)
+	}
+	if !strings.HasPrefix(stmt.Code, "CREATE TABLE a (") {
+		t.Errorf("Code = %q", stmt.Code)
+	}
+}
+
+func TestStatementScannerResync(t *testing.T) {
+	src := "INSERT INTO a VALUES ('broken);\nCREATE TABLE b (id int);\nCREATE TABLE c (id int);\n"
+	scanner := NewStatementScanner(strings.NewReader(src))
+	var stmts []Statement
+	for scanner.Scan() {
+		stmts = append(stmts, scanner.Statement())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 3 {
+		t.Fatalf("%d 個の文に分かれました, want 3: %+v", len(stmts), stmts)
+	}
+	if stmts[0].Malformed == nil || stmts[0].Malformed.Line != 1 {
+		t.Errorf("閉じられていない文字列リテラルの警告 = %+v", stmts[0].Malformed)
+	}
+	for i, want := range []string{"CREATE TABLE b (id int);", "CREATE TABLE c (id int);"} {
+		stmt := stmts[i+1]
+		if got := strings.TrimSpace(stmt.Text); got != want || stmt.Malformed != nil {
+			t.Errorf("文 %d = %q (Malformed %+v), want %q", i+1, got, stmt.Malformed, want)
+		}
+		if stmt.Line != i+2 {
+			t.Errorf("文 %d の行 = %d, want %d", i+1, stmt.Line, i+2)
+		}
+	}
+}
+
+// 後ろのテーブルほど前のテーブルを参照する、逆順に並んだ n 個のテーブルのスキーマ
+func benchmarkSchema(n int) string {
+	var b strings.Builder
+	for i := n - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "-- テーブル t%d\nCREATE TABLE t%d (\n  id int PRIMARY KEY,\n  name varchar(100) DEFAULT 'a;b',\n", i, i)
+		if i > 0 {
+			fmt.Fprintf(&b, "  parent_id int REFERENCES t%d(id),\n", i-1)
+		}
+		fmt.Fprintf(&b, "  /* 作成日時; */ created_at timestamp\n);\nCREATE INDEX t%d_name ON t%d (name);\n\n", i, i)
+	}
+	return b.String()
+}
+
+func BenchmarkStatementScanner(b *testing.B) {
+	src := benchmarkSchema(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for range b.N {
+		scanner := NewStatementScanner(strings.NewReader(src))
+		for scanner.Scan() {
+		}
+		if err := scanner.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
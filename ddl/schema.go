@@ -0,0 +1,124 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Span は入力の中の範囲
+type Span struct {
+	// 先頭からのバイト位置（End は範囲の直後）
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// 開始行と終了行（1始まり）
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+}
+
+// SchemaTable はテーブル定義と、入力の中でのそのテーブルのDDL
+type SchemaTable struct {
+	*Table
+	// 並び替えで書き出すテーブルのブロック（前置きのコメントや続く ALTER TABLE などを含む）
+	DDL string `json:"ddl"`
+	// CREATE TABLE から、ブロックに含まれる最後の文までの範囲
+	Span Span `json:"span"`
+}
+
+// Schema は入力を解析したテーブル定義の一覧
+type Schema struct {
+	// 入力に現れた順のテーブル
+	Tables []*SchemaTable `json:"tables"`
+}
+
+// Table は名前でテーブルを探す
+func (s *Schema) Table(name string) (*SchemaTable, bool) {
+	for _, table := range s.Tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// ParseSchema は入力のテーブルごとに、カラム・キー・外部キーの定義とDDLのテキスト・範囲を返す
+func ParseSchema(src string) (*Schema, error) {
+	tables, err := Tables(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	ddlContent, err := Split(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	spans, err := tableSpans(src)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{Tables: make([]*SchemaTable, 0, len(tables))}
+	for i, table := range tables {
+		entry := &SchemaTable{Table: table, DDL: ddlContent[table.Name]}
+		if i < len(spans) {
+			entry.Span = spans[i]
+		}
+		schema.Tables = append(schema.Tables, entry)
+	}
+	return schema, nil
+}
+
+// CREATE TABLE ごとに（Tables と同じ順で）、ブロックの文の範囲を返す。
+// USE・CREATE DATABASE と LOCK TABLES から UNLOCK TABLES までの文は別の位置に移すため範囲に含めない
+func tableSpans(src string) ([]Span, error) {
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+
+	var spans []Span
+	// 現在のブロックの範囲と、テーブルごとの範囲の位置
+	current := -1
+	indexes := make(map[string]int)
+	var db database
+	locked := false
+	scanner := NewStatementScanner(strings.NewReader(src))
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		switch {
+		case locked:
+			locked = !reUnlockTables.MatchString(code)
+			continue
+		case reLockTables.MatchString(code) && !ignored(stmt):
+			locked = true
+			continue
+		case db.use(code), reCreateDatabase.MatchString(code), strings.TrimSpace(code) == "":
+			continue
+		}
+
+		// 前置きの空白・コメントと、終端文字の後ろの空白を除いた範囲
+		leading := leadingText(stmt)
+		body := strings.TrimRight(stmt.Text[len(leading):], " \t\r\n")
+		start := stmt.Offset + len(leading)
+		end := start + len(body)
+		startLine := stmt.Line + strings.Count(leading, "\n")
+		endLine := startLine + strings.Count(body, "\n")
+
+		if matches := reCreateTable.FindStringSubmatch(code); matches != nil && !ignored(stmt) {
+			span := Span{Start: start, End: end, StartLine: startLine, EndLine: endLine}
+			name := db.qualify(qualifiedName(matches))
+			if i, exists := indexes[name]; exists && reOrReplace.MatchString(code) {
+				// CREATE OR REPLACE は Tables と同じく前の定義の位置で置き換える
+				spans[i], current = span, i
+				continue
+			}
+			indexes[name], current = len(spans), len(spans)
+			spans = append(spans, span)
+			continue
+		}
+		if current >= 0 {
+			spans[current].End = end
+			spans[current].EndLine = endLine
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
@@ -0,0 +1,66 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 方言の CREATE TABLE の名前（"db"."schema"."table" の形でもよい）
+const DIALECT_NAME_PATTERN = `("?` + DIALECT_NAME_CHARS + `"?(?:\s*\.\s*"?` + DIALECT_NAME_CHARS + `"?){0,2})`
+
+// IDENTIFIER_CHARS に $ を加えたもの
+const DIALECT_NAME_CHARS = `(?:[$\w]|[^\x00-\x7F\p{Z}])+`
+
+var (
+	// CREATE [OR REPLACE] [TRANSIENT | TEMPORARY ...] TABLE [IF NOT EXISTS] 名前
+	reSnowflakeTable = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:LOCAL|GLOBAL)\s+)?(?:(?:TEMP|TEMPORARY|VOLATILE|TRANSIENT)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + DIALECT_NAME_PATTERN)
+	// CREATE TABLE ... CLONE 元 / LIKE 元
+	reSnowflakeClone = regexp.MustCompile(`(?is)^\s*CREATE\b.*?\bTABLE\b.*?\b(?:CLONE|LIKE)\s+` + DIALECT_NAME_PATTERN)
+)
+
+func init() {
+	RegisterDialect(snowflakeDialect{})
+}
+
+// Snowflake。外部キーは検査されない情報としての制約だが、作成順序には反映する
+type snowflakeDialect struct{}
+
+func (snowflakeDialect) Name() string { return "snowflake" }
+
+func (snowflakeDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (snowflakeDialect) Terminator() string { return ";" }
+
+func (snowflakeDialect) FoldIdentifier(name string) string { return foldASCII(name, unicode.ToUpper) }
+
+// CLONE と LIKE の元のテーブルは先に作成する必要がある
+func (snowflakeDialect) Dependencies(stmt Statement) []string {
+	if matches := reSnowflakeClone.FindStringSubmatch(stmt.Code); matches != nil {
+		return []string{dialectTableName(matches[1])}
+	}
+	return nil
+}
+
+func (snowflakeDialect) CreateTable(stmt Statement) (string, bool) {
+	if matches := reSnowflakeTable.FindStringSubmatch(stmt.Code); matches != nil {
+		return dialectTableName(matches[1]), true
+	}
+	return "", false
+}
+
+func (snowflakeDialect) InformationalConstraints() bool { return true }
+
+// 引用符を除き、データベースで修飾された名前は schema.table にする
+func dialectTableName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"`)
+	}
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,139 @@
+package ddl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SourceMapEntry はテーブルのブロックの並び替える前と後の位置
+type SourceMapEntry struct {
+	Table string `json:"table"`
+	// 出力での順番（1始まり）
+	Position int `json:"position"`
+	// 入力での CREATE TABLE からブロックの最後の文までの範囲（方言が定義するオブジェクトなど、CREATE TABLE がない場合は nil）
+	Original *Span `json:"original,omitempty"`
+	// 出力でのブロックの範囲（バイト位置は改行コードと文字コードを変換する前のもの）
+	Output Span `json:"output"`
+}
+
+// SourceMap は WriteTables で書き出すブロックごとに、入力での範囲と出力での位置を返す
+func SourceMap(src string, sortedTables []string, ddlContent map[string]string) ([]SourceMapEntry, error) {
+	blocks := scopedBlocks(sortedTables, ddlContent)
+	original, err := definitionSpans(src)
+	if err != nil {
+		return nil, err
+	}
+	output, err := definitionSpans(strings.Join(blocks, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, table := range sortedTables {
+		if _, exists := ddlContent[table]; exists {
+			written = append(written, table)
+		}
+	}
+
+	entries := make([]SourceMapEntry, 0, len(written))
+	offset, line := 0, 1
+	for i, block := range blocks {
+		table := written[i]
+		entry := SourceMapEntry{Table: table, Position: i + 1}
+		if span, exists := original[table]; exists {
+			entry.Original = &span
+		}
+		if span, exists := output[table]; exists {
+			entry.Output = span
+		} else {
+			// CREATE TABLE のないブロックはブロック全体の範囲
+			body := strings.TrimRight(block, " \t\r\n")
+			entry.Output = Span{Start: offset, End: offset + len(body), StartLine: line, EndLine: line + strings.Count(body, "\n")}
+		}
+		entries = append(entries, entry)
+		offset += len(block)
+		line += strings.Count(block, "\n")
+	}
+	return entries, nil
+}
+
+// テーブルごとの、CREATE TABLE からブロックの最後の文までの範囲
+func definitionSpans(src string) (map[string]Span, error) {
+	tables, err := Tables(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	spans, err := tableSpans(src)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Span, len(spans))
+	for i, table := range tables {
+		if i < len(spans) {
+			byName[table.Name] = spans[i]
+		}
+	}
+	return byName, nil
+}
+
+// Restore は SourceMap の entries を書き出したときの出力 ordered を、ブロックの入力での順に並べ直す。
+// 各ブロックは前のブロックの後ろから最後の文の行までで、入力での範囲がないブロックは出力での直前のブロックの後ろに置く。
+// 出力の後で CREATE TABLE の行が変わっている場合はエラーを返す
+func Restore(ordered string, entries []SourceMapEntry) (string, error) {
+	ordered = StripManifest(ordered)
+	spans, err := definitionSpans(ordered)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.SplitAfter(ordered, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	entries = append([]SourceMapEntry{}, entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Position < entries[j].Position })
+	type block struct {
+		text string
+		// 入力での開始行（並べ直すときのキー）
+		line int
+	}
+	blocks := make([]block, 0, len(entries))
+	start, line := 0, 0
+	for _, entry := range entries {
+		span, exists := spans[entry.Table]
+		if !exists {
+			return "", fmt.Errorf("ソースマップの %s の定義が見つかりません。出力した後に編集されています", entry.Table)
+		}
+		if span.StartLine != entry.Output.StartLine || span.EndLine != entry.Output.EndLine {
+			return "", fmt.Errorf("%s の位置がソースマップと一致しません（%d〜%d行目、ソースマップでは %d〜%d行目）。出力した後に編集されています", entry.Table, span.StartLine, span.EndLine, entry.Output.StartLine, entry.Output.EndLine)
+		}
+		delete(spans, entry.Table)
+		end := entry.Output.EndLine
+		if end < start || end > len(lines) {
+			return "", fmt.Errorf("%s の位置がソースマップと一致しません（ソースマップでは %d行目まで）", entry.Table, end)
+		}
+		if entry.Original != nil {
+			line = entry.Original.StartLine
+		}
+		blocks = append(blocks, block{text: strings.Join(lines[start:end], ""), line: line})
+		start = end
+	}
+	if len(spans) > 0 {
+		missing := make([]string, 0, len(spans))
+		for table := range spans {
+			missing = append(missing, table)
+		}
+		sort.Strings(missing)
+		return "", fmt.Errorf("%s がソースマップにありません。出力した後に編集されています", strings.Join(missing, ", "))
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].line < blocks[j].line })
+	var b strings.Builder
+	for _, block := range blocks {
+		b.WriteString(endLine(block.text))
+	}
+	// 最後のブロックの後ろの行（空行など）はそのまま末尾に置く
+	b.WriteString(strings.Join(lines[start:], ""))
+	return b.String(), nil
+}
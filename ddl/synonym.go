@@ -0,0 +1,59 @@
+package ddl
+
+import "regexp"
+
+// CREATE [OR REPLACE] [EDITIONABLE | NONEDITIONABLE] [PUBLIC] SYNONYM 名前 FOR 対象[@データベースリンク]
+const SYNONYM_PATTERN = `(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:EDITIONABLE|NONEDITIONABLE)\s+)?(PUBLIC\s+)?SYNONYM\s+` +
+	QUALIFIED_NAME_PATTERN + `\s+FOR\s+` + QUALIFIED_NAME_PATTERN + `(@\w+)?`
+
+var reCreateSynonym = regexp.MustCompile(SYNONYM_PATTERN)
+
+// 文が定義するシノニムの名前と対象（データベースリンクを介したシノニムは入力のテーブルを指さないため除く）
+func synonymDefinition(code string, db database) (name, target string, ok bool) {
+	matches := reCreateSynonym.FindStringSubmatch(code)
+	if matches == nil || matches[6] != "" {
+		return "", "", false
+	}
+	name = qualifiedName(matches[1:])
+	if matches[1] == "" {
+		// PUBLIC でないシノニムは USE で選択されているスキーマに作成される
+		name = db.qualify(name)
+	}
+	return name, db.qualify(qualifiedName(matches[3:])), true
+}
+
+// resolveSynonyms は依存関係の参照先がシノニムの場合に、入力で定義したシノニムはその名前に、
+// 定義していないシノニムはシノニムが指すテーブルに置き換える（入力で定義したシノニムはオブジェクトとして並び替え、対象に依存する）。
+// 修飾されていない参照先は参照元と同じスキーマのシノニム、次に PUBLIC のシノニムの順に探す
+func (o Options) resolveSynonyms(tables []string, edges []Edge, synonyms map[string]string) {
+	if len(synonyms) == 0 {
+		return
+	}
+	defined := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		defined[table] = true
+	}
+	for i := range edges {
+		edge := &edges[i]
+		// シノニムを指すシノニムもたどる（循環している場合は途中で止める）
+		for range len(synonyms) {
+			if defined[edge.Parent] {
+				break
+			}
+			name := edge.Parent
+			target, exists := synonyms[name]
+			if schema := SchemaOf(edge.Child); !exists && schema != "" && SchemaOf(edge.Parent) == "" {
+				name = schema + "." + edge.Parent
+				target, exists = synonyms[name]
+			}
+			if !exists {
+				break
+			}
+			if defined[name] {
+				edge.Parent = name
+				break
+			}
+			edge.Parent = o.resolve(target, edge.Child, defined)
+		}
+	}
+}
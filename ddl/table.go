@@ -0,0 +1,320 @@
+package ddl
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Column はテーブルのカラム定義
+type Column struct {
+	Name string `json:"name"`
+	// 型（小文字に揃え、空白を1つにまとめたもの）
+	Type    string `json:"type"`
+	NotNull bool   `json:"not_null,omitempty"`
+}
+
+// Key は主キー・一意制約・インデックス
+type Key struct {
+	Name    string   `json:"name,omitempty"`
+	Columns []string `json:"columns"`
+	Primary bool     `json:"primary,omitempty"`
+	Unique  bool     `json:"unique,omitempty"`
+	// WHERE で対象の行を絞った部分インデックス（外部キーの参照先にはならない）
+	Partial bool `json:"partial,omitempty"`
+}
+
+// Table は CREATE TABLE / ALTER TABLE / CREATE INDEX から組み立てたテーブル定義
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Keys        []Key        `json:"keys,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	// CONSTRAINT で名前を付けた制約の名前
+	Constraints []string `json:"constraints,omitempty"`
+	// CREATE TABLE の開始行（1始まり）
+	Line int `json:"line"`
+}
+
+// Column は名前でカラムを探す（大文字小文字は区別しない）
+func (t *Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// PrimaryKey は主キーのカラムを返す（主キーがない場合は nil）
+func (t *Table) PrimaryKey() []string {
+	for _, key := range t.Keys {
+		if key.Primary {
+			return key.Columns
+		}
+	}
+	return nil
+}
+
+// 主キーに含まれるカラムは NOT NULL として扱う
+func (t *Table) notNull(column Column) bool {
+	if column.NotNull {
+		return true
+	}
+	for _, name := range t.PrimaryKey() {
+		if strings.EqualFold(name, column.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUniqueKey は columns と同じカラムの組に主キーまたは一意制約があるかどうかを返す（順序は問わず、部分インデックスは除く）
+func (t *Table) HasUniqueKey(columns []string) bool {
+	for _, key := range t.Keys {
+		if (key.Primary || key.Unique) && !key.Partial && sameColumns(key.Columns, columns) {
+			return true
+		}
+	}
+	return false
+}
+
+const INDEX_PATTERN = `(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:` + IDENTIFIER_PATTERN + `\s+)?ON\s+(?:ONLY\s+)?` + QUALIFIED_NAME_PATTERN + `\s*(?:USING\s+\w+\s*)?\(([^()]*(?:\([^()]*\)[^()]*)*)\)`
+
+var (
+	reCreateIndex = regexp.MustCompile(INDEX_PATTERN)
+	// CREATE INDEX ... WHERE による部分インデックス
+	reWhere = regexp.MustCompile(`(?i)\bWHERE\b`)
+	// 項目の先頭の CONSTRAINT 名前
+	reConstraintName = regexp.MustCompile(`(?is)^\s*CONSTRAINT\s+` + IDENTIFIER_PATTERN + `\s*`)
+	// PRIMARY KEY / UNIQUE [KEY|INDEX] [名前] / KEY|INDEX [名前] の表制約
+	reKeyItem = regexp.MustCompile(`(?is)^\s*(PRIMARY\s+KEY|UNIQUE(?:\s+(?:KEY|INDEX))?|KEY|INDEX)\s*(?:` + IDENTIFIER_PATTERN + `\s*)?(?:USING\s+\w+\s*)?\(([^()]*)\)`)
+	// カラム定義以外の表制約
+	reOtherItem = regexp.MustCompile(`(?is)^\s*(?:FOREIGN\s+KEY|CHECK|EXCLUDE|LIKE|FULLTEXT|SPATIAL|PERIOD)\b`)
+	// カラム定義の名前
+	reColumnDef = regexp.MustCompile(`(?s)^\s*` + IDENTIFIER_PATTERN + `\s*(.*)$`)
+	// カラムの型の後ろに続く列制約の開始
+	reColumnOption = regexp.MustCompile(`(?i)\b(?:NOT\s+NULL|NULL|DEFAULT|PRIMARY\s+KEY|REFERENCES|UNIQUE|CHECK|CONSTRAINT|COLLATE|GENERATED|AUTO_INCREMENT|AUTOINCREMENT|COMMENT|IDENTITY|ON\s+UPDATE|DISTKEY|SORTKEY|ENCODE|AUTO_RANDOM)\b`)
+	rePrimaryKey   = regexp.MustCompile(`(?i)\bPRIMARY\s+KEY\b`)
+	reUnique       = regexp.MustCompile(`(?i)\bUNIQUE\b`)
+	reNotNull      = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	// ALTER TABLE の ADD [COLUMN]
+	reAddItem = regexp.MustCompile(`(?is)^\s*ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?`)
+)
+
+// Tables は入力に含まれるテーブル定義を入力に現れた順に返す
+func Tables(r io.Reader) ([]*Table, error) {
+	return TablesWithOptions(r, Options{})
+}
+
+// TablesWithOptions は opts の方言の終端文字と文字列リテラルの書き方で文を区切る Tables
+func TablesWithOptions(r io.Reader, opts Options) ([]*Table, error) {
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+
+	var tables []*Table
+	byName := make(map[string]*Table)
+	var db database
+	scanner := opts.newScanner(context.Background(), r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		if ignored(stmt) || db.use(code) {
+			continue
+		}
+
+		if matches := reCreateTable.FindStringSubmatch(code); len(matches) > 1 {
+			table := &Table{Name: db.qualify(qualifiedName(matches)), Line: stmt.Line + strings.Count(leadingText(stmt), "\n")}
+			if previous, exists := byName[table.Name]; exists && reOrReplace.MatchString(code) {
+				// CREATE OR REPLACE は前の定義を置き換える
+				for i := range tables {
+					if tables[i] == previous {
+						tables[i] = table
+					}
+				}
+			} else {
+				tables = append(tables, table)
+			}
+			byName[table.Name] = table
+
+			start := len(matches[0]) + strings.Index(code[len(matches[0]):], "(")
+			if start < len(matches[0]) {
+				continue
+			}
+			end := closingParen(code, start)
+			for _, item := range splitItems(code[start+1 : end]) {
+				table.addItem(item)
+			}
+			table.ForeignKeys = db.qualifyForeignKeys(statementForeignKeys(table.Name, code))
+			continue
+		}
+
+		if matches := reAlterTable.FindStringSubmatch(code); len(matches) > 1 {
+			table, exists := byName[db.qualify(qualifiedName(matches))]
+			if !exists {
+				continue
+			}
+			body := strings.TrimRight(code[len(matches[0]):], " \t\r\n;")
+			for _, item := range splitItems(body) {
+				if loc := reAddItem.FindStringIndex(item); loc != nil {
+					table.addItem(item[loc[1]:])
+				}
+			}
+			table.ForeignKeys = append(table.ForeignKeys, db.qualifyForeignKeys(statementForeignKeys(table.Name, code))...)
+			continue
+		}
+
+		if matches := reCreateIndex.FindStringSubmatch(code); matches != nil {
+			table, exists := byName[db.qualify(qualifiedName(matches[2:]))]
+			if !exists {
+				continue
+			}
+			table.Keys = append(table.Keys, Key{
+				Name:    matches[2],
+				Columns: indexColumns(matches[5]),
+				Unique:  matches[1] != "",
+				Partial: reWhere.MatchString(code[len(matches[0]):]),
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// 表の定義の1項目（カラム定義または表制約）を取り込む
+func (t *Table) addItem(item string) {
+	name := ""
+	if matches := reConstraintName.FindStringSubmatch(item); matches != nil {
+		name = matches[1]
+		t.Constraints = append(t.Constraints, name)
+		item = item[len(matches[0]):]
+	}
+
+	if matches := reKeyItem.FindStringSubmatch(item); matches != nil {
+		if name == "" {
+			name = matches[2]
+		}
+		kind := strings.ToUpper(matches[1])
+		t.Keys = append(t.Keys, Key{
+			Name:    name,
+			Columns: indexColumns(matches[3]),
+			Primary: strings.HasPrefix(kind, "PRIMARY"),
+			Unique:  strings.HasPrefix(kind, "PRIMARY") || strings.HasPrefix(kind, "UNIQUE"),
+		})
+		return
+	}
+	columnName, typ, options, ok := columnDefinition(item)
+	if !ok {
+		return
+	}
+	column := Column{Name: columnName, Type: strings.ToLower(strings.Join(strings.Fields(typ), " "))}
+
+	// 列制約
+	for _, matches := range reColumnConstraint.FindAllStringSubmatch(options, -1) {
+		t.Constraints = append(t.Constraints, matches[1])
+	}
+	if rePrimaryKey.MatchString(options) {
+		column.NotNull = true
+		t.Keys = append(t.Keys, Key{Columns: []string{column.Name}, Primary: true, Unique: true})
+	} else if reUnique.MatchString(options) {
+		t.Keys = append(t.Keys, Key{Columns: []string{column.Name}, Unique: true})
+	}
+	if reNotNull.MatchString(options) {
+		column.NotNull = true
+	}
+	t.Columns = append(t.Columns, column)
+}
+
+// カラム定義の名前・型（入力のまま）・列制約に分ける（表制約の場合は false）
+func columnDefinition(item string) (name, typ, options string, ok bool) {
+	if reOtherItem.MatchString(item) || strings.TrimSpace(item) == "" {
+		return "", "", "", false
+	}
+	matches := reColumnDef.FindStringSubmatch(item)
+	if matches == nil {
+		return "", "", "", false
+	}
+	rest := matches[2]
+	if loc := reColumnOption.FindStringIndex(rest); loc != nil {
+		return matches[1], rest[:loc[0]], rest[loc[0]:], true
+	}
+	return matches[1], rest, "", true
+}
+
+// 括弧の外のカンマで項目を分割する
+func splitItems(body string) []string {
+	var items []string
+	for start := 0; start <= len(body); {
+		end := itemEnd(body, start)
+		items = append(items, body[start:end])
+		if end >= len(body) || body[end] != ',' {
+			break
+		}
+		start = end + 1
+	}
+	return items
+}
+
+// open の位置の開き括弧に対応する閉じ括弧の位置を返す（見つからない場合は末尾）
+func closingParen(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(code)
+}
+
+// インデックスのカラムリストから、長さの指定や並び順を除いたカラム名を取り出す
+func indexColumns(list string) []string {
+	var columns []string
+	for _, item := range splitItems(list) {
+		if matches := reColumnDef.FindStringSubmatch(item); matches != nil {
+			columns = append(columns, matches[1])
+		}
+	}
+	return columns
+}
+
+// 2つのカラムの組が順序を問わず一致するかどうか（大文字小文字は区別しない）
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// 2つのカラムの組が同じ順に一致するかどうか（大文字小文字は区別しない）
+func sameColumnOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
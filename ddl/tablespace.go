@@ -0,0 +1,37 @@
+package ddl
+
+import "regexp"
+
+const (
+	// CREATE [UNDO] TABLESPACE 名前 ...
+	TABLESPACE_PATTERN = `(?is)^\s*CREATE\s+(?:UNDO\s+)?TABLESPACE\s+` + "[`\"]?" + `(\w+)` + "[`\"]?"
+	// テーブル・インデックス・制約の TABLESPACE 名前 / TABLESPACE = 名前（CREATE TABLESPACE 自身は除く）
+	TABLESPACE_CLAUSE_PATTERN = `(?i)\bTABLESPACE\s*(?:=\s*)?` + "[`\"]?" + `(\w+)` + "[`\"]?"
+)
+
+var (
+	reCreateTablespace = regexp.MustCompile(TABLESPACE_PATTERN)
+	reTablespaceClause = regexp.MustCompile(TABLESPACE_CLAUSE_PATTERN)
+)
+
+// 文が定義するテーブルスペースの名前
+func tablespaceDefinition(code string) (string, bool) {
+	if matches := reCreateTablespace.FindStringSubmatch(code); matches != nil {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// 文が TABLESPACE で指定するテーブルスペースの名前（入力に定義されたものだけを依存関係にする）
+func tablespaceUses(code string) []string {
+	if reCreateTablespace.MatchString(code) {
+		return nil
+	}
+	var names []string
+	for _, matches := range reTablespaceClause.FindAllStringSubmatch(code, -1) {
+		if !contains(names, matches[1]) {
+			names = append(names, matches[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,103 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TerminatorStyle は出力する文の終端文字の揃え方
+type TerminatorStyle string
+
+const (
+	// TerminatorsKeep は入力のまま出力する
+	TerminatorsKeep TerminatorStyle = "keep"
+	// TerminatorsSemicolon はすべての文を ; で終え、GO の行を除く
+	TerminatorsSemicolon TerminatorStyle = "semicolon"
+	// TerminatorsGo はすべての文を ; で終え、その次の行に GO を置く（SQL Server の sqlcmd など）
+	TerminatorsGo TerminatorStyle = "go"
+)
+
+// ParseTerminatorStyle は文字列から TerminatorStyle を返す（空文字は keep）
+func ParseTerminatorStyle(s string) (TerminatorStyle, error) {
+	switch TerminatorStyle(strings.ToLower(s)) {
+	case "", TerminatorsKeep:
+		return TerminatorsKeep, nil
+	case TerminatorsSemicolon:
+		return TerminatorsSemicolon, nil
+	case TerminatorsGo:
+		return TerminatorsGo, nil
+	}
+	return "", fmt.Errorf("不明な terminators の指定です: %s", s)
+}
+
+// batchSeparator は文が SQL Server の GO の行で終わっているかどうかを返す
+func (s Statement) batchSeparator() bool {
+	return strings.EqualFold(s.Terminator, "GO")
+}
+
+// NormalizeTerminators はブロックごとに文の終端文字を opts.Terminators に揃える。
+// 終端文字のない文には ; を付け、GO の行は semicolon では除き、go ではすべての文の後ろに置く。
+// DELIMITER などで終端文字を切り替えている間の文と COPY のデータ行はそのままにする
+func NormalizeTerminators(ddlContent map[string]string, opts Options) map[string]string {
+	if opts.Terminators != TerminatorsSemicolon && opts.Terminators != TerminatorsGo {
+		return ddlContent
+	}
+	normalized := make(map[string]string, len(ddlContent))
+	for table, block := range ddlContent {
+		normalized[table] = normalizeBlock(block, opts)
+	}
+	return normalized
+}
+
+func normalizeBlock(block string, opts Options) string {
+	var out strings.Builder
+	scanner := opts.newScanner(context.Background(), strings.NewReader(block))
+	terminator := scanner.delimiter
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		switch {
+		case stmt.Directive || stmt.CopyData || scanner.delimiter != terminator:
+			out.WriteString(stmt.Text)
+		case !stmt.executable():
+			// 単独の GO の行は除き、前置きのコメントは残す
+			if stmt.batchSeparator() {
+				out.WriteString(leadingText(stmt))
+			} else {
+				out.WriteString(stmt.Text)
+			}
+		default:
+			out.WriteString(normalizeStatement(stmt, terminator, opts.Terminators))
+		}
+	}
+	return out.String()
+}
+
+// 文の本体の後ろに terminator を付け（go の場合は次の行に GO を置き）、終端文字の後ろの行末コメントは残す
+func normalizeStatement(stmt Statement, terminator string, style TerminatorStyle) string {
+	end, rest := len(stmt.Code), ""
+	if stmt.Terminator != "" {
+		if i := strings.LastIndex(stmt.Code, stmt.Terminator); i >= 0 {
+			end, rest = i, stmt.Text[i+len(stmt.Terminator):]
+		}
+	}
+	if stmt.batchSeparator() {
+		// GO の後ろの回数は除く（改行は残す）
+		rest = rest[len(rest)-len(strings.TrimLeft(rest, " \t0123456789")):]
+	}
+	body := strings.TrimRight(stmt.Code[:end], " \t\r\n")
+	// 本体の後ろのコメントは終端文字の後ろに移す
+	trailing := stmt.Text[len(body):end] + rest
+	if strings.TrimSpace(trailing) == "" {
+		trailing = "\n"
+	}
+
+	text := stmt.Text[:len(body)] + terminator
+	if style == TerminatorsGo {
+		if !strings.HasSuffix(trailing, "\n") {
+			trailing += "\n"
+		}
+		return text + trailing + "GO\n"
+	}
+	return text + trailing
+}
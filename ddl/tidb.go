@@ -0,0 +1,69 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// CREATE PLACEMENT POLICY [IF NOT EXISTS] 名前
+	rePlacementPolicy = regexp.MustCompile(`(?is)^\s*CREATE\s+PLACEMENT\s+POLICY\s+(?:IF\s+NOT\s+EXISTS\s+)?` + "`?" + `(\w+)` + "`?")
+	// テーブルやデータベースの PLACEMENT POLICY = 名前（/*T![placement] ... */ の中に書かれることが多い）
+	rePlacementPolicyOption = regexp.MustCompile(`(?i)\bPLACEMENT\s+POLICY\s*=\s*` + "`?" + `(\w+)` + "`?")
+	// Vitess の ALTER VSCHEMA ON テーブル ...
+	reAlterVSchema = regexp.MustCompile(`(?is)^\s*ALTER\s+VSCHEMA\s+ON\s+` + QUALIFIED_NAME_PATTERN)
+)
+
+func init() {
+	RegisterDialect(tidbDialect{name: "tidb"})
+	RegisterDialect(tidbDialect{name: "vitess"})
+}
+
+// TiDB / Vitess。MySQL と同じ構文に加え、配置ポリシーをテーブルより先に作成し、
+// ALTER VSCHEMA をその対象のテーブルより後に置く（/*T! ... */ や /*vt+ ... */ のコメントはそのまま残す）
+type tidbDialect struct {
+	name string
+}
+
+func (d tidbDialect) Name() string { return d.name }
+
+func (tidbDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (tidbDialect) Terminator() string { return ";" }
+
+func (tidbDialect) BackslashEscapes() bool { return true }
+
+func (tidbDialect) ReferencedKey(table *Table, columns []string) bool {
+	return mysqlDialect{}.ReferencedKey(table, columns)
+}
+
+// 配置ポリシーは「placement policy 名前」の名前のオブジェクトとして並び替える
+func placementPolicy(name string) string {
+	return "placement policy " + name
+}
+
+func (tidbDialect) Object(stmt Statement) (string, bool) {
+	if matches := rePlacementPolicy.FindStringSubmatch(stmt.Code); matches != nil {
+		return placementPolicy(matches[1]), true
+	}
+	return "", false
+}
+
+func (tidbDialect) Dependencies(stmt Statement) []string {
+	if matches := reAlterVSchema.FindStringSubmatch(stmt.Code); matches != nil {
+		return []string{qualifiedName(matches)}
+	}
+	if rePlacementPolicy.MatchString(stmt.Code) {
+		return nil
+	}
+	// 実行されるコメントの中も探す
+	var deps []string
+	for _, matches := range rePlacementPolicyOption.FindAllStringSubmatch(stmt.Text[len(leadingText(stmt)):], -1) {
+		if !strings.EqualFold(matches[1], "DEFAULT") {
+			deps = append(deps, placementPolicy(matches[1]))
+		}
+	}
+	return deps
+}
@@ -0,0 +1,30 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SELECT [schema.]create_hypertable('テーブル', ...) などの、テーブルを対象にする TimescaleDB の関数の呼び出し
+const HYPERTABLE_PATTERN = `(?is)^\s*SELECT\s+(?:\w+\s*\.\s*)?(?:create_hypertable|create_distributed_hypertable|add_dimension|set_chunk_time_interval|set_integer_now_func|add_retention_policy|add_compression_policy|add_reorder_policy|add_columnstore_policy)\s*\(\s*(?:(?:relation|hypertable)\s*=>\s*)?'`
+
+var reHypertable = regexp.MustCompile(HYPERTABLE_PATTERN)
+
+// hypertableTarget は TimescaleDB の関数の呼び出しの対象のテーブルを返す（呼び出しでない場合は空文字）。
+// テーブル名は文字列リテラルの中にあるため Code ではなく Text から取り出す
+func hypertableTarget(stmt Statement) string {
+	loc := reHypertable.FindStringIndex(stmt.Code)
+	if loc == nil {
+		return ""
+	}
+	literal := stmt.Text[loc[1]:]
+	end := strings.IndexByte(literal, '\'')
+	if end < 0 {
+		return ""
+	}
+	parts := strings.Split(literal[:end], ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"`)
+	}
+	return strings.Join(parts, ".")
+}
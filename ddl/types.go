@@ -0,0 +1,100 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CREATE [OR REPLACE] TYPE 名前 ... / CREATE DOMAIN 名前 ...
+const TYPE_PATTERN = `(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:EDITIONABLE|NONEDITIONABLE)\s+)?(TYPE|DOMAIN)\s+(?:IF\s+NOT\s+EXISTS\s+)?` +
+	"[`\"]?" + `(\w+)` + "[`\"]?" + `(?:\.` + "[`\"]?" + `(\w+)` + "[`\"]?" + `)?`
+
+var (
+	reCreateType = regexp.MustCompile(TYPE_PATTERN)
+	// 型の名前（"schema"."type" の形でもよい。配列や長さの指定は除く）
+	reTypeName = regexp.MustCompile(`^\s*"?(\w+)"?(?:\s*\.\s*"?(\w+)"?)?`)
+	// CREATE DOMAIN 名前 [AS] 元の型
+	reDomainBase = regexp.MustCompile(`(?is)^\s*(?:AS\s+)?("?\w+"?(?:\s*\.\s*"?\w+"?)?)`)
+)
+
+// 文が定義するユーザー定義の型（複合型・列挙型・ドメインなど）の名前。
+// CREATE TYPE BODY は型の実装であるため含めない
+func typeDefinition(code string) (string, bool) {
+	matches := reCreateType.FindStringSubmatch(code)
+	if matches == nil || strings.EqualFold(matches[2], "BODY") && matches[3] == "" {
+		return "", false
+	}
+	return qualifiedName(matches[1:]), true
+}
+
+// テーブルのカラム・複合型の属性・ドメインの元の型に使われている型の名前。
+// 組み込みの型も含むため、入力に定義された型だけを依存関係にする
+func columnTypes(code string) []string {
+	var types []string
+	add := func(typ string) {
+		if matches := reTypeName.FindStringSubmatch(typ); matches != nil {
+			if name := qualifiedName(matches); !contains(types, name) {
+				types = append(types, name)
+			}
+		}
+	}
+	addItems := func(body string, alter bool) {
+		for _, item := range splitItems(body) {
+			if alter {
+				loc := reAddItem.FindStringIndex(item)
+				if loc == nil {
+					continue
+				}
+				item = item[loc[1]:]
+			}
+			if reConstraintName.MatchString(item) || reKeyItem.MatchString(item) {
+				continue
+			}
+			if _, typ, _, ok := columnDefinition(item); ok {
+				add(typ)
+			}
+		}
+	}
+
+	switch {
+	case reTableDefinition.MatchString(code):
+		matches := reTableDefinition.FindString(code)
+		if start := strings.Index(code[len(matches):], "("); start >= 0 {
+			start += len(matches)
+			addItems(code[start+1:closingParen(code, start)], false)
+		}
+	case reAlterTable.MatchString(code):
+		addItems(strings.TrimRight(code[len(reAlterTable.FindString(code)):], " \t\r\n;"), true)
+	case reCreateType.MatchString(code):
+		loc := reCreateType.FindStringSubmatchIndex(code)
+		rest := code[loc[1]:]
+		if strings.EqualFold(code[loc[2]:loc[3]], "DOMAIN") {
+			if matches := reDomainBase.FindStringSubmatch(rest); matches != nil {
+				add(matches[1])
+			}
+			break
+		}
+		// CREATE TYPE 名前 AS (属性 型, ...) の複合型
+		if body := strings.TrimLeft(rest, " \t\r\n"); len(body) > 2 && strings.EqualFold(body[:2], "AS") {
+			if open := strings.Index(body, "("); open >= 0 && strings.TrimSpace(body[2:open]) == "" {
+				addItems(body[open+1:closingParen(body, open)], false)
+			}
+		}
+	}
+	return types
+}
+
+// 型・照合順序・テーブルスペースを使う文の依存関係の候補から、入力に定義されたものへの依存関係を返す
+// （修飾されていない名前は SearchPath と Resolution に従って解決する）
+func (o Options) namedEdges(uses []Edge, named map[string]bool) []Edge {
+	var edges []Edge
+	seen := make(map[[2]string]bool)
+	for _, use := range uses {
+		parent := o.resolve(use.Parent, use.Child, named)
+		if key := [2]string{parent, use.Child}; named[parent] && parent != use.Child && !seen[key] {
+			seen[key] = true
+			edges = append(edges, Edge{Parent: parent, Child: use.Child})
+		}
+	}
+	return edges
+}
@@ -0,0 +1,90 @@
+package ddl
+
+import (
+	"context"
+	"strings"
+)
+
+// PlaceUnknown は opts.Unknown が top / bottom の場合に、種類を判断できない文をブロックから取り出し、
+// 並び替えた順で最初のブロックの先頭または最後のブロックの末尾にまとめる（DELIMITER などで終端文字を変えていた文は同じコマンドで囲む）
+func PlaceUnknown(ddlContent map[string]string, sortedTables []string, opts Options) map[string]string {
+	if opts.Unknown != UnknownTop && opts.Unknown != UnknownBottom {
+		return ddlContent
+	}
+
+	placed := make(map[string]string, len(ddlContent))
+	for table, block := range ddlContent {
+		placed[table] = block
+	}
+	var blocks []string
+	var group strings.Builder
+	// 終端文字を切り替えていたのが DB2 の --#SET TERMINATOR かどうか
+	setTerminator := false
+	for _, table := range sortedTables {
+		block, exists := placed[table]
+		if !exists {
+			continue
+		}
+		blocks = append(blocks, table)
+
+		var rest strings.Builder
+		scanner := opts.newScanner(context.Background(), strings.NewReader(block))
+		terminator := scanner.delimiter
+		// DELIMITER から次に残す文までのテキストと、最初の DELIMITER の前の終端文字
+		var directives strings.Builder
+		before := ""
+		// 囲んでいた文をすべて取り出した結果、終端文字が元に戻る DELIMITER は残さない
+		flushDirectives := func() {
+			if directives.Len() > 0 && before != scanner.delimiter {
+				rest.WriteString(directives.String())
+			}
+			directives.Reset()
+			before = ""
+		}
+		current := terminator
+		for scanner.Scan() {
+			stmt := scanner.Statement()
+			switch {
+			case stmt.Directive:
+				setTerminator = strings.HasPrefix(strings.TrimSpace(stmt.Text), "--#")
+				if directives.Len() == 0 {
+					before = current
+				}
+				directives.WriteString(stmt.Text)
+				current = scanner.delimiter
+				continue
+			case !stmt.executable() && directives.Len() > 0:
+				directives.WriteString(stmt.Text)
+				continue
+			case !stmt.executable() || !opts.unknown(stmt):
+				flushDirectives()
+				rest.WriteString(stmt.Text)
+				continue
+			}
+			if stmt.Terminator != terminator && stmt.Terminator != "" && !stmt.batchSeparator() {
+				command := "DELIMITER "
+				if setTerminator {
+					command = "--#SET TERMINATOR "
+				}
+				group.WriteString(command + stmt.Terminator + "\n")
+				group.WriteString(endLine(stmt.Text))
+				group.WriteString(command + terminator + "\n")
+				continue
+			}
+			group.WriteString(endLine(stmt.Text))
+		}
+		flushDirectives()
+		placed[table] = rest.String()
+	}
+	if group.Len() == 0 || len(blocks) == 0 {
+		return ddlContent
+	}
+
+	if opts.Unknown == UnknownTop {
+		placed[blocks[0]] = group.String() + placed[blocks[0]]
+	} else {
+		last := blocks[len(blocks)-1]
+		placed[last] = endLine(placed[last]) + group.String()
+	}
+	return placed
+}
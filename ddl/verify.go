@@ -0,0 +1,110 @@
+package ddl
+
+import (
+	"context"
+	"strings"
+)
+
+// StatementDiff は入力と並び替えた出力の文の違い
+type StatementDiff struct {
+	// 入力にあり、出力にない文（入力での位置）
+	Missing []Statement
+	// 出力にあり、入力にない文か、入力より多く現れる文（出力での位置）
+	Extra []Statement
+}
+
+// Empty は違いがないかどうかを返す
+func (d StatementDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0
+}
+
+// VerifyStatements は src を opts で分割した結果の output に、入力と同じ文がちょうど1つずつ含まれているかを調べる。
+// 設定に従って除いた文（置き換えられた CREATE OR REPLACE、除いた一時テーブル、-views skip のビュー）は入力に含めず、
+// 最初のテーブルより前にあるため出力されない文は Missing とする。
+// added（-fix で末尾に加えた ALTER TABLE など）は入力に加える。
+// USE はブロックごとに出力し直すため比べない。文は空白の違いを無視して比べる
+func VerifyStatements(ctx context.Context, src, output, added string, opts Options) (StatementDiff, int, error) {
+	dropped, err := DroppedStatements(ctx, src, opts)
+	if err != nil {
+		return StatementDiff{}, 0, err
+	}
+	excluded := make(map[string]int)
+	for _, stmt := range dropped {
+		if compared(stmt) {
+			excluded[statementKey(stmt)]++
+		}
+	}
+
+	input, err := comparedStatements(src+endLine(added), opts)
+	if err != nil {
+		return StatementDiff{}, 0, err
+	}
+
+	// 入力の文を数え、出力に現れるたびに減らす
+	expected := make(map[string][]Statement)
+	total := 0
+	for _, stmt := range input {
+		key := statementKey(stmt)
+		if excluded[key] > 0 {
+			excluded[key]--
+			continue
+		}
+		expected[key] = append(expected[key], stmt)
+		total++
+	}
+
+	outputStatements, err := comparedStatements(output, opts)
+	if err != nil {
+		return StatementDiff{}, 0, err
+	}
+	var diff StatementDiff
+	for _, stmt := range outputStatements {
+		key := statementKey(stmt)
+		if remaining := expected[key]; len(remaining) > 0 {
+			expected[key] = remaining[1:]
+			continue
+		}
+		diff.Extra = append(diff.Extra, stmt)
+	}
+	for _, stmt := range input {
+		key := statementKey(stmt)
+		if remaining := expected[key]; len(remaining) > 0 && remaining[0].Offset == stmt.Offset {
+			diff.Missing = append(diff.Missing, stmt)
+			expected[key] = remaining[1:]
+		}
+	}
+	return diff, total, nil
+}
+
+// 比べる文（空白やコメントだけの文、DELIMITER コマンド、USE を除く）
+func comparedStatements(src string, opts Options) ([]Statement, error) {
+	var statements []Statement
+	scanner := opts.newScanner(context.Background(), strings.NewReader(src))
+	for scanner.Scan() {
+		if stmt := scanner.Statement(); compared(stmt) {
+			statements = append(statements, stmt)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// 比べる文かどうか
+func compared(stmt Statement) bool {
+	return stmt.executable() && !reUse.MatchString(stmt.Code)
+}
+
+// 前後のコメントと空白の違いを除いた文の内容（-annotate のコメントや終端文字の揃え方では変わらない）
+func statementKey(stmt Statement) string {
+	code := stmt.Code
+	if stmt.Terminator != "" {
+		if i := strings.LastIndex(code, stmt.Terminator); i >= 0 {
+			code = code[:i]
+		}
+	}
+	end := len(strings.TrimRight(code, " \t\r\n"))
+	start := len(code) - len(strings.TrimLeft(code, " \t\r\n"))
+	return strings.Join(strings.Fields(stmt.Text[start:max(start, end)]), " ")
+}
@@ -0,0 +1,85 @@
+package ddl
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVerifyStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		opts Options
+		// output が空の場合は Order の結果と比べる
+		output  string
+		total   int
+		missing []string
+		extra   []string
+	}{
+		{
+			name:    "最初のテーブルより前の SET は出力されないため Missing にする",
+			src:     "SET x=1;\nCREATE TABLE c (id int, p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			total:   3,
+			missing: []string{"SET x=1"},
+		},
+		{
+			name:  "最初のテーブルより前の orderddl:ignore の文とそのまま残すビュー",
+			src:   "-- orderddl:ignore\nCREATE TABLE legacy (id int);\nCREATE VIEW v AS SELECT 1;\nCREATE TABLE c (id int, p int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			opts:  Options{Views: ViewsPassthrough},
+			total: 4,
+		},
+		{
+			name:  "-temporary exclude で除いた一時テーブルは比べない",
+			src:   "CREATE TABLE a (id int);\nCREATE TEMPORARY TABLE t (id int);\nCREATE TABLE b (id int);\n",
+			opts:  Options{Temporary: TemporaryExclude},
+			total: 2,
+		},
+		{
+			name:    "出力にない文",
+			src:     "CREATE TABLE a (id int);\nCREATE INDEX a_id ON a (id);\n",
+			output:  "CREATE TABLE a (id int);\n",
+			total:   2,
+			missing: []string{"CREATE INDEX a_id ON a (id)"},
+		},
+		{
+			name:   "空白の違いは無視し、重複した文は Extra にする",
+			src:    "CREATE TABLE a (id int);\n",
+			output: "CREATE  TABLE a\n  (id int);\nCREATE TABLE a (id int);\n",
+			total:  1,
+			extra:  []string{"CREATE TABLE a (id int)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := tt.output
+			if output == "" {
+				var err error
+				if output, err = Order(tt.src, tt.opts); err != nil {
+					t.Fatal(err)
+				}
+			}
+			diff, total, err := VerifyStatements(context.Background(), tt.src, output, "", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+			if got := statementKeys(diff.Missing); strings.Join(got, "\n") != strings.Join(tt.missing, "\n") {
+				t.Errorf("Missing = %q, want %q", got, tt.missing)
+			}
+			if got := statementKeys(diff.Extra); strings.Join(got, "\n") != strings.Join(tt.extra, "\n") {
+				t.Errorf("Extra = %q, want %q", got, tt.extra)
+			}
+		})
+	}
+}
+
+func statementKeys(statements []Statement) []string {
+	var keys []string
+	for _, stmt := range statements {
+		keys = append(keys, statementKey(stmt))
+	}
+	return keys
+}
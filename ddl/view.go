@@ -0,0 +1,54 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CREATE [OR REPLACE] [修飾子 ...] VIEW [IF NOT EXISTS] 名前
+const VIEW_PATTERN = `(?is)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:TEMP|TEMPORARY|SECURE|MATERIALIZED|RECURSIVE|ALGORITHM\s*=\s*\w+|DEFINER\s*=\s*\S+|SQL\s+SECURITY\s+\w+)\s+)*` +
+	`VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?` + QUALIFIED_NAME_PATTERN
+
+// FROM / JOIN の後ろのテーブルの並び（FROM a x, b AS y のようなカンマ区切りを含む）
+const VIEW_SOURCE_PATTERN = `(?i)\b(?:FROM|JOIN)\s+(` + VIEW_SOURCE_NAME + `(?:\s+(?:AS\s+)?\w+)?(?:\s*,\s*` + VIEW_SOURCE_NAME + `(?:\s+(?:AS\s+)?\w+)?)*)`
+
+const VIEW_SOURCE_NAME = "[`\"]?" + IDENTIFIER_CHARS + "[`\"]?" + `(?:\.` + "[`\"]?" + IDENTIFIER_CHARS + "[`\"]?" + `)?`
+
+var (
+	reCreateView = regexp.MustCompile(VIEW_PATTERN)
+	reViewSource = regexp.MustCompile(VIEW_SOURCE_PATTERN)
+	reSourceName = regexp.MustCompile(`^` + QUALIFIED_NAME_PATTERN)
+)
+
+// Options.Views が order の場合に、並び替えるビューの名前
+func (o Options) orderedView(stmt Statement) (string, bool) {
+	if o.Views != ViewsOrder {
+		return "", false
+	}
+	if matches := reCreateView.FindStringSubmatch(stmt.Code); matches != nil {
+		return qualifiedName(matches), true
+	}
+	return "", false
+}
+
+// ビューの定義が FROM と JOIN で参照するテーブル（現れた順、重複とビュー自身は除く）
+func viewSources(code string) []string {
+	loc := reCreateView.FindStringSubmatchIndex(code)
+	if loc == nil {
+		return nil
+	}
+	view := qualifiedName(submatches(code, loc))
+	var sources []string
+	for _, matches := range reViewSource.FindAllStringSubmatch(code[loc[1]:], -1) {
+		for _, item := range strings.Split(matches[1], ",") {
+			name := reSourceName.FindStringSubmatch(strings.TrimSpace(item))
+			if name == nil {
+				continue
+			}
+			if source := qualifiedName(name); source != view && !contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+	return sources
+}
@@ -0,0 +1,129 @@
+package ddl
+
+import (
+	"context"
+	"io"
+	"regexp"
+)
+
+// StatementKind は文の種類
+type StatementKind string
+
+const (
+	KindCreateTable StatementKind = "create-table"
+	KindAlterTable  StatementKind = "alter-table"
+	KindCreateIndex StatementKind = "create-index"
+	// CREATE TEMPORARY TABLE などの一時テーブルの文
+	KindTemporaryTable StatementKind = "temporary-table"
+	KindUse            StatementKind = "use"
+	KindCreateDatabase StatementKind = "create-database"
+	KindLockTables     StatementKind = "lock-tables"
+	KindUnlockTables   StatementKind = "unlock-tables"
+	KindData           StatementKind = "data"
+	// 空白やコメントだけの文、DELIMITER コマンドとその他の文
+	KindOther StatementKind = "other"
+)
+
+// StatementInfo は Walk が文ごとに渡す情報
+type StatementInfo struct {
+	Statement
+	Kind StatementKind
+	// 文の対象のテーブル（USE で選択されたデータベースで修飾する）。USE と CREATE DATABASE ではデータベース名。
+	// COPY のデータ行では直前の COPY のテーブル
+	Name string
+	// 外部キーと orderddl:after で依存するテーブル（現れた順、重複と自己参照は除く）
+	Dependencies []string
+	// orderddl:ignore が付いた文
+	Ignored bool
+}
+
+// Walk は入力を先頭から1文ずつ解析し、文ごとに fn を呼ぶ。入力全体を保持しないため大きな入力にも使える。
+// fn がエラーを返した場合はそこで終え、そのエラーを返す
+func Walk(r io.Reader, fn func(StatementInfo) error) error {
+	return WalkContext(context.Background(), r, fn)
+}
+
+// WalkContext は ctx が取り消された場合に途中で終える Walk
+func WalkContext(ctx context.Context, r io.Reader, fn func(StatementInfo) error) error {
+	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
+
+	var db database
+	copyTable := ""
+	scanner := NewStatementScannerContext(ctx, r)
+	for scanner.Scan() {
+		stmt := scanner.Statement()
+		code := stmt.Code
+		info := StatementInfo{Statement: stmt, Kind: KindOther, Ignored: ignored(stmt)}
+
+		switch {
+		case stmt.Directive:
+		case stmt.CopyData:
+			info.Kind, info.Name = KindData, copyTable
+		case db.use(code):
+			info.Kind, info.Name = KindUse, string(db)
+		case reCreateDatabase.MatchString(code):
+			info.Kind, info.Name = KindCreateDatabase, reCreateDatabase.FindStringSubmatch(code)[1]
+		case reTemporaryTable.MatchString(code):
+			info.Kind = KindTemporaryTable
+			info.Name = db.qualify(qualifiedName(reTemporaryTable.FindStringSubmatch(code)))
+		case reCreateTable.MatchString(code):
+			info.Kind = KindCreateTable
+			info.Name = db.qualify(qualifiedName(reCreateTable.FindStringSubmatch(code)))
+			for _, parent := range parseHint(stmt).After {
+				info.addDependency(db.qualify(parent))
+			}
+			for _, fk := range db.qualifyForeignKeys(statementForeignKeys(info.Name, code)) {
+				info.addDependency(fk.RefTable)
+			}
+		case reAlterTable.MatchString(code):
+			info.Kind = KindAlterTable
+			info.Name = db.qualify(qualifiedName(reAlterTable.FindStringSubmatch(code)))
+			for _, fk := range db.qualifyForeignKeys(statementForeignKeys(info.Name, code)) {
+				info.addDependency(fk.RefTable)
+			}
+		case reCreateIndex.MatchString(code):
+			info.Kind = KindCreateIndex
+			info.Name = db.qualify(qualifiedName(reCreateIndex.FindStringSubmatch(code)[2:]))
+		case reLockTables.MatchString(code):
+			info.Kind = KindLockTables
+			info.Name = db.qualify(qualifiedName(reLockTables.FindStringSubmatch(code)))
+		case reUnlockTables.MatchString(code):
+			info.Kind = KindUnlockTables
+		case reData.MatchString(code):
+			info.Kind = KindData
+			if matches := reDataTable.FindStringSubmatch(code); matches != nil {
+				info.Name = db.qualify(qualifiedName(matches))
+				copyTable = info.Name
+			}
+		}
+
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// 組み込みの解析が種類を判断できる文（空白やコメントだけの文と DELIMITER コマンドを含む）
+var recognizedPatterns = []*regexp.Regexp{
+	reUse, reCreateDatabase, reTemporaryTable, reOrReplace, reTableDefinition, reAlterTable, reCreateIndex, reLockTables, reUnlockTables, reData, reCreateSynonym, reCharsetSetting, reHypertable,
+}
+
+// recognized は文の種類を組み込みの解析で判断できるかどうかを返す
+func recognized(stmt Statement) bool {
+	if stmt.Directive || stmt.CopyData || !stmt.executable() {
+		return true
+	}
+	for _, re := range recognizedPatterns {
+		if re.MatchString(stmt.Code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (info *StatementInfo) addDependency(table string) {
+	if table != info.Name && !contains(info.Dependencies, table) {
+		info.Dependencies = append(info.Dependencies, table)
+	}
+}
@@ -0,0 +1,49 @@
+package ddl
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Weight は名前が Pattern に一致するテーブルの並び順の重み。
+// 依存関係と orderddl:first / orderddl:last が許す範囲で、重みが小さいテーブルほど先に置く（一致しないテーブルは 0）
+type Weight struct {
+	// テーブル名のパターン（path.Match の書き方。修飾した名前と、修飾を除いた名前のどちらかに一致すればよい）
+	Pattern string `json:"pattern"`
+	Weight  int    `json:"weight"`
+}
+
+// CheckWeights は重みのパターンが正しいかどうかを確かめる
+func CheckWeights(weights []Weight) error {
+	for i, w := range weights {
+		if _, err := path.Match(w.Pattern, ""); err != nil {
+			return fmt.Errorf("%d番目の重みの pattern が正しくありません: %s: %w", i+1, w.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// テーブルごとの重み（複数のパターンに一致する場合は最初のもの）
+func (o Options) weights(tables []string) map[string]int {
+	weight := make(map[string]int)
+	if len(o.Weights) == 0 {
+		return weight
+	}
+	for _, table := range tables {
+		name := strings.ToLower(table)
+		short := name[strings.LastIndex(name, ".")+1:]
+		for _, w := range o.Weights {
+			pattern := strings.ToLower(w.Pattern)
+			if matched, _ := path.Match(pattern, name); matched {
+				weight[table] = w.Weight
+				break
+			}
+			if matched, _ := path.Match(pattern, short); matched {
+				weight[table] = w.Weight
+				break
+			}
+		}
+	}
+	return weight
+}
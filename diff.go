@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// diff サブコマンドを実行する
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	out := fs.String("o", "", "マイグレーションの出力先（空の場合は標準出力）")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errors.New("使い方: orderddl diff [-o migration.sql] <old.sql> <new.sql>")
+	}
+
+	oldContent, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	newContent, err := os.ReadFile(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	oldSrc, newSrc := string(oldContent), string(newContent)
+
+	diff, err := ddl.Diff(oldSrc, newSrc, ddl.Options{})
+	if err != nil {
+		return err
+	}
+
+	// 差分の概要は標準エラー出力に表示する
+	for _, table := range diff.AddedTables {
+		fmt.Fprintln(os.Stderr, "+ テーブル", table)
+	}
+	for _, table := range diff.RemovedTables {
+		fmt.Fprintln(os.Stderr, "- テーブル", table)
+	}
+	for _, fk := range diff.AddedForeignKeys {
+		fmt.Fprintln(os.Stderr, "+ 外部キー", fk)
+	}
+	for _, fk := range diff.RemovedForeignKeys {
+		fmt.Fprintln(os.Stderr, "- 外部キー", fk)
+	}
+
+	script, err := migrationScript(oldSrc, newSrc, diff)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(script)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(script), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ マイグレーションを出力しました:", *out)
+	return nil
+}
+
+// 差分を適用するマイグレーションを組み立てる。
+// 削除された外部キー → 削除されたテーブル（作成順の逆順） → 追加されたテーブル（作成順） → 追加された外部キー の順に並べる。
+// テーブル名は削除するものは古い DDL、追加するものは新しい DDL での書き方に合わせて引用符で囲む
+func migrationScript(oldSrc, newSrc string, diff *ddl.SchemaDiff) (string, error) {
+	ddlContent, err := ddl.Split(strings.NewReader(newSrc))
+	if err != nil {
+		return "", err
+	}
+	opts := ddl.Options{Dialect: dialect}
+	oldSpellings, newSpellings := ddl.TableSpellings(oldSrc, opts), ddl.TableSpellings(newSrc, opts)
+
+	var out strings.Builder
+	for _, fk := range diff.RemovedForeignKeys {
+		if fk.Name == "" {
+			fmt.Fprintf(&out, "-- orderddl: 制約名がないため削除できません: %s\n", fk)
+			continue
+		}
+		fmt.Fprintf(&out, "ALTER TABLE %s DROP CONSTRAINT %s;\n", quoteTable(oldSpellings, fk.Table), fk.Name)
+	}
+	for i := len(diff.RemovedTables) - 1; i >= 0; i-- {
+		fmt.Fprintf(&out, "DROP TABLE IF EXISTS %s;\n", quoteTable(oldSpellings, diff.RemovedTables[i]))
+	}
+	if err := ddl.WriteTables(&out, diff.AddedTables, ddlContent); err != nil {
+		return "", err
+	}
+	for _, fk := range diff.AddedForeignKeys {
+		out.WriteString(addForeignKeySQL(newSpellings, fk))
+	}
+	return out.String(), nil
+}
+
+// 外部キーを追加する ALTER TABLE 文
+func addForeignKeySQL(spellings ddl.Spellings, fk ddl.ForeignKey) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ALTER TABLE %s ADD ", quoteTable(spellings, fk.Table))
+	if fk.Name != "" {
+		fmt.Fprintf(&b, "CONSTRAINT %s ", fk.Name)
+	}
+	fmt.Fprintf(&b, "FOREIGN KEY (%s) REFERENCES %s", strings.Join(fk.Columns, ", "), quoteTable(spellings, fk.RefTable))
+	if len(fk.RefColumns) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(fk.RefColumns, ", "))
+	}
+	if fk.OnDelete != "" {
+		b.WriteString(" ON DELETE " + fk.OnDelete)
+	}
+	if fk.OnUpdate != "" {
+		b.WriteString(" ON UPDATE " + fk.OnUpdate)
+	}
+	if fk.Deferrable {
+		b.WriteString(" DEFERRABLE")
+		if fk.InitiallyDeferred {
+			b.WriteString(" INITIALLY DEFERRED")
+		}
+	}
+	if fk.NotValid {
+		b.WriteString(" NOT VALID")
+	}
+	b.WriteString(";\n")
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 作成順の逆順にテーブルを削除するDDLを書き出す
+func writeDropDDL(src, outputPath string, sortedTables []string) error {
+	opts := ddl.Options{Dialect: dialect}
+	fks, err := ddl.ForeignKeysWithOptions(strings.NewReader(src), opts)
+	if err != nil {
+		return err
+	}
+	spellings := ddl.TableSpellings(src, opts)
+
+	// テーブルごとの参照先と、そのうち ON DELETE CASCADE の参照先
+	refs := make(map[string][]string)
+	cascades := make(map[string][]string)
+	for _, fk := range fks {
+		if fk.RefTable == fk.Table {
+			continue
+		}
+		refs[fk.Table] = append(refs[fk.Table], fk.RefTable)
+		if fk.OnDelete == "CASCADE" {
+			cascades[fk.Table] = append(cascades[fk.Table], fk.RefTable)
+		}
+	}
+
+	var out strings.Builder
+	for i := len(sortedTables) - 1; i >= 0; i-- {
+		table := sortedTables[i]
+		// すべての参照先が ON DELETE CASCADE の場合、親の行を消せば子の行も消えるため個別の削除は不要
+		if len(refs[table]) > 0 && len(cascades[table]) == len(refs[table]) {
+			parents := uniqueSorted(cascades[table])
+			fmt.Fprintf(&out, "-- orderddl: %s の行は %s の削除に連動して削除されます (ON DELETE CASCADE)\n", table, strings.Join(parents, ", "))
+		}
+		fmt.Fprintf(&out, "DROP TABLE IF EXISTS %s;\n", quoteTable(spellings, table))
+	}
+
+	if err := writeEncoded(outputPath, out.String()); err != nil {
+		return err
+	}
+	fmt.Println("✅ 削除順のDDLを出力しました:", outputPath)
+	return nil
+}
+
+// テーブル名を入力での書き方に合わせて -dialect の引用符で囲む（-dialect がない場合は入力の引用符で、予約語などの部分だけを囲む）
+func quoteTable(spellings ddl.Spellings, table string) string {
+	return spellings.Quote(dialect, table)
+}
+
+// 重複を除いて並べ替えたスライスを返す
+func uniqueSorted(list []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, v := range list {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 入出力の文字コード
+type textEncoding struct {
+	name string
+	enc  encoding.Encoding
+	// 出力の先頭に付ける BOM
+	bom []byte
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// 名前から文字コードを返す
+func lookupEncoding(name string) (textEncoding, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "utf-8", "utf8":
+		return textEncoding{name: "utf-8", enc: unicode.UTF8}, nil
+	case "utf-8-bom", "utf8-bom":
+		return textEncoding{name: "utf-8-bom", enc: unicode.UTF8, bom: bomUTF8}, nil
+	case "utf-16le":
+		return textEncoding{name: "utf-16le", enc: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), bom: bomUTF16LE}, nil
+	case "utf-16be":
+		return textEncoding{name: "utf-16be", enc: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), bom: bomUTF16BE}, nil
+	case "shift-jis", "sjis", "cp932", "windows-31j":
+		return textEncoding{name: "shift_jis", enc: japanese.ShiftJIS}, nil
+	case "euc-jp", "eucjp":
+		return textEncoding{name: "euc-jp", enc: japanese.EUCJP}, nil
+	}
+	return textEncoding{}, fmt.Errorf("不明な文字コードです: %s", name)
+}
+
+// 入力の文字コードを判定する（BOM、UTF-16 の NUL バイト、UTF-8 として正しいかどうかの順に調べる）
+func detectEncoding(content []byte) textEncoding {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		enc, _ := lookupEncoding("utf-8-bom")
+		return enc
+	case bytes.HasPrefix(content, bomUTF16LE):
+		enc, _ := lookupEncoding("utf-16le")
+		return enc
+	case bytes.HasPrefix(content, bomUTF16BE):
+		enc, _ := lookupEncoding("utf-16be")
+		return enc
+	}
+
+	// BOM のない UTF-16 は ASCII の文字の片側が NUL になる
+	var evenNUL, oddNUL int
+	for i, b := range content[:min(len(content), 4096)] {
+		if b == 0 {
+			if i%2 == 0 {
+				evenNUL++
+			} else {
+				oddNUL++
+			}
+		}
+	}
+	if half := min(len(content), 4096) / 2; half > 0 {
+		switch {
+		case oddNUL > half/2:
+			enc, _ := lookupEncoding("utf-16le")
+			enc.bom = nil
+			return enc
+		case evenNUL > half/2:
+			enc, _ := lookupEncoding("utf-16be")
+			enc.bom = nil
+			return enc
+		}
+	}
+
+	if utf8.Valid(content) {
+		enc, _ := lookupEncoding("utf-8")
+		return enc
+	}
+	enc, _ := lookupEncoding("shift_jis")
+	return enc
+}
+
+// 入力を文字コードに従って文字列に変換する。name が auto の場合は判定する
+func decodeInput(content []byte, name string) (string, textEncoding, error) {
+	enc := detectEncoding(content)
+	if name != "auto" {
+		var err error
+		if enc, err = lookupEncoding(name); err != nil {
+			return "", textEncoding{}, err
+		}
+	}
+	// BOM は文字列に含めない
+	switch enc.name {
+	case "utf-8", "utf-8-bom":
+		content = bytes.TrimPrefix(content, bomUTF8)
+	case "utf-16le":
+		content = bytes.TrimPrefix(content, bomUTF16LE)
+	case "utf-16be":
+		content = bytes.TrimPrefix(content, bomUTF16BE)
+	}
+
+	// 正しい UTF-8 は変換しても変わらないため、大きな入力をもう一度コピーしない
+	if enc.enc == unicode.UTF8 && utf8.Valid(content) {
+		return string(content), enc, nil
+	}
+	decoded, err := enc.enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return "", textEncoding{}, fmt.Errorf("%s として読み込めませんでした: %w", enc.name, err)
+	}
+	return string(decoded), enc, nil
+}
+
+// 出力の文字コードを決める。name が input の場合は入力と同じにする
+func outputEncodingFor(name string, input textEncoding) (textEncoding, error) {
+	if name == "input" {
+		return input, nil
+	}
+	return lookupEncoding(name)
+}
+
+// 文字列を文字コードに従って変換する
+func (e textEncoding) encode(s string) ([]byte, error) {
+	encoded, err := e.enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("%s に変換できない文字があります: %w", e.name, err)
+	}
+	return append(append([]byte{}, e.bom...), encoded...), nil
+}
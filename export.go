@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// グラフをJSONで出力する際の形式
+type graphExport struct {
+	// 入力に現れた順のテーブル
+	Tables []string `json:"tables"`
+	// テーブルの作成順序（循環依存がある場合は空）
+	Order       []string         `json:"order,omitempty"`
+	ForeignKeys []ddl.ForeignKey `json:"foreign_keys"`
+	Cycles      [][]string       `json:"cycles,omitempty"`
+}
+
+// テーブルと外部キーの依存関係をJSONで書き出す。作成順序は -format sql と同じ AnalyzeContext の結果を使う
+func exportGraphJSON(src, outputPath string, result *ddl.Result, opts ddl.Options) error {
+	fks, err := ddl.ForeignKeysWithOptions(strings.NewReader(src), opts)
+	if err != nil {
+		return err
+	}
+
+	export := graphExport{Tables: result.Tables, ForeignKeys: fks, Order: result.Sorted, Cycles: result.Cycles}
+	if export.Tables == nil {
+		export.Tables = []string{}
+	}
+	if export.ForeignKeys == nil {
+		export.ForeignKeys = []ddl.ForeignKey{}
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+
+	fmt.Println("✅ 依存関係をJSONで出力しました:", outputPath)
+	return nil
+}
+
+// 循環依存しているテーブルを1つのノードにまとめたグラフをJSONで書き出す
+func exportCondensedJSON(outputPath string, graph map[string][]string, tableOrder []string) error {
+	data, err := json.MarshalIndent(ddl.Condense(tableOrder, graph), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+
+	fmt.Println("✅ 循環依存をまとめたグラフをJSONで出力しました:", outputPath)
+	return nil
+}
+
+// 依存関係を child,parent,constraint の行の CSV で書き出す（constraint は外部キーの制約名、名前のない外部キーやその他の依存関係では空）
+func exportEdgesCSV(outputPath string, edges []ddl.Edge) error {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"child", "parent", "constraint"})
+	for _, edge := range edges {
+		w.Write([]string{edge.Child, edge.Parent, edge.ForeignKey.Name})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("書き込みに失敗しました: %w", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+
+	fmt.Println("✅ 依存関係をCSVで出力しました:", outputPath)
+	return nil
+}
+
+// 段ごとの適用計画をJSONで書き出す
+func writePlan(src, outputPath string, graph map[string][]string, sortedTables []string) error {
+	ddlContent, err := splitDDL(src, graph, sortedTables)
+	if err != nil {
+		return err
+	}
+	plan, _ := ddl.BuildPlanWithOptions(sortedTables, graph, ddlContent, splitOptions())
+
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+
+	fmt.Println("✅ 適用計画をJSONで出力しました:", outputPath)
+	return nil
+}
+
+// テーブルごとの入力での範囲と出力での位置をJSONで書き出す
+func writeSourceMap(src, outputPath string, sortedTables []string, ddlContent map[string]string) error {
+	entries, err := ddl.SourceMap(src, sortedTables, ddlContent)
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []ddl.SourceMapEntry{}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+
+	fmt.Println("✅ ソースマップをJSONで出力しました:", outputPath)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 循環依存している a と b、a に依存する c
+const exportSchema = "CREATE TABLE c (id int, a_id int REFERENCES a(id));\n" +
+	"CREATE TABLE a (id int PRIMARY KEY, b_id int REFERENCES b(id));\n" +
+	"CREATE TABLE b (id int PRIMARY KEY, a_id int REFERENCES a(id));\n"
+
+func TestExportCondensedJSON(t *testing.T) {
+	graph, _, tableOrder, err := ddl.Parse(strings.NewReader(exportSchema), ddl.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "condensed.json")
+	if err := exportCondensedJSON(output, graph, tableOrder); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ddl.Condensation
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Nodes) != 2 || got.Nodes[0].Name != "a+b" || got.Nodes[0].Level != 0 || got.Nodes[1].Name != "c" || got.Nodes[1].Level != 1 {
+		t.Errorf("Nodes = %+v", got.Nodes)
+	}
+	if len(got.Edges) != 1 || got.Edges[0] != (ddl.CondensedEdge{Parent: "a+b", Child: "c"}) {
+		t.Errorf("Edges = %+v", got.Edges)
+	}
+}
+
+func TestExportEdgesCSV(t *testing.T) {
+	src := "CREATE TABLE p (id int PRIMARY KEY);\nCREATE TABLE c (id int, p_id int, CONSTRAINT fk_c_p FOREIGN KEY (p_id) REFERENCES p(id));\nCREATE TABLE d (c_id int REFERENCES c(id));\n"
+	_, edges, err := ddl.Edges(strings.NewReader(src), ddl.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "deps.csv")
+	if err := exportEdgesCSV(output, edges); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "child,parent,constraint\nc,p,fk_c_p\nd,c,\n"; string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+
+	// 出力した CSV はそのまま -deps-file に使える
+	deps, err := ddl.ReadDependencies(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, dep := range deps {
+		got = append(got, dep.String())
+	}
+	if strings.Join(got, ",") != "c:p,d:c" {
+		t.Errorf("ReadDependencies = %v, want [c:p d:c]", got)
+	}
+}
+
+func TestExportGraphJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		order  []string
+		cycles int
+	}{
+		{name: "作成順序", src: "CREATE TABLE b (a_id int REFERENCES a(id));\nCREATE TABLE a (id int PRIMARY KEY);\n", order: []string{"a", "b"}},
+		{name: "循環依存", src: exportSchema, cycles: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ddl.Analyze(tt.src, ddl.Options{})
+			if result == nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(t.TempDir(), "graph.json")
+			if err := exportGraphJSON(tt.src, output, result, ddl.Options{}); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got graphExport
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got.Order, ",") != strings.Join(tt.order, ",") || len(got.Cycles) != tt.cycles {
+				t.Errorf("order = %q, cycles = %q", got.Order, got.Cycles)
+			}
+		})
+	}
+}
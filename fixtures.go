@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// YAML で引用符なしに書けるテーブル名
+var rePlainYAML = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// fixtures サブコマンドを実行する
+func runFixtures(args []string) error {
+	fs := flag.NewFlagSet("fixtures", flag.ExitOnError)
+	format := fs.String("format", "text", "出力形式（text, yaml）")
+	reverse := fs.Bool("reverse", false, "削除できる順（作成順の逆順）に出力する（text の場合）")
+	out := fs.String("o", "", "出力先（空の場合は標準出力）")
+	rest := parseInterspersed(fs, args)
+	if len(rest) != 1 {
+		return errors.New("使い方: orderddl fixtures [-format text|yaml] [-reverse] [-o 出力先] <schema.sql>")
+	}
+
+	content, err := os.ReadFile(rest[0])
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	result, err := ddl.Analyze(string(content), ddl.Options{})
+	if err != nil {
+		return err
+	}
+	insert := result.Sorted
+	remove := slices.Clone(insert)
+	slices.Reverse(remove)
+
+	var b strings.Builder
+	switch *format {
+	case "text":
+		list := insert
+		if *reverse {
+			list = remove
+		}
+		for _, table := range list {
+			b.WriteString(table + "\n")
+		}
+	case "yaml":
+		// テストデータを読み込む順と、削除する順
+		b.WriteString("insert:\n")
+		writeYAMLList(&b, insert)
+		b.WriteString("delete:\n")
+		writeYAMLList(&b, remove)
+	default:
+		return fmt.Errorf("不明な出力形式です: %s", *format)
+	}
+
+	if *out == "" {
+		fmt.Print(b.String())
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ テストデータを読み込む順序を出力しました:", *out)
+	return nil
+}
+
+// YAML のリストを書き出す
+func writeYAMLList(b *strings.Builder, list []string) {
+	if len(list) == 0 {
+		b.WriteString("  []\n")
+		return
+	}
+	for _, v := range list {
+		if !rePlainYAML.MatchString(v) {
+			v = strconv.Quote(v)
+		}
+		b.WriteString("  - " + v + "\n")
+	}
+}
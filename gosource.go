@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	goformat "go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 作成順と削除順（作成順の逆順）のテーブル名の一覧を定義する Go のソースファイルを書き出す
+func exportGoSource(outputPath, pkg string, sortedTables []string) error {
+	if pkg == "" {
+		pkg = goPackageName(outputPath)
+	}
+	if !token.IsIdentifier(pkg) {
+		return fmt.Errorf("Go のパッケージ名として使えません: %s", pkg)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by orderddl. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("// TableOrder はテーブルを作成できる順（テストデータを入れる順）\n")
+	writeGoStrings(&b, "TableOrder", sortedTables)
+	b.WriteString("\n// ReverseTableOrder はテーブルを削除できる順（TRUNCATE する順）\n")
+	reverse := make([]string, len(sortedTables))
+	for i, table := range sortedTables {
+		reverse[len(sortedTables)-1-i] = table
+	}
+	writeGoStrings(&b, "ReverseTableOrder", reverse)
+
+	source, err := goformat.Source([]byte(b.String()))
+	if err != nil {
+		return fmt.Errorf("Go のソースを整形できませんでした: %w", err)
+	}
+	if err := os.WriteFile(outputPath, source, 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ テーブルの作成順序を Go のソースで出力しました:", outputPath)
+	return nil
+}
+
+// 文字列のスライスの変数を定義する
+func writeGoStrings(b *strings.Builder, name string, values []string) {
+	fmt.Fprintf(b, "var %s = []string{\n", name)
+	for _, value := range values {
+		fmt.Fprintf(b, "\t%s,\n", strconv.Quote(value))
+	}
+	b.WriteString("}\n")
+}
+
+// 出力先のディレクトリの名前を Go のパッケージ名にする（使えない名前の場合は schema）
+func goPackageName(outputPath string) string {
+	abs, err := filepath.Abs(outputPath)
+	if err != nil {
+		return "schema"
+	}
+	name := strings.ToLower(strings.NewReplacer("-", "", ".", "").Replace(filepath.Base(filepath.Dir(abs))))
+	if !token.IsIdentifier(name) {
+		return "schema"
+	}
+	return name
+}
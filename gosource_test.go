@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportGoSource(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "db-schema")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "order.go")
+	if err := exportGoSource(output, "", []string{"users", "app.orders"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by orderddl. DO NOT EDIT.
+
+package dbschema
+
+// TableOrder はテーブルを作成できる順（テストデータを入れる順）
+var TableOrder = []string{
+	"users",
+	"app.orders",
+}
+
+// ReverseTableOrder はテーブルを削除できる順（TRUNCATE する順）
+var ReverseTableOrder = []string{
+	"app.orders",
+	"users",
+}
+`
+	if string(data) != want {
+		t.Errorf("Go のソース =\n%s\nwant\n%s", data, want)
+	}
+
+	if err := exportGoSource(output, "not-a-package", nil); err == nil {
+		t.Error("パッケージ名として使えない名前でエラーになりませんでした")
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"internal/dbschema/order.go", "dbschema"},
+		{"gen/My.Tables/order.go", "mytables"},
+		{"gen/1st/order.go", "schema"},
+	}
+	for _, tt := range tests {
+		if got := goPackageName(tt.path); got != tt.want {
+			t.Errorf("goPackageName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ba58ajbse/orderddl/api/orderddlpb"
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// orderddlpb.OrderDDLServer の実装
+type grpcServer struct {
+	orderddlpb.UnimplementedOrderDDLServer
+}
+
+// gRPCサーバーを起動する（認証・メッセージの大きさ・リクエスト数は HTTP と同じ limits で制限し、
+// リクエスト数と並び替えの時間は HTTP と同じ metrics に記録する）
+func serveGRPC(ctx context.Context, listen string, limits *serveLimits) error {
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("gRPCサーバーを起動できませんでした: %w", err)
+	}
+
+	maxRecv := math.MaxInt32
+	if limits.maxBody > 0 {
+		maxRecv = int(min(limits.maxBody, math.MaxInt32))
+	}
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxRecv),
+		grpc.ChainUnaryInterceptor(metrics.unaryInterceptor, limits.authInterceptor, limits.rateLimitInterceptor),
+	)
+	orderddlpb.RegisterOrderDDLServer(server, &grpcServer{})
+
+	// ctx が取り消されたら処理中のリクエストを終えてから止める
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	fmt.Println("🚀 gRPCサーバーを起動しました:", listen)
+	return server.Serve(lis)
+}
+
+// authorization メタデータの Bearer トークンを確かめる
+func (l *serveLimits) authInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	authorization := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	if !l.authorizedBy(authorization) {
+		return nil, status.Error(codes.Unauthenticated, "authorization メタデータに Bearer でトークンを指定してください")
+	}
+	return handler(ctx, req)
+}
+
+// クライアント（送信元の IP アドレス）ごとのリクエスト数を制限する
+func (l *serveLimits) rateLimitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host
+		}
+	}
+	if wait, ok := l.allow(client, time.Now()); !ok {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+		return nil, status.Error(codes.ResourceExhausted, "リクエストが多すぎます。しばらくしてから再度実行してください")
+	}
+	return handler(ctx, req)
+}
+
+func (s *grpcServer) OrderSchema(ctx context.Context, req *orderddlpb.OrderSchemaRequest) (*orderddlpb.OrderSchemaResponse, error) {
+	opts, err := grpcOptions(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := ddl.AnalyzeContext(ctx, req.GetSql(), opts)
+	if err != nil {
+		metrics.observeOrder(len(req.GetSql()), start, err)
+		if errors.Is(err, ddl.ErrCycle) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ordered, err := ddl.OrderResult(ctx, req.GetSql(), result, opts)
+	metrics.observeOrder(len(req.GetSql()), start, err)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &orderddlpb.OrderSchemaResponse{Sql: ordered, Tables: result.Sorted}, nil
+}
+
+func (s *grpcServer) GetGraph(ctx context.Context, req *orderddlpb.GetGraphRequest) (*orderddlpb.GetGraphResponse, error) {
+	opts, err := grpcOptions(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+	_, _, tableOrder, err := ddl.Parse(strings.NewReader(req.GetSql()), opts)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	fks, err := ddl.ForeignKeysWithOptions(strings.NewReader(req.GetSql()), opts)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp := &orderddlpb.GetGraphResponse{Tables: tableOrder}
+	for _, fk := range fks {
+		resp.Edges = append(resp.Edges, &orderddlpb.Edge{
+			Child:             fk.Table,
+			Parent:            fk.RefTable,
+			Constraint:        fk.Name,
+			Columns:           fk.Columns,
+			RefColumns:        fk.RefColumns,
+			OnDelete:          fk.OnDelete,
+			OnUpdate:          fk.OnUpdate,
+			NotValid:          fk.NotValid,
+			NotEnforced:       fk.NotEnforced,
+			Deferrable:        fk.Deferrable,
+			InitiallyDeferred: fk.InitiallyDeferred,
+		})
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) Validate(ctx context.Context, req *orderddlpb.ValidateRequest) (*orderddlpb.ValidateResponse, error) {
+	opts, err := grpcOptions(req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+	// OrderSchema と同じく、DEFERRABLE な外部キーで解消できる循環依存は並び替えられるものとする
+	result, err := ddl.AnalyzeContext(ctx, req.GetSql(), opts)
+	if err != nil && !errors.Is(err, ddl.ErrCycle) {
+		return &orderddlpb.ValidateResponse{Errors: []string{err.Error()}}, nil
+	}
+
+	resp := &orderddlpb.ValidateResponse{}
+	var undefined []string
+	for _, edge := range result.Edges {
+		if !slices.Contains(result.Tables, edge.Parent) && !slices.Contains(undefined, edge.Parent) {
+			undefined = append(undefined, edge.Parent)
+		}
+	}
+	sort.Strings(undefined)
+	for _, parent := range undefined {
+		resp.Errors = append(resp.Errors, "参照先のテーブルが定義されていません: "+parent)
+	}
+	if len(resp.Errors) == 0 && err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+	resp.Valid = len(resp.Errors) == 0
+	return resp, nil
+}
+
+// リクエストのオプションを ddl.Options にする（不正な指定は InvalidArgument）
+func grpcOptions(o *orderddlpb.Options) (ddl.Options, error) {
+	opts, err := requestOptions{
+		SoftConstraints: o.GetSoftConstraints(),
+		Dialect:         o.GetDialect(),
+		SearchPath:      o.GetSearchPath(),
+		Views:           o.GetViews(),
+		Temporary:       o.GetTemporary(),
+	}.options()
+	if err != nil {
+		return ddl.Options{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return opts, nil
+}
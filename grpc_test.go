@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ba58ajbse/orderddl/api/orderddlpb"
+)
+
+// メモリ上の接続で gRPC サーバーを起動し、クライアントを返す
+func newGRPCClient(t *testing.T, server *grpc.Server) orderddlpb.OrderDDLClient {
+	t.Helper()
+	orderddlpb.RegisterOrderDDLServer(server, &grpcServer{})
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return orderddlpb.NewOrderDDLClient(conn)
+}
+
+const (
+	grpcSchema = "CREATE TABLE b (\n  a_id int,\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\nCREATE TABLE a (\n  id int\n);\n"
+	grpcCyclic = "CREATE TABLE a (\n  FOREIGN KEY (b_id) REFERENCES b(id)\n);\nCREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n"
+)
+
+func TestGRPCOrderSchema(t *testing.T) {
+	client := newGRPCClient(t, grpc.NewServer())
+	ctx := context.Background()
+
+	resp, err := client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{Sql: grpcSchema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(resp.GetTables(), ","); got != "a,b" {
+		t.Errorf("Tables = %s, want a,b", got)
+	}
+	if !strings.HasPrefix(resp.GetSql(), "CREATE TABLE a") {
+		t.Errorf("Sql =\n%s", resp.GetSql())
+	}
+
+	_, err = client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{Sql: grpcCyclic})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("循環依存の err = %v, want FailedPrecondition", err)
+	}
+}
+
+func TestGRPCGetGraph(t *testing.T) {
+	client := newGRPCClient(t, grpc.NewServer())
+	resp, err := client.GetGraph(context.Background(), &orderddlpb.GetGraphRequest{Sql: grpcSchema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(resp.GetTables(), ","); got != "b,a" {
+		t.Errorf("Tables = %s, want b,a", got)
+	}
+	if edges := resp.GetEdges(); len(edges) != 1 || edges[0].GetChild() != "b" || edges[0].GetParent() != "a" {
+		t.Errorf("Edges = %v, want b → a", edges)
+	}
+}
+
+func TestGRPCValidate(t *testing.T) {
+	client := newGRPCClient(t, grpc.NewServer())
+	tests := []struct {
+		name   string
+		sql    string
+		errors int
+	}{
+		{name: "並び替えられる", sql: grpcSchema},
+		{name: "循環依存", sql: grpcCyclic, errors: 1},
+		{name: "DEFERRABLE な外部キーで解消できる循環依存", sql: "CREATE TABLE a (\n  FOREIGN KEY (b_id) REFERENCES b(id) DEFERRABLE INITIALLY DEFERRED\n);\nCREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n"},
+		{name: "参照先がない", sql: "CREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n", errors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Validate(context.Background(), &orderddlpb.ValidateRequest{Sql: tt.sql})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.GetValid() != (tt.errors == 0) || len(resp.GetErrors()) != tt.errors {
+				t.Errorf("Valid = %v, Errors = %q", resp.GetValid(), resp.GetErrors())
+			}
+		})
+	}
+}
+
+func TestGRPCLimits(t *testing.T) {
+	limits := &serveLimits{tokens: []string{"secret"}, maxBody: 1 << 10, rate: 1, burst: 2}
+	client := newGRPCClient(t, grpc.NewServer(
+		grpc.MaxRecvMsgSize(int(limits.maxBody)),
+		grpc.ChainUnaryInterceptor(limits.authInterceptor, limits.rateLimitInterceptor),
+	))
+	req := &orderddlpb.OrderSchemaRequest{Sql: grpcSchema}
+
+	if _, err := client.OrderSchema(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("トークンなしの err = %v, want Unauthenticated", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.OrderSchema(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	large := &orderddlpb.OrderSchemaRequest{Sql: strings.Repeat("-- padding\n", 200) + grpcSchema}
+	if _, err := client.OrderSchema(ctx, large); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("大きすぎるメッセージの err = %v, want ResourceExhausted", err)
+	}
+	// 受け取る前に断ったメッセージと認証できなかったリクエストは数えない
+	if _, err := client.OrderSchema(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	var header metadata.MD
+	if _, err := client.OrderSchema(ctx, req, grpc.Header(&header)); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("3 回目の err = %v, want ResourceExhausted", err)
+	}
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("retry-after = %v, want [1]", got)
+	}
+}
+
+func TestGRPCOptions(t *testing.T) {
+	client := newGRPCClient(t, grpc.NewServer())
+	ctx := context.Background()
+
+	resp, err := client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{
+		Sql:     "CREATE TEMPORARY TABLE t (id int);\nCREATE TABLE app.orders (id int, u int REFERENCES users(id));\nCREATE TABLE app.users (id int);\n",
+		Options: &orderddlpb.Options{Dialect: "postgres", SearchPath: []string{"app"}, Temporary: "exclude"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(resp.GetTables(), ","); got != "app.users,app.orders" {
+		t.Errorf("Tables = %s, want app.users,app.orders", got)
+	}
+	if strings.Contains(resp.GetSql(), "TEMPORARY") {
+		t.Errorf("一時テーブルが除かれていません:\n%s", resp.GetSql())
+	}
+
+	_, err = client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{Sql: grpcSchema, Options: &orderddlpb.Options{Dialect: "unknown"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("不明な方言の err = %v, want InvalidArgument", err)
+	}
+}
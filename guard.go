@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPサーバーモードのリクエストの制限
+type serveLimits struct {
+	// 受け付けるトークン（空の場合は認証しない）
+	tokens []string
+	// 本文の最大のバイト数（0 の場合は制限しない）
+	maxBody int64
+	// クライアントごとの1秒あたりのリクエスト数と、続けて受け付けられる数（rate が 0 の場合は制限しない）
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+// クライアントごとの、受け付けられる残りのリクエスト数
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// トークンのファイル（1行に1つ、空行と # で始まる行は無視する）を読む
+func readTokens(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("トークンのファイルを開けませんでした: %w", err)
+	}
+	defer f.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("トークンのファイルを読み込めませんでした: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("トークンのファイルにトークンがありません: %s", path)
+	}
+	return tokens, nil
+}
+
+// 認証・本文の大きさ・リクエスト数の制限を行ってから next に渡す
+func (l *serveLimits) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="orderddl"`)
+			writeJSON(w, http.StatusUnauthorized, orderResponse{Error: "Authorization: Bearer でトークンを指定してください"})
+			return
+		}
+		if wait, ok := l.allow(clientAddress(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeJSON(w, http.StatusTooManyRequests, orderResponse{Error: "リクエストが多すぎます。しばらくしてから再度実行してください"})
+			return
+		}
+		if l.maxBody > 0 {
+			if r.ContentLength > l.maxBody {
+				writeJSON(w, http.StatusRequestEntityTooLarge, orderResponse{Error: fmt.Sprintf("本文が大きすぎます（最大 %d バイト）", l.maxBody)})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, l.maxBody)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// リクエストのトークンが受け付けるトークンのいずれかと一致するか
+func (l *serveLimits) authorized(r *http.Request) bool {
+	return l.authorizedBy(r.Header.Get("Authorization"))
+}
+
+// Authorization の値（Bearer トークン）が受け付けるトークンのいずれかと一致するか
+func (l *serveLimits) authorizedBy(authorization string) bool {
+	if len(l.tokens) == 0 {
+		return true
+	}
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	token = strings.TrimSpace(token)
+	authorized := false
+	// 一致したトークンが時間の違いでわからないよう、すべてのトークンと比べる
+	for _, t := range l.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// クライアントのリクエストを受け付けられるかどうかと、受け付けられない場合は次に受け付けられるまでの時間を返す
+func (l *serveLimits) allow(client string, now time.Time) (time.Duration, bool) {
+	if l.rate <= 0 {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	// しばらくリクエストのないクライアントは満杯に戻っているため忘れる
+	if now.Sub(l.swept) > time.Minute {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.last).Seconds()*l.rate >= float64(l.burst) {
+				delete(l.buckets, key)
+			}
+		}
+		l.swept = now
+	}
+
+	bucket, exists := l.buckets[client]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(float64(l.burst), bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second)), false
+	}
+	bucket.tokens--
+	return 0, true
+}
+
+// リクエストの送信元の IP アドレス（プロキシのヘッダーは偽装できるため使わない）
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// 待ち受けるアドレスのホストがループバックアドレスか
+func loopbackAddress(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	return err == nil && isLoopback(host)
+}
+
+// 待ち受けるホストがループバックアドレスか（localhost からしか接続できないか）
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// 本文の読み込みのエラーのステータス（大きすぎる場合は 413）
+func requestErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
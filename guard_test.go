@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeLimitsAuthorization(t *testing.T) {
+	limits := &serveLimits{tokens: []string{"first", "second"}}
+	handler := limits.wrap(http.HandlerFunc(handleOrder))
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "トークンなし", status: http.StatusUnauthorized},
+		{name: "一致しないトークン", authorization: "Bearer third", status: http.StatusUnauthorized},
+		{name: "Bearer 以外", authorization: "Basic second", status: http.StatusUnauthorized},
+		{name: "2つ目のトークン", authorization: "bearer second", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader("CREATE TABLE a (id int);\n"))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate がありません")
+			}
+		})
+	}
+}
+
+func TestServeLimitsMaxBody(t *testing.T) {
+	handler := (&serveLimits{maxBody: 16}).wrap(http.HandlerFunc(handleOrder))
+	body := "CREATE TABLE a (id int);\n"
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(body)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Content-Length が大きすぎる場合の status = %d, want 413", w.Code)
+	}
+
+	// Content-Length のないリクエストは読み込んだ大きさで判断する
+	req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(body))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Content-Length のない場合の status = %d, want 413", w.Code)
+	}
+}
+
+func TestServeLimitsRate(t *testing.T) {
+	limits := &serveLimits{rate: 1, burst: 2}
+	now := time.Now()
+	for i := range 2 {
+		if _, ok := limits.allow("10.0.0.1", now); !ok {
+			t.Fatalf("%d 回目のリクエストを受け付けませんでした", i+1)
+		}
+	}
+	wait, ok := limits.allow("10.0.0.1", now)
+	if ok || wait != time.Second {
+		t.Errorf("3 回目 = %v, %v, want 1s, false", wait, ok)
+	}
+	if _, ok := limits.allow("10.0.0.2", now); !ok {
+		t.Error("別のクライアントのリクエストを受け付けませんでした")
+	}
+	if _, ok := limits.allow("10.0.0.1", now.Add(time.Second)); !ok {
+		t.Error("1秒後のリクエストを受け付けませんでした")
+	}
+}
+
+func TestReadTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte("# コメント\nfirst\n\n  second  \n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := readTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tokens, ",") != "first,second" {
+		t.Errorf("readTokens = %v, want [first second]", tokens)
+	}
+
+	if err := os.WriteFile(path, []byte("# コメントだけ\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readTokens(path); err == nil {
+		t.Error("トークンのないファイルでエラーになりませんでした")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// errLintIssues は lint で問題が見つかったことを表す（メッセージは表示済み）
+var errLintIssues = errors.New("lint で問題が見つかりました")
+
+// lint サブコマンドを実行する
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	enable := fs.String("enable", "", "使う規則（カンマ区切り、空の場合はすべて）")
+	disable := fs.String("disable", "", "使わない規則（カンマ区切り）")
+	format := fs.String("format", "text", "出力形式（text, json）")
+	dialectName := fs.String("dialect", "", "方言（mysql, postgres など。外部キーの参照先に必要なキーの判断に使う）")
+	list := fs.Bool("list", false, "規則の一覧を表示する")
+	fs.Parse(args)
+
+	if *list {
+		for _, rule := range ddl.LintRules {
+			fmt.Printf("%-28s %s\n", rule.Name, rule.Description)
+		}
+		return nil
+	}
+	if fs.NArg() != 1 {
+		return errors.New("使い方: orderddl lint [-enable 規則,...] [-disable 規則,...] [-dialect 方言] [-format text|json] <schema.sql>")
+	}
+
+	enabled, err := ddl.LintRuleSet(*enable, *disable)
+	if err != nil {
+		return err
+	}
+	var opts ddl.Options
+	if *dialectName != "" {
+		if opts.Dialect, err = ddl.LookupDialect(*dialectName); err != nil {
+			return err
+		}
+	}
+	content, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	tables, err := ddl.TablesWithOptions(strings.NewReader(string(content)), opts)
+	if err != nil {
+		return err
+	}
+	issues := ddl.Lint(tables, enabled, opts)
+
+	switch *format {
+	case "text":
+		for _, issue := range issues {
+			fmt.Printf("⚠️ %s:%d: [%s] %s\n", fs.Arg(0), issue.Line, issue.Rule, issue.Message)
+		}
+		if len(issues) == 0 {
+			fmt.Println("✅ 問題は見つかりませんでした")
+		}
+	case "json":
+		if issues == nil {
+			issues = []ddl.LintIssue{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			return fmt.Errorf("書き込みに失敗しました: %w", err)
+		}
+	default:
+		return fmt.Errorf("不明な出力形式です: %s", *format)
+	}
+
+	if len(issues) > 0 {
+		return errLintIssues
+	}
+	return nil
+}
@@ -1,185 +1,1020 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"regexp"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
-)
+	"syscall"
+	"time"
 
-const (
-	TABLE_PATTERN      = `(?i)CREATE TABLE ` + "`?" + `(\w+)` + "`?"
-	REFERENCES_PATTERN = `(?i)REFERENCES ` + "`?" + `(\w+)` + "`?"
+	"github.com/ba58ajbse/orderddl/ddl"
 )
 
 var (
-	input  = flag.String("i", "", "")
-	output = flag.String("o", "output.sql", "")
+	input       = flag.String("i", "", "")
+	output      = flag.String("o", "output.sql", "")
+	schemaDir   = flag.String("schema-dir", "", "スキーマごとのDDLを書き出すディレクトリ（-o にはそれらを読み込む順のクライアントのコマンドを書き出す。-dialect mysql / tidb では SOURCE、snowflake では SnowSQL の !source、それ以外は psql の \\ir）")
+	components  = flag.Bool("components", false, "依存関係でつながるテーブルのまとまり（互いに依存しないため別々に作成できる）を表示する")
+	compDir     = flag.String("component-dir", "", "依存関係でつながるテーブルのまとまりごとに、作成順に並べたDDLを書き出すディレクトリ（-o への出力に加えて書き出す）")
+	watch       = flag.Bool("watch", false, "入力ファイルの変更を監視して再出力する")
+	format      = flag.String("format", "sql", "出力形式（sql, json, condensed, csv, markdown, go。condensed では循環依存しているテーブルを1つにまとめたグラフをJSONで、csv では依存関係を child,parent,constraint の行で、markdown では作成順序・依存関係・循環依存のレポートを、go では作成順と逆順のテーブル名の一覧を定義する Go のソースを出力する）")
+	tmplFile    = flag.String("template", "", "解析したスキーマ（Tables, Order, Edges, Levels）を渡して出力を作る Go の text/template のファイル（指定した場合は -o にテンプレートの結果を出力する）")
+	goPackage   = flag.String("go-package", "", "-format go で出力するソースのパッケージ名（空の場合は出力先のディレクトリの名前）")
+	dropOut     = flag.String("drop-out", "", "作成順の逆順にテーブルを削除するDDLの出力先")
+	softDeps    = flag.String("soft-constraints", "order", "NOT VALID / NOT ENFORCED の外部キーの扱い（order, warn, ignore）")
+	idempotent  = flag.Bool("verify-idempotent", false, "出力をもう一度並び替えても変わらないことを確認する")
+	checksum    = flag.Bool("print-checksum", false, "並び替えたDDLのチェックサムを表示する")
+	planFormat  = flag.String("plan-format", "", "適用計画の出力形式（json、空の場合は出力しない）")
+	planOut     = flag.String("plan-out", "plan.json", "適用計画の出力先")
+	inputEnc    = flag.String("encoding", "auto", "入力の文字コード（auto, utf-8, utf-8-bom, utf-16le, utf-16be, shift_jis, euc-jp）")
+	outputEnc   = flag.String("output-encoding", "input", "出力の文字コード（input の場合は入力と同じ）")
+	newline     = flag.String("newline", "", "出力の改行コード（lf, crlf、空の場合は入力に合わせる）")
+	renameFile  = flag.String("rename-file", "", "テーブル名の変更（1行に1つ 変更前=変更後）を書いたファイル")
+	prefix      = flag.String("prefix", "", "すべてのテーブル名の先頭に付ける文字列")
+	suffix      = flag.String("suffix", "", "すべてのテーブル名の末尾に付ける文字列")
+	searchPath  = flag.String("search-path", "public", "修飾されていないテーブル名を探すスキーマ（カンマ区切り）")
+	resolve     = flag.String("resolve", "search-path", "修飾されていない参照先の解決方法（search-path, same-schema）")
+	dialectName = flag.String("dialect", "", "方言（mysql, postgres など、空の場合は方言に固有の構文を解釈しない）")
+	configFile  = flag.String("config", "", "設定ファイル（JSON、rules で文からオブジェクトの名前と依存関係を取り出す規則を、weights でテーブル名のパターンごとの並び順の重みを指定する）")
+	terminator  = flag.String("terminator", "", "文の既定の終端文字（DB2 のルーチンの @ など、空の場合は方言の終端文字）")
+	keepReplace = flag.Bool("keep-replaced", false, "CREATE OR REPLACE で置き換えられる前の定義も出力する（指定しない場合は最後の定義だけを出力する）")
+	temporary   = flag.String("temporary", "keep", "一時テーブルの文の扱い（keep, exclude、どちらの場合もテーブルとして並び替えない）")
+	views       = flag.String("views", "", "CREATE VIEW の扱い（skip, order, passthrough。order では参照するテーブルの後に並び替え、passthrough では入力の位置に残す。空の場合は種類を判断できない文と同じ）")
+	unknown     = flag.String("unknown", "", "種類を判断できない文の置き場所（keep, top, bottom、空の場合は前後の文と同じ位置に残し、最初のテーブルより前の文は出力しない）")
+	terminators = flag.String("terminators", "keep", "出力する文の終端文字の揃え方（keep, semicolon, go。semicolon と go では終端文字のない文に ; を付け、go では各文の後ろに GO の行を置く）")
+	tieBreak    = flag.String("tie-break", "input", "依存関係で順序が決まらないテーブルの並べ方（input, alpha）")
+	cycles      = flag.String("cycles", "error", "DEFERRABLE INITIALLY DEFERRED で解消できない循環依存の扱い（error, comment, fix。comment では循環ごとに外部キーを1つ選んでコメントにし、fix は -fix と同じ）")
+	fix         = flag.Bool("fix", false, "循環に含まれる外部キーをテーブル定義から取り除き、出力の末尾の ALTER TABLE で追加する（-dialect postgres では DEFERRABLE INITIALLY DEFERRED を付ける）")
+	minMoves    = flag.Bool("minimal-moves", false, "並べ直さずに、依存関係を満たすために必要な最小のテーブルだけを動かし、動かしたテーブルを表示する")
+	strict      = flag.Bool("strict", false, "種類を判断できない文や、そのまま出力される・出力されない文がある場合はエラーにする")
+	pluginCmd   = flag.String("plugin", "", "組み込みの解析で判断できない文を渡すプラグインのコマンド（1行に1つのJSONを標準入出力でやり取りする）")
+	schemaOut   = flag.String("schema-out", "", "並び替えたスキーマの文の出力先（指定した場合は -o の代わりに出力する）")
+	dataOut     = flag.String("data-out", "", "並び替えたデータの文（INSERT, COPY など）の出力先（指定した場合は -o の代わりに出力する）")
+	filesFrom   = flag.String("files-from", "", "並び替えるファイルの一覧（1行に1つのパス、- の場合は標準入力）。-check か -w と一緒に指定する")
+	checkOnly   = flag.Bool("check", false, "ファイルを書き換えずに、並び順が正しくないファイルを表示する（1つでもあれば終了コード 1）")
+	writeFiles  = flag.Bool("w", false, "並び順が正しくないファイルを、並び替えた結果で書き換える")
+	noCache     = flag.Bool("no-cache", false, "-check と -w で、内容とオプションが前回と同じファイルの結果をキャッシュ（$XDG_CACHE_HOME/orderddl）から使わずに並び替え直す")
+	stats       = flag.Bool("stats", false, "解析・並び替え・出力にかかった時間、最大メモリ、テーブルと依存関係の数、最大の文の大きさを表示する")
+	noProgress  = flag.Bool("no-progress", false, "大きな入力の解析中に進捗を標準エラー出力に表示しない")
+	noPrompt    = flag.Bool("no-prompt", false, "端末から実行した場合も、循環依存で除外する外部キーを選ばせずにエラーにする")
+	sourceMap   = flag.String("source-map", "", "テーブルごとの入力での行の範囲と出力での位置（JSON）の出力先")
+	restoreMap  = flag.String("restore", "", "-source-map で書き出したソースマップ。指定した場合は -i の並び替えた出力を、並び替える前の順に戻して -o に書き出す")
+	verifyStmts = flag.Bool("verify", false, "出力に入力と同じ文がちょうど1つずつ含まれていることを確かめる（失われた文や重複した文があればエラー）")
+	addExts     = flag.Bool("add-extensions", false, "カラムの型が必要とするが CREATE EXTENSION が入力にない拡張（geometry の postgis など）を作成する文を加える")
+	validateDB  = flag.String("validate-with", "", "並び替えた出力を実行して読み込めることを確かめるデータベース（docker:postgres:16 などのイメージ、または postgres://... / mysql://... の接続先）")
+	depsFile    = flag.String("deps-file", "", "入力の SQL の依存関係に加える依存関係の CSV（child,parent[,constraint] の行。-format csv の出力をそのまま使える）")
+	inlineFKs   = flag.Bool("inline-fks", false, "ALTER TABLE ... ADD で追加する外部キーを参照元の CREATE TABLE に移してから並び替える（-separate-fks の逆）")
+	separateFKs = flag.String("separate-fks", "", "すべての外部キーを CREATE TABLE から取り除き、追加する ALTER TABLE を作成順に書き出すファイル（テーブルの作成順序は外部キーによらなくなる）")
+	auditOut    = flag.String("audit", "", "見つけた依存関係、並び順の決め方、循環依存の解消、書き換えた文・動かしたテーブル・出力しなかった文など、行ったすべての判断の記録（JSON）の出力先")
+	reportOut   = flag.String("report", "", "出力の後に表示する、動かしたテーブルとその理由となった依存関係（JSON）の出力先")
+	header      = flag.Bool("header", false, "出力の先頭に、バージョン・入力のハッシュ・方言・テーブルの数と作成順序のコメントを書く")
+	annotate    = flag.Bool("annotate", false, "各テーブルの前に段数と依存先のコメントを出力する")
 )
 
-// テーブルの依存関係を解析する関数
-func parseDDL(ddlFile string) (map[string][]string, map[string]int, []string) {
-	file, err := os.Open(ddlFile)
+// -rename で指定したテーブル名の変更（変更前 → 変更後）
+var renames = renameFlag{}
+
+// 繰り返し指定できる -rename old=new
+type renameFlag map[string]string
+
+func (f renameFlag) String() string {
+	var pairs []string
+	for from, to := range f {
+		pairs = append(pairs, from+"="+to)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f renameFlag) Set(value string) error {
+	from, to, err := ddl.ParseRename(value)
 	if err != nil {
-		fmt.Println("ファイルを開けませんでした:", err)
-		os.Exit(1)
+		return err
+	}
+	f[from] = to
+	return nil
+}
+
+// -extra-dep で指定した、入力の依存関係に加える依存関係
+var extraDeps dependencyFlag
+
+// -ignore-dep で指定した、作成順序の判断から除く依存関係
+var ignoreDeps dependencyFlag
+
+// 繰り返し指定できる -extra-dep / -ignore-dep child:parent
+type dependencyFlag []ddl.Dependency
+
+func (f *dependencyFlag) String() string {
+	var deps []string
+	for _, dep := range *f {
+		deps = append(deps, dep.String())
 	}
-	defer file.Close()
+	return strings.Join(deps, ",")
+}
 
-	// 正規表現: CREATE TABLE と FOREIGN KEY を抽出
-	reCreateTable := regexp.MustCompile(TABLE_PATTERN)
-	reReferences := regexp.MustCompile(REFERENCES_PATTERN)
+func (f *dependencyFlag) Set(value string) error {
+	dep, err := ddl.ParseDependency(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, dep)
+	return nil
+}
 
-	// データ構造
-	graph := make(map[string][]string) // 外部キーの依存関係（親 → 子）
-	inDegree := make(map[string]int)   // 入次数
-	tableOrder := []string{}           // テーブル作成順序
-	currentTable := ""
+func init() {
+	flag.Var(renames, "rename", "テーブル名の変更（変更前=変更後、繰り返し指定できる）")
+	flag.Var(&extraDeps, "extra-dep", "入力の SQL では表せない依存関係（子:親、子のテーブルを親の後に作成する。繰り返し指定できる）")
+	flag.Var(&ignoreDeps, "ignore-dep", "作成順序の判断から除く依存関係（子:親、削除する予定の外部キーなど。繰り返し指定できる）")
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// 並び替えのたびに読み込む、オプションで指定したファイル（指定のないものは含めない）
+func optionFiles() []string {
+	var paths []string
+	for _, path := range []string{*configFile, *depsFile, *renameFile, *tmplFile} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
 
-		// CREATE TABLE の検出
-		if matches := reCreateTable.FindStringSubmatch(line); len(matches) > 1 {
-			// fmt.Printf("matches: %v\n", matches[1])
-			currentTable = matches[1]
-			tableOrder = append(tableOrder, currentTable)
-			if _, exists := graph[currentTable]; !exists {
-				graph[currentTable] = []string{}
+// -rename・-rename-file・-prefix・-suffix の指定に従ってテーブル名を書き換える
+func renameTables(src string) (string, error) {
+	all := make(map[string]string)
+	if *renameFile != "" {
+		content, err := os.ReadFile(*renameFile)
+		if err != nil {
+			return "", fmt.Errorf("ファイルを開けませんでした: %w", err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
 			}
-			if _, exists := inDegree[currentTable]; !exists {
-				inDegree[currentTable] = 0
+			from, to, err := ddl.ParseRename(line)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", *renameFile, err)
 			}
+			all[from] = to
+		}
+	}
+	for from, to := range renames {
+		all[from] = to
+	}
+	if len(all) > 0 {
+		var err error
+		if src, err = ddl.RenameTables(src, all); err != nil {
+			return "", err
+		}
+		froms := make([]string, 0, len(all))
+		for from := range all {
+			froms = append(froms, from)
 		}
+		sort.Strings(froms)
+		for _, from := range froms {
+			audit.rewrite("rename", from, from+" → "+all[from])
+		}
+	}
 
-		// FOREIGN KEY の検出
-		if strings.Contains(strings.ToLower(line), "foreign key") {
-			if matches := reReferences.FindStringSubmatch(line); len(matches) > 1 {
-				// fmt.Printf("matches: %v\n", matches[1])
-				parentTable := matches[1]
-				if currentTable != "" {
-					graph[parentTable] = append(graph[parentTable], currentTable)
-					inDegree[currentTable]++
-				}
+	// -prefix / -suffix はスキーマ名を除いたテーブル名に付ける
+	if *prefix == "" && *suffix == "" {
+		return src, nil
+	}
+	return ddl.RewriteTableNames(src, func(table string) string {
+		schema := ddl.SchemaOf(table)
+		if schema == "" {
+			return *prefix + table + *suffix
+		}
+		return schema + "." + *prefix + strings.TrimPrefix(table, schema+".") + *suffix
+	})
+}
+
+// -dialect で指定した方言（processSQL で決める。指定がない場合は nil）
+var dialect ddl.Dialect
+
+// -unknown で指定した置き場所、-temporary・-views で指定した一時テーブルとビューの扱い、-resolve で指定した名前の解決方法と -terminators で指定した終端文字の揃え方（processSQL で決める）
+var (
+	unknownPlacement ddl.UnknownPlacement
+	temporaryPolicy  ddl.TemporaryPolicy
+	viewPolicy       ddl.ViewPolicy
+	resolution       ddl.Resolution
+	terminatorStyle  ddl.TerminatorStyle
+)
+
+// 出力の文字コード（processSQL で入力に合わせて決める）
+var outputEncoding, _ = lookupEncoding("utf-8")
+
+// -header で出力の先頭に書く入力のハッシュ（processSQL で決める）
+var inputHash string
+
+// -fix で循環依存から取り除いた外部キーを追加する ALTER TABLE の文（processSQL で決め、最後のブロックの末尾に書く）
+var fixedConstraints string
+
+// 指定した順序でテーブルのDDLをファイルに書き出す
+func writeDDL(outputDDL string, sortedTables []string, ddlContent map[string]string) error {
+	content, err := orderedDDL(sortedTables, ddlContent)
+	if err != nil {
+		return err
+	}
+	return writeEncoded(outputDDL, content)
+}
+
+// 指定した順序でテーブルのDDLをつなげる（-header の場合は先頭に Manifest のコメントを書く）
+func orderedDDL(sortedTables []string, ddlContent map[string]string) (string, error) {
+	var out strings.Builder
+	if *header {
+		manifest := ddl.Manifest{Version: toolVersion(), InputHash: inputHash, Dialect: *dialectName}
+		for _, table := range sortedTables {
+			if _, exists := ddlContent[table]; exists {
+				manifest.Order = append(manifest.Order, table)
 			}
 		}
+		out.WriteString(manifest.String())
 	}
+	if err := ddl.WriteTables(&out, sortedTables, ddlContent); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Println("ファイル読み込みエラー:", err)
-		os.Exit(1)
+// 出力の文字コードに変換してファイルに書き出す
+func writeEncoded(path, content string) error {
+	data, err := outputEncoding.encode(normalizeNewlines(content, outputNewline))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
 	}
+	return nil
+}
 
-	return graph, inDegree, tableOrder
+// DDLをテーブルごとに分割するときの設定
+func splitOptions() ddl.Options {
+	return ddl.Options{SearchPath: searchSchemas(), Resolution: resolution, Dialect: dialect, Terminator: *terminator, KeepReplaced: *keepReplace, Temporary: temporaryPolicy, Views: viewPolicy, Unknown: unknownPlacement, Terminators: terminatorStyle}
 }
 
-// Kahn's Algorithm を使ったトポロジカルソート
-func topologicalSort(graph map[string][]string, inDegree map[string]int) []string {
-	var sortedTables []string
-	var queue []string
+// -search-path のスキーマ
+func searchSchemas() []string {
+	return strings.FieldsFunc(*searchPath, func(r rune) bool { return r == ',' || r == ' ' })
+}
 
-	// 入次数が0のノードをキューに追加
-	for table, degree := range inDegree {
-		if degree == 0 {
-			queue = append(queue, table)
+// DDLをテーブルごとに分割する（-annotate の場合はコメントを付ける）
+func splitDDL(src string, graph map[string][]string, sortedTables []string) (map[string]string, error) {
+	opts := splitOptions()
+	ddlContent, err := ddl.SplitWithOptions(context.Background(), strings.NewReader(src), opts)
+	if err != nil {
+		return nil, err
+	}
+	ddlContent = ddl.PlaceUnknown(ddlContent, sortedTables, opts)
+	if fixedConstraints != "" {
+		for i := len(sortedTables) - 1; i >= 0; i-- {
+			if block, exists := ddlContent[sortedTables[i]]; exists {
+				ddlContent[sortedTables[i]] = strings.TrimRight(block, "\n") + "\n\n" + fixedConstraints
+				break
+			}
 		}
 	}
+	ddlContent = ddl.NormalizeTerminators(ddlContent, opts)
+	// 以前の出力の Manifest はブロックに含めない
+	for table, block := range ddlContent {
+		ddlContent[table] = ddl.StripManifest(block)
+	}
+	if *annotate {
+		ddlContent = ddl.Annotate(ddlContent, sortedTables, graph)
+	}
+	return ddlContent, nil
+}
 
-	// トポロジカルソート処理
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
-		sortedTables = append(sortedTables, current)
+// DDLを正しい順序で並び替えて出力
+func reorderDDL(src, outputDDL string, graph map[string][]string, sortedTables []string) error {
+	ddlContent, err := splitDDL(src, graph, sortedTables)
+	if err != nil {
+		return err
+	}
 
-		for _, dependent := range graph[current] {
-			inDegree[dependent]--
-			if inDegree[dependent] == 0 {
-				queue = append(queue, dependent)
-			}
+	// 新しいDDLファイルに正しい順序で書き出す
+	if err := writeDDL(outputDDL, sortedTables, ddlContent); err != nil {
+		return err
+	}
+	if *sourceMap != "" && !quietOutput {
+		if err := writeSourceMap(src, *sourceMap, sortedTables, ddlContent); err != nil {
+			return err
 		}
 	}
 
-	// 閉路チェック（DAGでない場合）
-	if len(sortedTables) != len(graph) {
-		fmt.Println("エラー: 外部キーの循環依存が発生しています")
-		os.Exit(1)
+	if !quietOutput {
+		fmt.Println("✅ 正しい順序でDDLを出力しました:", outputDDL)
 	}
-
-	return sortedTables
+	return nil
 }
 
-// DDLを正しい順序で並び替えて出力
-func reorderDDL(inputDDL, outputDDL string, sortedTables []string) {
-	file, err := os.Open(inputDDL)
+// スキーマごとにDDLを分けて出力し、スキーマ間の依存順に読み込むトップレベルのファイルを書き出す
+func reorderDDLBySchema(src, outputDDL, dir string, graph map[string][]string, sortedTables []string) error {
+	ddlContent, err := splitDDL(src, graph, sortedTables)
 	if err != nil {
-		fmt.Println("ファイルを開けませんでした:", err)
-		os.Exit(1)
+		return err
 	}
-	defer file.Close()
 
-	ddlContent := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	var currentTable string
-	var currentDDL strings.Builder
+	// スキーマごとにテーブルを振り分ける（全体の並び順を保つ）
+	schemaTables := make(map[string][]string)
+	for _, table := range sortedTables {
+		if _, exists := ddlContent[table]; !exists {
+			continue
+		}
+		schema := ddl.SchemaOf(table)
+		schemaTables[schema] = append(schemaTables[schema], table)
+	}
 
-	// DDLをテーブルごとに分割
-	for scanner.Scan() {
-		line := scanner.Text()
+	// スキーマ間の依存関係（親スキーマ → 子スキーマ）と、その元になった最初のテーブルの依存関係
+	schemaGraph := make(map[string][]string)
+	schemaInDegree := make(map[string]int)
+	seen := make(map[string]bool)
+	tableEdges := make(map[string]string)
+	for schema := range schemaTables {
+		schemaGraph[schema] = []string{}
+		schemaInDegree[schema] = 0
+	}
+	for _, parent := range sortedTables {
+		parentSchema := ddl.SchemaOf(parent)
+		if _, exists := schemaTables[parentSchema]; !exists {
+			continue
+		}
+		for _, child := range graph[parent] {
+			childSchema := ddl.SchemaOf(child)
+			key := parentSchema + "\x00" + childSchema
+			if parentSchema == childSchema || seen[key] {
+				continue
+			}
+			seen[key] = true
+			tableEdges[key] = child + " → " + parent
+			schemaGraph[parentSchema] = append(schemaGraph[parentSchema], childSchema)
+			schemaInDegree[childSchema]++
+		}
+	}
 
-		if matches := regexp.MustCompile(TABLE_PATTERN).FindStringSubmatch(line); len(matches) > 1 {
-			if currentTable != "" {
-				ddlContent[currentTable] = currentDDL.String()
-				currentDDL.Reset()
+	sortedSchemas, err := ddl.TopologicalSort(schemaGraph, schemaInDegree)
+	if errors.Is(err, ddl.ErrCycle) {
+		// テーブルの依存関係に循環はないが、スキーマをまたいで行き来するためファイルの順が決まらない
+		var details []string
+		for _, cycle := range ddl.Cycles(schemaGraph) {
+			sort.Strings(cycle)
+			var deps []string
+			for _, parent := range cycle {
+				for _, child := range cycle {
+					if edge, exists := tableEdges[parent+"\x00"+child]; exists {
+						deps = append(deps, edge)
+					}
+				}
 			}
-			currentTable = matches[1]
+			details = append(details, fmt.Sprintf("%s（%s）", strings.Join(cycle, ", "), strings.Join(deps, ", ")))
+		}
+		return fmt.Errorf("スキーマ間の依存関係が循環しているため、スキーマごとのファイルに分けられません: %s。-schema-dir を指定せずに1つのファイルに出力してください", strings.Join(details, "; "))
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("出力ディレクトリを作成できませんでした: %w", err)
+	}
+
+	// トップレベルのファイルからの相対パスで読み込む
+	baseDir := filepath.Dir(outputDDL)
+	var top strings.Builder
+	top.WriteString("-- orderddl: スキーマ間の依存関係に基づく読み込み順\n")
+	for _, schema := range sortedSchemas {
+		name := schema
+		if name == "" {
+			name = "default"
+		}
+		path := filepath.Join(dir, name+".sql")
+		if err := writeDDL(path, schemaTables[schema], ddlContent); err != nil {
+			return err
 		}
+		fmt.Println("✅ スキーマごとのDDLを出力しました:", path)
 
-		if currentTable != "" {
-			currentDDL.WriteString(line + "\n")
+		rel, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			rel = path
 		}
+		top.WriteString(includeCommand(filepath.ToSlash(rel)) + "\n")
 	}
 
-	// 最後のテーブルを追加
-	if currentTable != "" {
-		ddlContent[currentTable] = currentDDL.String()
+	if err := os.WriteFile(outputDDL, []byte(top.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
 	}
+	fmt.Println("✅ スキーマの読み込み順を出力しました:", outputDDL)
+	return nil
+}
 
-	// 新しいDDLファイルに正しい順序で書き出す
-	outputFile, err := os.Create(outputDDL)
+// スキーマごとのファイルを読み込むクライアントのコマンド（SOURCE は mysql クライアントの作業ディレクトリからのパス）
+func includeCommand(path string) string {
+	name := ""
+	if dialect != nil {
+		name = dialect.Name()
+	}
+	switch name {
+	case "mysql", "tidb", "vitess":
+		return "SOURCE " + path + ";"
+	case "snowflake":
+		return "!source " + path
+	default:
+		return "\\ir " + path
+	}
+}
+
+func processSQL(ctx context.Context, input, output string) error {
+	// アーカイブの場合はすべての .sql ファイルをつなげて1つの入力とする
+	var entries []archiveEntry
+	var src string
+	var enc textEncoding
+	if isArchive(input) {
+		var err error
+		if entries, err = readArchive(input); err != nil {
+			return err
+		}
+		src, enc = joinEntries(entries), entries[0].enc
+	} else {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			return fmt.Errorf("ファイルを開けませんでした: %w", err)
+		}
+		// SQLite のデータベースファイルの場合は sqlite_master の CREATE 文を入力とする
+		if isSQLiteDatabase(content) {
+			if src, err = readSQLiteSchema(input, content); err != nil {
+				return err
+			}
+			enc, _ = lookupEncoding("utf-8")
+		} else if src, enc, err = decodeInput(content, *inputEnc); err != nil {
+			return err
+		}
+	}
+	var err error
+	if outputEncoding, err = outputEncodingFor(*outputEnc, enc); err != nil {
+		return err
+	}
+	if outputNewline, err = newlineFor(*newline, src); err != nil {
+		return err
+	}
+	inputHash = checksumOfString(ddl.StripManifest(src))
+	fixedConstraints = ""
+	if isArchive(output) {
+		if name := archiveRewriteFlag(); name != "" {
+			return fmt.Errorf("アーカイブに出力する場合は %s で文を書き換えられません", name)
+		}
+	}
+	startAudit(input, output)
+	if src, err = renameTables(src); err != nil {
+		return err
+	}
+
+	softConstraints, err := ddl.ParseSoftConstraintPolicy(*softDeps)
 	if err != nil {
-		fmt.Println("出力ファイルを作成できませんでした:", err)
-		os.Exit(1)
+		return err
+	}
+	if resolution, err = ddl.ParseResolution(*resolve); err != nil {
+		return err
+	}
+	if unknownPlacement, err = ddl.ParseUnknownPlacement(*unknown); err != nil {
+		return err
+	}
+	if temporaryPolicy, err = ddl.ParseTemporaryPolicy(*temporary); err != nil {
+		return err
+	}
+	if viewPolicy, err = ddl.ParseViewPolicy(*views); err != nil {
+		return err
+	}
+	if terminatorStyle, err = ddl.ParseTerminatorStyle(*terminators); err != nil {
+		return err
+	}
+	tieBreakOrder, err := ddl.ParseTieBreak(*tieBreak)
+	if err != nil {
+		return err
+	}
+	cyclePolicy, err := ddl.ParseCyclePolicy(*cycles)
+	if err != nil {
+		return err
+	}
+	if *fix {
+		if cyclePolicy != ddl.CyclesError {
+			return errors.New("-fix と -cycles は同時に指定できません")
+		}
+		cyclePolicy = ddl.CyclesFix
+	}
+	dialect = nil
+	if *dialectName != "" {
+		if dialect, err = ddl.LookupDialect(*dialectName); err != nil {
+			return err
+		}
+	}
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Rules) > 0 {
+		if dialect, err = ddl.NewRuleDialect(dialect, cfg.Rules); err != nil {
+			return fmt.Errorf("%s: %w", *configFile, err)
+		}
+	}
+	dependencies, err := readDependencyFile(*depsFile)
+	if err != nil {
+		return err
+	}
+	var plugin *ddl.Plugin
+	if fields := strings.Fields(*pluginCmd); len(fields) > 0 {
+		if plugin, err = ddl.StartPlugin(fields[0], fields[1:]...); err != nil {
+			return err
+		}
+		defer plugin.Close()
+		plugin.Base = dialect
+		dialect = plugin
+	}
+	opts := ddl.Options{
+		SoftConstraints:     softConstraints,
+		SearchPath:          searchSchemas(),
+		Resolution:          resolution,
+		Dialect:             dialect,
+		Terminator:          *terminator,
+		KeepReplaced:        *keepReplace,
+		Temporary:           temporaryPolicy,
+		Views:               viewPolicy,
+		Unknown:             unknownPlacement,
+		TieBreak:            tieBreakOrder,
+		Weights:             cfg.Weights,
+		MinimalMoves:        *minMoves,
+		Terminators:         terminatorStyle,
+		Cycles:              cyclePolicy,
+		ExtraDependencies:   append(append([]ddl.Dependency{}, extraDeps...), dependencies...),
+		IgnoredDependencies: ignoreDeps,
+	}
+	if err := setEntryTables(entries, opts); err != nil {
+		return err
+	}
+	if !*noPrompt {
+		if prompt := newCyclePrompt(); prompt != nil {
+			opts.ResolveCycle = prompt.resolve
+		}
 	}
-	defer outputFile.Close()
 
-	writer := bufio.NewWriter(outputFile)
-	for _, table := range sortedTables {
-		if ddl, exists := ddlContent[table]; exists {
-			_, err := writer.WriteString(ddl)
-			if err != nil {
-				fmt.Println("書き込みに失敗しました:", err)
-				os.Exit(1)
+	if *tmplFile != "" {
+		if *format != "sql" {
+			return errors.New("-template と -format は同時に指定できません")
+		}
+		if err := renderTemplate(ctx, src, input, output, *tmplFile, opts); err != nil {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return nil
+	}
+
+	switch *format {
+	case "sql":
+	case "json":
+		// 循環依存は端末で選ばせずに、そのまま cycles に書き出す
+		jsonOpts := opts
+		jsonOpts.ResolveCycle = nil
+		result, err := ddl.AnalyzeContext(ctx, src, jsonOpts)
+		if err != nil && !errors.Is(err, ddl.ErrCycle) {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return exportGraphJSON(src, output, result, jsonOpts)
+	case "condensed":
+		graph, _, tableOrder, err := ddl.Parse(strings.NewReader(src), opts)
+		if err != nil {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return exportCondensedJSON(output, graph, tableOrder)
+	case "csv":
+		_, edges, err := ddl.Edges(strings.NewReader(src), opts)
+		if err != nil {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return exportEdgesCSV(output, edges)
+	case "markdown":
+		// 循環依存は端末で選ばせずに、レポートにそのまま書く
+		reportOpts := opts
+		reportOpts.ResolveCycle = nil
+		result, err := ddl.AnalyzeContext(ctx, src, reportOpts)
+		if err != nil && !errors.Is(err, ddl.ErrCycle) {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return exportMarkdown(output, result)
+	case "go":
+		result, err := ddl.AnalyzeContext(ctx, src, opts)
+		if err != nil {
+			return err
+		}
+		if plugin != nil && plugin.Err() != nil {
+			return plugin.Err()
+		}
+		return exportGoSource(output, *goPackage, result.Sorted)
+	default:
+		return fmt.Errorf("不明な出力形式です: %s", *format)
+	}
+
+	// -separate-fks では外部キーを取り除いた入力を、-inline-fks では ALTER TABLE の外部キーを CREATE TABLE に移した入力を並び替える
+	var separatedConstraints string
+	if *separateFKs != "" || *inlineFKs {
+		if *separateFKs != "" && *inlineFKs {
+			return errors.New("-separate-fks と -inline-fks は同時に指定できません")
+		}
+		beforeOpts := opts
+		beforeOpts.ResolveCycle = nil
+		beforeOpts.Cycles = ddl.CyclesError
+		before, err := ddl.AnalyzeContext(ctx, src, beforeOpts)
+		if before == nil {
+			return err
+		}
+		if *inlineFKs {
+			var moved []ddl.ForeignKey
+			var keys int
+			src, moved, keys = ddl.InlineForeignKeys(src, before.Edges, opts)
+			for _, fk := range moved {
+				audit.rewrite("inline_foreign_key", fk.Table, fk.String())
 			}
+			fmt.Printf("🔧 %d個の外部キーと %d個の主キー・一意制約を ALTER TABLE から CREATE TABLE に移しました\n", len(moved), keys)
+		} else {
+			order := before.Sorted
+			if len(order) == 0 {
+				order = before.Tables
+			}
+			src, separatedConstraints = ddl.SeparateForeignKeys(src, before.Edges, order, opts)
+			audit.rewrite("separate_foreign_keys", "", *separateFKs)
 		}
 	}
-	writer.Flush()
 
-	fmt.Println("✅ 正しい順序でDDLを出力しました:", outputDDL)
+	// 進捗は最初の解析だけで表示する
+	analyzeOpts := opts
+	var progress *progressReporter
+	if !*noProgress {
+		progress = newProgressReporter(int64(len(src)))
+		analyzeOpts.Progress = progress.update
+	}
+	result, err := ddl.AnalyzeContext(ctx, src, analyzeOpts)
+	// 入力にない拡張を作成する文を加えて解析し直す
+	var addedExtensions []ddl.MissingExtension
+	if *addExts && result != nil && len(result.MissingExtensions) > 0 {
+		if isArchive(output) {
+			return errors.New("アーカイブに出力する場合は -add-extensions で文を加えられません")
+		}
+		addedExtensions = result.MissingExtensions
+		src = ddl.AddExtensions(src, addedExtensions, opts)
+		result, err = ddl.AnalyzeContext(ctx, src, opts)
+	}
+	if progress != nil {
+		progress.finish()
+	}
+	if *stats && result != nil {
+		analyzedAt := time.Now()
+		defer func() { printStats(result, time.Since(analyzedAt)) }()
+	}
+	if result != nil {
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "⚠️ 警告: %d行目: %s\n", warning.Line, warning.Message)
+		}
+		for _, fk := range result.Duplicates {
+			fmt.Fprintln(os.Stderr, "⚠️ 警告: 重複している外部キーを1つにまとめました:", fk)
+		}
+		for _, edge := range result.Edges {
+			if edge.Extra && (result.Graph[edge.Parent] == nil || result.Graph[edge.Child] == nil) {
+				fmt.Fprintf(os.Stderr, "⚠️ 警告: -extra-dep %s:%s のテーブルが入力に定義されていないため、作成順序に影響しません\n", edge.Child, edge.Parent)
+			}
+		}
+		for _, extension := range result.MissingExtensions {
+			fmt.Fprintf(os.Stderr, "⚠️ 警告: %s が %s 拡張の型を使っていますが、CREATE EXTENSION %s がありません（-add-extensions で加えられます）\n", strings.Join(extension.Tables, ", "), extension.Name, extension.Name)
+		}
+		for _, extension := range addedExtensions {
+			fmt.Printf("🔧 %s が %s 拡張の型を使っているため、CREATE EXTENSION IF NOT EXISTS %s を加えました\n", strings.Join(extension.Tables, ", "), extension.Name, extension.Name)
+			audit.rewrite("add_extension", strings.Join(extension.Tables, ", "), "CREATE EXTENSION IF NOT EXISTS "+extension.Name)
+		}
+		for _, dep := range result.UnusedIgnores {
+			fmt.Fprintf(os.Stderr, "⚠️ 警告: -ignore-dep %s に一致する依存関係がありません\n", dep)
+		}
+		for _, edge := range result.Ignored {
+			fmt.Printf("ℹ️ -ignore-dep の指定に従って、%s から %s への依存関係を作成順序の判断から除外しました\n", edge.Child, edge.Parent)
+		}
+		for _, edge := range result.Deferred {
+			fmt.Printf("ℹ️ 循環依存を解消するため、%s から %s への外部キー（DEFERRABLE INITIALLY DEFERRED）を作成順序の判断から除外しました\n", edge.Child, edge.Parent)
+		}
+		for _, edge := range result.Resolved {
+			fmt.Printf("ℹ️ 循環依存を解消するため、選択に従って %s から %s への依存関係を作成順序の判断から除外しました\n", edge.Child, edge.Parent)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if plugin != nil && plugin.Err() != nil {
+		return plugin.Err()
+	}
+	if *strict {
+		for _, warning := range result.Unrecognized {
+			fmt.Fprintf(os.Stderr, "❌ %d行目: %s\n", warning.Line, warning.Message)
+		}
+		if n := len(result.Warnings) + len(result.Unrecognized); n > 0 {
+			return fmt.Errorf("-strict: 正しく扱えない文が%d件あります", n)
+		}
+	}
+	sortedTables := result.Sorted
+	if len(result.Commented) > 0 {
+		if isArchive(output) {
+			return errors.New("アーカイブに出力する場合は循環依存の外部キーをコメントにできません")
+		}
+		for _, edge := range result.Commented {
+			if edge.Parent != edge.Child {
+				fmt.Printf("⚠️ 循環依存のため、%s の外部キーをコメントにしました（%s）\n", edge.Child, edge.ForeignKey)
+				audit.rewrite("comment_out_foreign_key", edge.Child, edge.ForeignKey.String())
+			}
+		}
+		src = ddl.CommentOutForeignKeys(src, result.Commented)
+	}
+	// 端末で選んだ依存関係と DEFERRABLE INITIALLY DEFERRED の外部キーも、-fix と同じように末尾の ALTER TABLE に
+	// 移さないと出力を読み込めない（検査を遅らせても、CREATE TABLE の時点で参照先のテーブルが必要になる）
+	fixed := append([]ddl.Edge{}, result.Fixed...)
+	for _, edge := range append(append([]ddl.Edge{}, result.Deferred...), result.Resolved...) {
+		if !edge.Hint && !edge.Extra && edge.ForeignKey.Text != "" {
+			fixed = append(fixed, edge)
+		}
+	}
+	if len(fixed) > 0 {
+		if isArchive(output) {
+			return errors.New("アーカイブに出力する場合は循環依存の外部キーを移せません")
+		}
+		for _, edge := range fixed {
+			fmt.Printf("🔧 循環依存のため、%s の外部キーを末尾の ALTER TABLE に移しました（%s）\n", edge.Child, edge.ForeignKey)
+			audit.rewrite("move_foreign_key", edge.Child, edge.ForeignKey.String())
+		}
+		src, fixedConstraints = ddl.FixForeignKeys(src, fixed, opts)
+	}
+	if opts.MinimalMoves {
+		for _, move := range result.Moves {
+			if move.After == "" {
+				fmt.Printf("🚚 %s を先頭に移動しました\n", move.Table)
+			} else {
+				fmt.Printf("🚚 %s を %s の後ろに移動しました\n", move.Table, move.After)
+			}
+		}
+		fmt.Printf("ℹ️ %d個のテーブルのうち%d個を移動しました\n", len(sortedTables), len(result.Moves))
+	}
+
+	tables, err := ddl.TablesWithOptions(strings.NewReader(src), opts)
+	if err != nil {
+		return err
+	}
+	for _, issue := range ddl.ForeignKeyColumnWarnings(tables) {
+		fmt.Fprintf(os.Stderr, "⚠️ 警告: %d行目: %s\n", issue.Line, issue.Message)
+	}
+
+	if opts.SoftConstraints == ddl.SoftConstraintsWarn && !opts.InformationalConstraints() {
+		fks, err := ddl.ForeignKeysWithOptions(strings.NewReader(src), opts)
+		if err != nil {
+			return err
+		}
+		for _, warning := range ddl.SoftDependencyWarnings(fks, sortedTables) {
+			fmt.Fprintln(os.Stderr, "⚠️ 警告:", warning)
+		}
+	}
+
+	if *dropOut != "" {
+		if err := writeDropDDL(src, *dropOut, sortedTables); err != nil {
+			return err
+		}
+	}
+
+	if *idempotent {
+		if err := verifyIdempotent(src, opts); err != nil {
+			return err
+		}
+		fmt.Println("✅ 出力をもう一度並び替えても結果は変わりません")
+	}
+
+	switch *planFormat {
+	case "":
+	case "json":
+		if err := writePlan(src, *planOut, result.Graph, sortedTables); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("不明な適用計画の形式です: %s", *planFormat)
+	}
+
+	if *checksum {
+		ddlContent, err := splitDDL(src, result.Graph, sortedTables)
+		if err != nil {
+			return err
+		}
+		// 改行コードと文字コードを変換した後の、出力ファイルと同じ内容から計算する
+		var out strings.Builder
+		if err := ddl.WriteTables(&out, sortedTables, ddlContent); err != nil {
+			return err
+		}
+		data, err := outputEncoding.encode(normalizeNewlines(out.String(), outputNewline))
+		if err != nil {
+			return err
+		}
+		fmt.Println("🔑 チェックサム:", checksumOf(data))
+	}
+
+	if err := writeOutput(src, output, entries, result, sortedTables); err != nil {
+		return err
+	}
+	if quietOutput {
+		return nil
+	}
+	if *verifyStmts || *validateDB != "" {
+		ddlContent, err := splitDDL(src, result.Graph, sortedTables)
+		if err != nil {
+			return err
+		}
+		content, err := orderedDDL(sortedTables, ddlContent)
+		if err != nil {
+			return err
+		}
+		if *verifyStmts {
+			if err := verifyOutput(ctx, src, content); err != nil {
+				return err
+			}
+		}
+		if *validateDB != "" {
+			if err := validateOutput(ctx, *validateDB, content, opts); err != nil {
+				return err
+			}
+		}
+	}
+	if *separateFKs != "" {
+		if err := writeEncoded(*separateFKs, separatedConstraints); err != nil {
+			return err
+		}
+		fmt.Println("✅ 外部キーを追加する ALTER TABLE を出力しました:", *separateFKs)
+	}
+	if err := outputComponents(src, result, sortedTables); err != nil {
+		return err
+	}
+	if err := writeAudit(ctx, src, result, sortedTables); err != nil {
+		return err
+	}
+	return reportMoves(result, sortedTables, opts.MinimalMoves)
 }
 
-func processSQL(input, output string) {
-	graph, inDegree, _ := parseDDL(input)
+// 並び替えた結果を出力先の種類に合わせて書き出す
+func writeOutput(src, output string, entries []archiveEntry, result *ddl.Result, sortedTables []string) error {
+	if isArchive(output) {
+		if entries == nil {
+			return errors.New("アーカイブに出力するには入力もアーカイブにしてください")
+		}
+		order, err := orderEntries(entries, result.Graph)
+		if err != nil {
+			return fmt.Errorf("アーカイブ内のファイル間で%w", err)
+		}
+		return writeArchive(output, entries, order)
+	}
 
-	sortedTables := topologicalSort(graph, inDegree)
+	if *schemaOut != "" || *dataOut != "" {
+		return writeSchemaAndData(src, *schemaOut, *dataOut, result.Graph, sortedTables)
+	}
 
-	reorderDDL(input, output, sortedTables)
+	if *schemaDir != "" {
+		return reorderDDLBySchema(src, output, *schemaDir, result.Graph, sortedTables)
+	}
+
+	return reorderDDL(src, output, result.Graph, sortedTables)
+}
+
+// 並び替えた結果をもう一度並び替え、同じ結果になることを確認する
+func verifyIdempotent(src string, opts ddl.Options) error {
+	first, err := ddl.Order(src, opts)
+	if err != nil {
+		return err
+	}
+	second, err := ddl.Order(first, opts)
+	if err != nil {
+		return fmt.Errorf("出力をもう一度並び替えられませんでした: %w", err)
+	}
+	if first == second {
+		return nil
+	}
+
+	firstLines := strings.Split(first, "\n")
+	secondLines := strings.Split(second, "\n")
+	line := 0
+	for line < len(firstLines) && line < len(secondLines) && firstLines[line] == secondLines[line] {
+		line++
+	}
+	return fmt.Errorf("出力をもう一度並び替えると結果が変わります（%d 行目）", line+1)
 }
 
 func main() {
+	// サブコマンドの振り分け
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			if err := runServe(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "tui":
+			if err := runTUI(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "orphans":
+			if err := runOrphans(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "merge":
+			if err := runMerge(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "baseline":
+			if err := runBaseline(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "plan":
+			if err := runPlan(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "apply":
+			if err := runApply(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "fixtures":
+			if err := runFixtures(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "load-csv":
+			if err := runLoadCSV(os.Args[2:]); err != nil {
+				fmt.Println("❌ エラー:", err)
+				os.Exit(1)
+			}
+			return
+		case "lint":
+			if err := runLint(os.Args[2:]); err != nil {
+				if !errors.Is(err, errLintIssues) {
+					fmt.Println("❌ エラー:", err)
+				}
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	flag.Parse()
+	if *restoreMap != "" {
+		if err := runRestore(*input, *output, *restoreMap); err != nil {
+			fmt.Println("❌ エラー:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *filesFrom != "" || *checkOnly || *writeFiles {
+		if err := runReorderFiles(); err != nil {
+			if !errors.Is(err, errNeedsReorder) {
+				fmt.Println("❌ エラー:", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
 	// 必須項目のチェック
 	if *input == "" {
 		fmt.Println("❌ エラー: `-input` オプションで入力 SQL ファイルのパスを指定してください。")
@@ -187,5 +1022,48 @@ func main() {
 		os.Exit(1)
 	}
 
-	processSQL(*input, *output)
+	ctx, stop := signalContext()
+	defer stop()
+
+	if *watch {
+		if err := watchSQL(ctx, *input, *output); err != nil {
+			fmt.Println("❌ エラー:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := processSQL(ctx, *input, *output); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Println("⚠️ 中断しました")
+			os.Exit(130)
+		}
+		fmt.Println("❌ エラー:", err)
+		os.Exit(1)
+	}
+}
+
+// -files-from または -i で指定したファイルを -check / -w で並び替える
+func runReorderFiles() error {
+	if *checkOnly == *writeFiles {
+		return errors.New("-check と -w のどちらか一方を指定してください")
+	}
+	paths := []string{*input}
+	if *filesFrom != "" {
+		var err error
+		if paths, err = readFileList(*filesFrom); err != nil {
+			return err
+		}
+	} else if *input == "" {
+		return errors.New("`-i` か `-files-from` で並び替えるファイルを指定してください")
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+	return reorderFiles(ctx, paths, *writeFiles)
+}
+
+// Ctrl+C（SIGINT）または SIGTERM で取り消される context を返す
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 }
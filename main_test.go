@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestReorderDDLBySchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "スキーマの依存順に読み込む",
+			src:  "CREATE TABLE sales.orders (id int, c int REFERENCES crm.customers(id));\nCREATE TABLE crm.customers (id int PRIMARY KEY);\n",
+			want: []string{`\ir schemas/crm.sql`, `\ir schemas/sales.sql`},
+		},
+		{
+			name:    "テーブルの依存関係はスキーマをまたいで循環する",
+			src:     "CREATE TABLE a.x (id int PRIMARY KEY);\nCREATE TABLE b.y (id int PRIMARY KEY, x int REFERENCES a.x(id));\nCREATE TABLE a.z (id int, y int REFERENCES b.y(id));\n",
+			wantErr: "スキーマ間の依存関係が循環しているため",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ddl.Analyze(tt.src, ddl.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			output := filepath.Join(dir, "schema.sql")
+			err = reorderDDLBySchema(tt.src, output, filepath.Join(dir, "schemas"), result.Graph, result.Sorted)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			top, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(strings.TrimSpace(string(top)), "\n")[1:]; strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("読み込み順 = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeCommand(t *testing.T) {
+	defer func(d ddl.Dialect) { dialect = d }(dialect)
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{"", `\ir schemas/a.sql`},
+		{"postgres", `\ir schemas/a.sql`},
+		{"mysql", "SOURCE schemas/a.sql;"},
+		{"snowflake", "!source schemas/a.sql"},
+	}
+	for _, tt := range tests {
+		dialect = nil
+		if tt.dialect != "" {
+			var err error
+			if dialect, err = ddl.LookupDialect(tt.dialect); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := includeCommand("schemas/a.sql"); got != tt.want {
+			t.Errorf("includeCommand(%q) = %s, want %s", tt.dialect, got, tt.want)
+		}
+	}
+}
+
+func TestRenameTablesPrefix(t *testing.T) {
+	t.Cleanup(func() { *prefix, *suffix = "", "" })
+	*prefix, *suffix = "p_", "_v2"
+	renames["users"] = "accounts"
+	t.Cleanup(func() { delete(renames, "users") })
+
+	src := "CREATE TABLE users (id int PRIMARY KEY);\nCREATE TABLE sales.orders (user_id int REFERENCES users(id));\n"
+	want := "CREATE TABLE p_accounts_v2 (id int PRIMARY KEY);\nCREATE TABLE sales.p_orders_v2 (user_id int REFERENCES p_accounts_v2(id));\n"
+	got, err := renameTables(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("renameTables =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProcessSQLDeferredForeignKey(t *testing.T) {
+	t.Cleanup(func() { *dialectName, *verifyStmts, *validateDB = "", false, "" })
+	*dialectName, *verifyStmts = "postgres", true
+	// 接続先を指定した場合は出力を実際に読み込めることも確かめる
+	*validateDB = os.Getenv("ORDERDDL_TEST_POSTGRES_DSN")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.sql")
+	output := filepath.Join(dir, "output.sql")
+	src := "CREATE TABLE a (\n  id int PRIMARY KEY,\n  b_id int,\n  CONSTRAINT fk_a_b FOREIGN KEY (b_id) REFERENCES b(id) DEFERRABLE INITIALLY DEFERRED\n);\n" +
+		"CREATE TABLE b (\n  id int PRIMARY KEY,\n  a_id int REFERENCES a(id)\n);\n"
+	if err := os.WriteFile(input, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := processSQL(context.Background(), input, output); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 検査を遅らせる外部キーも CREATE TABLE の時点では参照先が必要なため、末尾の ALTER TABLE に移す
+	want := "CREATE TABLE a (\n  id int PRIMARY KEY,\n  b_id int\n);\nCREATE TABLE b (\n  id int PRIMARY KEY,\n  a_id int REFERENCES a(id)\n);\n\n" +
+		"ALTER TABLE a ADD CONSTRAINT fk_a_b FOREIGN KEY (b_id) REFERENCES b(id) DEFERRABLE INITIALLY DEFERRED;\n"
+	if string(got) != want {
+		t.Errorf("出力 =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 作成順序、テーブルごとの依存関係と循環依存を Markdown のレポートで書き出す。
+// 作成順序は -format sql と同じ AnalyzeContext の結果を使い、循環依存がある場合は、
+// 循環しているテーブルをまとめたグラフ（-format condensed と同じ）の順と段数で並べる
+func exportMarkdown(outputPath string, result *ddl.Result) error {
+	g := result.DependencyGraph()
+	tables := g.Tables()
+	cycles := result.Cycles
+
+	// 作成順のテーブルと段数、循環しているテーブルの組の番号
+	var order []string
+	levels := make(map[string]int)
+	cycleOf := make(map[string]int)
+	for i, cycle := range cycles {
+		for _, table := range cycle {
+			cycleOf[table] = i + 1
+		}
+	}
+	if len(cycles) == 0 {
+		order = result.Sorted
+		levels = ddl.Levels(order, result.Graph)
+	} else {
+		for _, node := range ddl.Condense(tables, result.Graph).Nodes {
+			for _, table := range node.Tables {
+				order = append(order, table)
+				levels[table] = node.Level
+			}
+		}
+	}
+
+	dependencies := 0
+	for _, table := range tables {
+		dependencies += len(g.Dependencies(table))
+	}
+
+	var b strings.Builder
+	b.WriteString("# 依存関係レポート\n\n")
+	fmt.Fprintf(&b, "%d個のテーブルと%d個の依存関係があります。\n\n", len(tables), dependencies)
+
+	b.WriteString("## 作成順序\n\n")
+	if len(cycles) > 0 {
+		b.WriteString("循環依存があるため、循環しているテーブルは同じ段にまとめています。\n\n")
+	}
+	b.WriteString("| # | テーブル | 段 | 循環 |\n")
+	b.WriteString("|---:|---|---:|---|\n")
+	for i, table := range order {
+		cycle := ""
+		if c := cycleOf[table]; c > 0 {
+			cycle = fmt.Sprintf("%d", c)
+		}
+		fmt.Fprintf(&b, "| %d | %s | %d | %s |\n", i+1, markdownCode(table), levels[table], cycle)
+	}
+
+	b.WriteString("\n## テーブルごとの依存関係\n")
+	for _, table := range order {
+		fmt.Fprintf(&b, "\n### %s\n\n", markdownCode(table))
+		fmt.Fprintf(&b, "- 依存先: %s\n", markdownList(g.Dependencies(table)))
+		fmt.Fprintf(&b, "- 依存元: %s\n", markdownList(g.Dependents(table)))
+	}
+
+	b.WriteString("\n## 循環依存\n\n")
+	if len(cycles) == 0 {
+		b.WriteString("循環依存はありません。\n")
+	}
+	for i, cycle := range cycles {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, markdownList(cycle))
+	}
+
+	if err := os.WriteFile(outputPath, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ 依存関係のレポートを Markdown で出力しました:", outputPath)
+	return nil
+}
+
+// テーブル名をコードとして書く（表の区切りになる | はエスケープする）
+func markdownCode(name string) string {
+	return "`" + strings.ReplaceAll(name, "|", `\|`) + "`"
+}
+
+// テーブル名をカンマ区切りで並べる（空の場合は「なし」）
+func markdownList(names []string) string {
+	if len(names) == 0 {
+		return "なし"
+	}
+	codes := make([]string, len(names))
+	for i, name := range names {
+		codes[i] = markdownCode(name)
+	}
+	return strings.Join(codes, ", ")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		opts ddl.Options
+		want []string
+	}{
+		{
+			name: "循環依存なし",
+			src:  "CREATE TABLE c (id int, p_id int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY);\n",
+			want: []string{
+				"2個のテーブルと1個の依存関係があります。",
+				"| 1 | `p` | 0 |  |\n| 2 | `c` | 1 |  |\n",
+				"### `p`\n\n- 依存先: なし\n- 依存元: `c`\n",
+				"### `c`\n\n- 依存先: `p`\n- 依存元: なし\n",
+				"循環依存はありません。",
+			},
+		},
+		{
+			name: "循環依存あり",
+			src:  exportSchema,
+			want: []string{
+				"循環依存があるため、循環しているテーブルは同じ段にまとめています。",
+				"| 1 | `a` | 0 | 1 |\n| 2 | `b` | 0 | 1 |\n| 3 | `c` | 1 |  |\n",
+				"## 循環依存\n\n1. `a`, `b`\n",
+			},
+		},
+		{
+			name: "-tie-break alpha の作成順序",
+			src:  "CREATE TABLE z (id int);\nCREATE TABLE m (id int);\nCREATE TABLE a (id int);\n",
+			opts: ddl.Options{TieBreak: ddl.TieBreakAlpha},
+			want: []string{"| 1 | `a` | 0 |  |\n| 2 | `m` | 0 |  |\n| 3 | `z` | 0 |  |\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ddl.Analyze(tt.src, tt.opts)
+			if result == nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(t.TempDir(), "report.md")
+			if err := exportMarkdown(output, result); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("レポートに %q が含まれていません:\n%s", want, data)
+				}
+			}
+		})
+	}
+}
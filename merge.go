@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// マージ元のDDLファイル
+type mergeSource struct {
+	path    string
+	modTime time.Time
+	tables  []string
+	blocks  map[string]string
+}
+
+// merge サブコマンドを実行する
+func runMerge(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := fs.String("o", "", "出力先（空の場合は標準出力）")
+	policy := fs.String("policy", "last", "同じテーブルが複数のファイルで定義されている場合に採用する定義（last, first, newest）")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("使い方: orderddl merge [-policy last|first|newest] [-o merged.sql] <a.sql> <b.sql> ...")
+	}
+	switch *policy {
+	case "last", "first", "newest":
+	default:
+		return fmt.Errorf("不明なマージ方針です: %s", *policy)
+	}
+
+	var sources []mergeSource
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("ファイルを開けませんでした: %w", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("ファイルを開けませんでした: %w", err)
+		}
+		tables, _, err := ddl.Edges(strings.NewReader(string(content)), ddl.Options{})
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		blocks, err := ddl.Split(strings.NewReader(string(content)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		sources = append(sources, mergeSource{path: path, modTime: info.ModTime(), tables: tables, blocks: blocks})
+	}
+
+	// テーブルごとに採用するファイルを決める（テーブルの並びは最初に現れた順）
+	var tables []string
+	winner := make(map[string]int)
+	definedIn := make(map[string][]string)
+	for i, source := range sources {
+		for _, table := range source.tables {
+			definedIn[table] = append(definedIn[table], source.path)
+			current, exists := winner[table]
+			if !exists {
+				tables = append(tables, table)
+				winner[table] = i
+				continue
+			}
+			switch *policy {
+			case "last":
+				winner[table] = i
+			case "newest":
+				if !source.modTime.Before(sources[current].modTime) {
+					winner[table] = i
+				}
+			}
+		}
+	}
+
+	var merged strings.Builder
+	for _, table := range tables {
+		source := sources[winner[table]]
+		merged.WriteString(source.blocks[table])
+		if len(definedIn[table]) > 1 {
+			fmt.Fprintf(os.Stderr, "ℹ️ %s は %s で定義されています。%s の定義を使います\n", table, strings.Join(definedIn[table], ", "), source.path)
+		}
+	}
+
+	ordered, err := ddl.Order(merged.String(), ddl.Options{})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Print(ordered)
+		return nil
+	}
+	if err := os.WriteFile(*out, []byte(ordered), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ マージしたDDLを出力しました:", *out)
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// HTTPサーバーモードの GET /metrics で Prometheus の形式で公開する値（-grpc-listen の gRPC サーバーの値も含む）
+type serveMetrics struct {
+	mu sync.Mutex
+	// エンドポイントとステータスごとのリクエスト数
+	requests map[requestLabels]uint64
+	// gRPC のメソッドとステータスコードごとのリクエスト数
+	grpcRequests map[grpcRequestLabels]uint64
+	// 並び替えにかかった時間と入力の大きさ
+	orderSeconds *histogram
+	inputBytes   *histogram
+	// 循環依存を見つけた回数と、理由ごとの並び替えのエラーの数
+	cycles uint64
+	errors map[string]uint64
+}
+
+type requestLabels struct {
+	handler string
+	code    int
+}
+
+type grpcRequestLabels struct {
+	method string
+	code   codes.Code
+}
+
+// 累積のバケットを持つヒストグラム
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds ...float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func newServeMetrics() *serveMetrics {
+	return &serveMetrics{
+		requests:     make(map[requestLabels]uint64),
+		grpcRequests: make(map[grpcRequestLabels]uint64),
+		orderSeconds: newHistogram(0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10),
+		inputBytes:   newHistogram(1<<10, 16<<10, 128<<10, 1<<20, 8<<20, 64<<20),
+		errors:       make(map[string]uint64),
+	}
+}
+
+// サーバーの値（runServe で起動するサーバーが共有する）
+var metrics = newServeMetrics()
+
+// ステータスを記録する ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// リクエストのエンドポイントとステータスを数えてから返す
+func (m *serveMetrics) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// 一致しないパスをそのまま使うと種類が増え続けるため、まとめて数える
+		handler := r.Pattern
+		if handler == "" {
+			handler = "unmatched"
+		}
+		m.mu.Lock()
+		m.requests[requestLabels{handler: handler, code: recorder.status}]++
+		m.mu.Unlock()
+	})
+}
+
+// gRPC のリクエストのメソッドとステータスコードを数える（認証やリクエスト数の制限で断った分も数えるため、最初に呼ぶ）
+func (m *serveMetrics) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	m.mu.Lock()
+	m.grpcRequests[grpcRequestLabels{method: info.FullMethod, code: status.Code(err)}]++
+	m.mu.Unlock()
+	return resp, err
+}
+
+// 1つの入力の並び替えの時間・大きさと、エラーの理由を記録する
+func (m *serveMetrics) observeOrder(size int, start time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orderSeconds.observe(time.Since(start).Seconds())
+	m.inputBytes.observe(float64(size))
+	switch {
+	case err == nil:
+	case errors.Is(err, ddl.ErrCycle):
+		m.cycles++
+		m.errors["cycle"]++
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		m.errors["canceled"]++
+	default:
+		m.errors["other"]++
+	}
+}
+
+// Prometheus のテキスト形式で書き出す
+func (m *serveMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP orderddl_http_requests_total エンドポイントとステータスごとのリクエスト数")
+	fmt.Fprintln(w, "# TYPE orderddl_http_requests_total counter")
+	labels := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].handler != labels[j].handler {
+			return labels[i].handler < labels[j].handler
+		}
+		return labels[i].code < labels[j].code
+	})
+	for _, l := range labels {
+		fmt.Fprintf(w, "orderddl_http_requests_total{handler=%s,code=\"%d\"} %d\n", strconv.Quote(l.handler), l.code, m.requests[l])
+	}
+
+	fmt.Fprintln(w, "# HELP orderddl_grpc_requests_total gRPC のメソッドとステータスコードごとのリクエスト数")
+	fmt.Fprintln(w, "# TYPE orderddl_grpc_requests_total counter")
+	grpcLabels := make([]grpcRequestLabels, 0, len(m.grpcRequests))
+	for l := range m.grpcRequests {
+		grpcLabels = append(grpcLabels, l)
+	}
+	sort.Slice(grpcLabels, func(i, j int) bool {
+		if grpcLabels[i].method != grpcLabels[j].method {
+			return grpcLabels[i].method < grpcLabels[j].method
+		}
+		return grpcLabels[i].code < grpcLabels[j].code
+	})
+	for _, l := range grpcLabels {
+		fmt.Fprintf(w, "orderddl_grpc_requests_total{method=%s,code=%q} %d\n", strconv.Quote(l.method), l.code.String(), m.grpcRequests[l])
+	}
+
+	writeHistogram(w, "orderddl_order_duration_seconds", "入力の解析と並び替えにかかった時間", m.orderSeconds)
+	writeHistogram(w, "orderddl_input_bytes", "並び替えた入力の大きさ", m.inputBytes)
+
+	fmt.Fprintln(w, "# HELP orderddl_cycles_detected_total 入力に循環依存を見つけた回数")
+	fmt.Fprintln(w, "# TYPE orderddl_cycles_detected_total counter")
+	fmt.Fprintf(w, "orderddl_cycles_detected_total %d\n", m.cycles)
+
+	fmt.Fprintln(w, "# HELP orderddl_order_errors_total 理由（cycle, canceled, other）ごとの並び替えのエラーの数")
+	fmt.Fprintln(w, "# TYPE orderddl_order_errors_total counter")
+	for _, reason := range []string{"cycle", "canceled", "other"} {
+		fmt.Fprintf(w, "orderddl_order_errors_total{reason=%q} %d\n", reason, m.errors[reason])
+	}
+}
+
+func writeHistogram(w io.Writer, name, help string, h *histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'f', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// GET /metrics
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	var b strings.Builder
+	metrics.write(&b)
+	io.WriteString(w, b.String())
+}
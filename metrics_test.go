@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ba58ajbse/orderddl/api/orderddlpb"
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestServeMetrics(t *testing.T) {
+	m := newServeMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /order", handleOrder)
+	handler := m.wrap(mux)
+	cyclic := "CREATE TABLE a (b_id int REFERENCES b(id));\nCREATE TABLE b (a_id int REFERENCES a(id));\n"
+	for _, req := range []struct{ target, body string }{
+		{"/order", "CREATE TABLE a (id int);\n"},
+		{"/order", cyclic},
+		{"/unknown/1", ""},
+		{"/unknown/2", ""},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, req.target, strings.NewReader(req.body)))
+	}
+
+	m.observeOrder(100, time.Now(), nil)
+	m.observeOrder(2000, time.Now(), ddl.ErrCycle)
+	m.observeOrder(10, time.Now(), context.Canceled)
+	m.observeOrder(10, time.Now(), errors.New("x"))
+
+	var b strings.Builder
+	m.write(&b)
+	out := b.String()
+	for _, want := range []string{
+		`orderddl_http_requests_total{handler="POST /order",code="200"} 1`,
+		`orderddl_http_requests_total{handler="POST /order",code="422"} 1`,
+		`orderddl_http_requests_total{handler="unmatched",code="404"} 2`,
+		`orderddl_input_bytes_bucket{le="1024"} 3`,
+		`orderddl_input_bytes_bucket{le="+Inf"} 4`,
+		`orderddl_input_bytes_sum 2120`,
+		`orderddl_order_duration_seconds_count 4`,
+		`orderddl_cycles_detected_total 1`,
+		`orderddl_order_errors_total{reason="cycle"} 1`,
+		`orderddl_order_errors_total{reason="canceled"} 1`,
+		`orderddl_order_errors_total{reason="other"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("%q がありません:\n%s", want, out)
+		}
+	}
+}
+
+func TestGRPCMetrics(t *testing.T) {
+	m := newServeMetrics()
+	limits := &serveLimits{tokens: []string{"secret"}}
+	client := newGRPCClient(t, grpc.NewServer(grpc.ChainUnaryInterceptor(m.unaryInterceptor, limits.authInterceptor)))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{Sql: grpcSchema})
+	client.OrderSchema(ctx, &orderddlpb.OrderSchemaRequest{Sql: grpcCyclic})
+	client.OrderSchema(context.Background(), &orderddlpb.OrderSchemaRequest{Sql: grpcSchema})
+
+	var b strings.Builder
+	m.write(&b)
+	out := b.String()
+	for _, want := range []string{
+		`orderddl_grpc_requests_total{method="/orderddl.v1.OrderDDL/OrderSchema",code="OK"} 1`,
+		`orderddl_grpc_requests_total{method="/orderddl.v1.OrderDDL/OrderSchema",code="FailedPrecondition"} 1`,
+		`orderddl_grpc_requests_total{method="/orderddl.v1.OrderDDL/OrderSchema",code="Unauthenticated"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("%q がありません:\n%s", want, out)
+		}
+	}
+}
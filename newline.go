@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 出力の改行コード（processSQL で入力と -newline から決める。空の場合は変換しない）
+var outputNewline string
+
+// 入力で多く使われている改行コードを返す
+func detectNewline(src string) string {
+	crlf := strings.Count(src, "\r\n")
+	if crlf > strings.Count(src, "\n")-crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// -newline の指定から出力の改行コードを決める。
+// 指定がない場合は入力に合わせ、CRLF の入力では並び替えで加わった LF も CRLF にそろえる
+func newlineFor(mode, src string) (string, error) {
+	switch mode {
+	case "":
+		if detectNewline(src) == "\r\n" {
+			return "\r\n", nil
+		}
+		return "", nil
+	case "lf":
+		return "\n", nil
+	case "crlf":
+		return "\r\n", nil
+	}
+	return "", fmt.Errorf("不明な改行コードです: %s", mode)
+}
+
+// 改行コードをそろえる
+func normalizeNewlines(s, newline string) string {
+	if newline == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if newline == "\r\n" {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
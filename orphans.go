@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// orphans サブコマンドを実行する
+func runOrphans(args []string) error {
+	fs := flag.NewFlagSet("orphans", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("使い方: orderddl orphans <schema.sql>")
+	}
+
+	content, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	tables, edges, err := ddl.Edges(strings.NewReader(string(content)), ddl.Options{})
+	if err != nil {
+		return err
+	}
+
+	orphans := ddl.Orphans(tables, edges)
+	if len(orphans) == 0 {
+		fmt.Println("✅ 外部キーの依存関係を持たないテーブルはありません")
+		return nil
+	}
+	fmt.Printf("⚠️ 外部キーの依存関係を持たないテーブル（%d / %d）:\n", len(orphans), len(tables))
+	for _, table := range orphans {
+		fmt.Println("  -", table)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// 解析がこの時間より早く終わる場合は進捗を表示しない
+	progressDelay = time.Second
+	// 端末に表示する場合の更新間隔
+	progressInterval = 200 * time.Millisecond
+	// 端末以外（ログなど）に表示する場合の間隔
+	progressLogInterval = 10 * time.Second
+)
+
+// 大きな入力の解析の進捗を標準エラー出力に表示する
+type progressReporter struct {
+	total    int64
+	start    time.Time
+	last     time.Time
+	terminal bool
+	shown    bool
+}
+
+func newProgressReporter(total int64) *progressReporter {
+	return &progressReporter{
+		total:    total,
+		start:    time.Now(),
+		terminal: term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// ddl.Options.Progress に渡す関数
+func (p *progressReporter) update(read int64, tables int) {
+	now := time.Now()
+	if now.Sub(p.start) < progressDelay {
+		return
+	}
+	interval := progressLogInterval
+	if p.terminal {
+		interval = progressInterval
+	}
+	if p.shown && now.Sub(p.last) < interval {
+		return
+	}
+	p.last, p.shown = now, true
+
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(read) * 100 / float64(p.total)
+	}
+	line := fmt.Sprintf("⏳ 解析中: %5.1f%%（%s / %s、テーブル %d個）", percent, formatBytes(read), formatBytes(p.total), tables)
+	if p.terminal {
+		// 前の表示を消してから上書きする
+		fmt.Fprint(os.Stderr, "\r\033[K"+line)
+	} else {
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
+
+// 端末に表示した進捗の行を消す
+func (p *progressReporter) finish() {
+	if p.shown && p.terminal {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
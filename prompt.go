@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 循環依存が見つかった場合に、作成順序の判断から除外する依存関係を端末で選ばせる（選んだ外部キーは -fix と同じように末尾の ALTER TABLE に移す）
+type cyclePrompt struct {
+	in *bufio.Reader
+	// 選ばれた依存関係（出力をもう一度並び替える場合などは同じものを選ぶ）
+	chosen []ddl.Edge
+}
+
+// 標準入力と標準エラー出力が端末の場合だけ cyclePrompt を返す
+func newCyclePrompt() *cyclePrompt {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return &cyclePrompt{in: bufio.NewReader(os.Stdin)}
+}
+
+// ddl.Options.ResolveCycle に渡す関数
+func (p *cyclePrompt) resolve(cycle []string, candidates []ddl.Edge) (int, error) {
+	for i, candidate := range candidates {
+		for _, edge := range p.chosen {
+			if sameEdge(candidate, edge) {
+				return i, nil
+			}
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "⚠️ 循環依存が見つかりました:", strings.Join(cycle, ", "))
+	for i, edge := range candidates {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, describeEdge(edge))
+		if edge.ForeignKey.Text != "" {
+			fmt.Fprintf(os.Stderr, "     %s\n", strings.Join(strings.Fields(edge.ForeignKey.Text), " "))
+		}
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "作成順序の判断から除外する依存関係の番号を入力してください（1-%d、空の場合は中止）: ", len(candidates))
+		line, err := p.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err == nil {
+				fmt.Fprintln(os.Stderr, "ℹ️ 中止しました")
+			} else {
+				fmt.Fprintln(os.Stderr)
+			}
+			return -1, ddl.ErrCycle
+		}
+		n, convErr := strconv.Atoi(answer)
+		if convErr == nil && n >= 1 && n <= len(candidates) {
+			p.chosen = append(p.chosen, candidates[n-1])
+			return n - 1, nil
+		}
+		fmt.Fprintln(os.Stderr, "❌ 正しい番号ではありません:", answer)
+		if err != nil {
+			return -1, ddl.ErrCycle
+		}
+	}
+}
+
+// 依存関係を「子 → 親」の形式で表す
+func describeEdge(edge ddl.Edge) string {
+	if edge.Hint {
+		return fmt.Sprintf("%s → %s（orderddl:after / orderddl:before の指定）", edge.Child, edge.Parent)
+	}
+	if edge.Extra {
+		return fmt.Sprintf("%s → %s（-extra-dep の指定）", edge.Child, edge.Parent)
+	}
+	return edge.ForeignKey.String()
+}
+
+func sameEdge(a, b ddl.Edge) bool {
+	return a.Parent == b.Parent && a.Child == b.Child && a.Hint == b.Hint && a.Extra == b.Extra && a.ForeignKey.Text == b.ForeignKey.Text
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -report で書き出す並び替えの結果
+type moveReport struct {
+	Tables int              `json:"tables"`
+	Moved  int              `json:"moved"`
+	Moves  []ddl.Relocation `json:"moves"`
+}
+
+// 出力を書き出した後に、入力から動かしたテーブルとその理由を表示する（-report の場合はJSONで書き出す）
+func reportMoves(result *ddl.Result, sortedTables []string, minimalMoves bool) error {
+	relocations := ddl.Relocations(result.Tables, sortedTables, result.Graph)
+
+	if *reportOut != "" {
+		report := moveReport{Tables: len(sortedTables), Moved: len(relocations), Moves: relocations}
+		if report.Moves == nil {
+			report.Moves = []ddl.Relocation{}
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportOut, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+		}
+		fmt.Println("✅ 並び替えの結果をJSONで出力しました:", *reportOut)
+		return nil
+	}
+
+	// -minimal-moves では動かしたテーブルを表示済み
+	if minimalMoves {
+		return nil
+	}
+	if len(relocations) == 0 {
+		fmt.Println("📊 テーブルの順序は入力のままです")
+		return nil
+	}
+	fmt.Printf("📊 %d個のテーブルのうち%d個を動かしました\n", len(sortedTables), len(relocations))
+	for _, r := range relocations {
+		var reasons []string
+		if len(r.DependsOn) > 0 {
+			reasons = append(reasons, strings.Join(r.DependsOn, ", ")+" に依存")
+		}
+		if len(r.RequiredBy) > 0 {
+			reasons = append(reasons, strings.Join(r.RequiredBy, ", ")+" から依存")
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "並び順の指定")
+		}
+		fmt.Printf("🚚 %s: %d番目 → %d番目（%s）\n", r.Table, r.From, r.To, strings.Join(reasons, "、"))
+	}
+	return nil
+}
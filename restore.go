@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -restore: 並び替えた出力のブロックを、ソースマップに記録した並び替える前の順に戻す
+func runRestore(input, output, mapPath string) error {
+	if input == "" {
+		return errors.New("`-i` で並び替えた出力のファイルを指定してください")
+	}
+	data, err := os.ReadFile(mapPath)
+	if err != nil {
+		return fmt.Errorf("ソースマップを読み込めませんでした: %w", err)
+	}
+	var entries []ddl.SourceMapEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("ソースマップを解析できませんでした: %s: %w", mapPath, err)
+	}
+
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	src, enc, err := decodeInput(content, *inputEnc)
+	if err != nil {
+		return err
+	}
+	if outputEncoding, err = outputEncodingFor(*outputEnc, enc); err != nil {
+		return err
+	}
+	if outputNewline, err = newlineFor(*newline, src); err != nil {
+		return err
+	}
+
+	restored, err := ddl.Restore(src, entries)
+	if err != nil {
+		return err
+	}
+	if err := writeEncoded(output, restored); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %d個のブロックを並び替える前の順に戻しました: %s\n", len(entries), output)
+	return nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// POST /order のJSONリクエスト
+type orderRequest struct {
+	SQL string `json:"sql"`
+	requestOptions
+}
+
+// リクエストで指定できる並び替えのオプション（CLI の同じ名前のフラグに対応し、指定しない項目は ddl.Options の既定値）
+type requestOptions struct {
+	SoftConstraints string   `json:"soft_constraints,omitempty"`
+	Dialect         string   `json:"dialect,omitempty"`
+	SearchPath      []string `json:"search_path,omitempty"`
+	Views           string   `json:"views,omitempty"`
+	Temporary       string   `json:"temporary,omitempty"`
+}
+
+// POST /order のJSONレスポンス
+type orderResponse struct {
+	SQL   string `json:"sql,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// POST /order/batch のJSONリクエスト
+type batchRequest struct {
+	Files []batchFile `json:"files"`
+	requestOptions
+}
+
+type batchFile struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+}
+
+// POST /order/batch のJSONレスポンス
+type batchResponse struct {
+	// ファイルごとに並び替えた結果（リクエストと同じ順）
+	Files []batchFileResult `json:"files"`
+	// ファイルを適用する順と、すべてのファイルをまとめたテーブルの作成順序
+	Order  []string `json:"order,omitempty"`
+	Tables []string `json:"tables,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type batchFileResult struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql,omitempty"`
+	// ファイルで定義されたテーブル
+	Tables []string `json:"tables"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// GET /openapi.json で公開する API の定義
+//
+//go:embed api/openapi.json
+var openAPIDocument []byte
+
+// HTTPサーバーモードを起動する
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "待ち受けるアドレス")
+	grpcListen := fs.String("grpc-listen", "", "gRPCサーバーを待ち受けるアドレス（空の場合は起動しない）")
+	tokenFile := fs.String("token-file", "", "受け付けるトークン（1行に1つ）のファイル。指定した場合は Authorization: Bearer（gRPC では authorization メタデータ）のトークンが一致するリクエストだけを受け付ける")
+	maxBody := fs.Int64("max-body", 10<<20, "リクエストの本文（gRPC では受け取るメッセージ）の最大のバイト数（0 の場合は制限しない）")
+	rateLimit := fs.Float64("rate-limit", 0, "クライアント（送信元の IP アドレス）ごとの1分あたりのリクエスト数の上限（0 の場合は制限しない）")
+	burst := fs.Int("burst", 10, "-rate-limit で、続けて受け付けられるリクエスト数")
+	readHeaderTimeout := fs.Duration("read-header-timeout", 10*time.Second, "HTTPのリクエストのヘッダーを読み込む制限時間（0 の場合は -read-timeout と同じ）")
+	readTimeout := fs.Duration("read-timeout", time.Minute, "HTTPのリクエストを本文まで読み込む制限時間（0 の場合は制限しない）")
+	idleTimeout := fs.Duration("idle-timeout", 2*time.Minute, "キープアライブの接続で次のリクエストを待つ時間（0 の場合は -read-timeout と同じ）")
+	fs.Parse(args)
+
+	tokens, err := readTokens(*tokenFile)
+	if err != nil {
+		return err
+	}
+	if *rateLimit < 0 || *burst < 1 || *maxBody < 0 {
+		return errors.New("-rate-limit と -max-body は 0 以上、-burst は 1 以上で指定してください")
+	}
+	if *readHeaderTimeout < 0 || *readTimeout < 0 || *idleTimeout < 0 {
+		return errors.New("-read-header-timeout、-read-timeout と -idle-timeout は 0 以上で指定してください")
+	}
+	limits := &serveLimits{tokens: tokens, maxBody: *maxBody, rate: *rateLimit / 60, burst: *burst}
+	if len(tokens) == 0 && (!loopbackAddress(*listen) || *grpcListen != "" && !loopbackAddress(*grpcListen)) {
+		fmt.Fprintln(os.Stderr, "⚠️ 警告: -token-file を指定していないため、認証せずにリクエストを受け付けます")
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	errc := make(chan error, 2)
+	if *grpcListen != "" {
+		go func() { errc <- serveGRPC(ctx, *grpcListen, limits) }()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /order", handleOrder)
+	mux.HandleFunc("POST /order/batch", handleOrderBatch)
+	mux.HandleFunc("GET /openapi.json", handleOpenAPI)
+	mux.HandleFunc("GET /metrics", handleMetrics)
+	// 遅いクライアントが接続を持ち続けないよう、読み込みと待ち受けの時間を制限する
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           metrics.wrap(limits.wrap(mux)),
+		ReadHeaderTimeout: *readHeaderTimeout,
+		ReadTimeout:       *readTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+
+	go func() {
+		fmt.Println("🚀 HTTPサーバーを起動しました:", *listen)
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 処理中のリクエストを待ってから止める
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("HTTPサーバーを停止できませんでした: %w", err)
+	}
+	fmt.Println("👋 サーバーを停止しました")
+	return nil
+}
+
+// 停止するときに処理中のリクエストを待つ時間
+const serveShutdownTimeout = 10 * time.Second
+
+// SQLを受け取り、依存関係の順に並び替えたSQLを返す
+func handleOrder(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	asJSON := mediaType == "application/json"
+
+	// オプションはクエリパラメータで指定し、JSONの場合は本文の指定を優先する
+	req := orderRequest{requestOptions: queryOptions(r.URL.Query())}
+	var src string
+	if asJSON {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeOrderError(w, asJSON, requestErrorStatus(err), fmt.Errorf("リクエストを解析できませんでした: %w", err))
+			return
+		}
+		src = req.SQL
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeOrderError(w, asJSON, requestErrorStatus(err), fmt.Errorf("リクエストを読み込めませんでした: %w", err))
+			return
+		}
+		src = string(body)
+	}
+
+	opts, err := req.options()
+	if err != nil {
+		writeOrderError(w, asJSON, http.StatusBadRequest, err)
+		return
+	}
+
+	start := time.Now()
+	ordered, err := ddl.OrderContext(r.Context(), src, opts)
+	metrics.observeOrder(len(src), start, err)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ddl.ErrCycle) {
+			status = http.StatusUnprocessableEntity
+		}
+		writeOrderError(w, asJSON, status, err)
+		return
+	}
+
+	if asJSON {
+		writeJSON(w, http.StatusOK, orderResponse{SQL: ordered})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, ordered)
+}
+
+// 名前を付けた複数のファイルを受け取り、ファイルごとに並び替えた結果と、ファイル間の依存関係による適用順を返す
+func handleOrderBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, requestErrorStatus(err), batchResponse{Error: fmt.Sprintf("リクエストを解析できませんでした: %v", err)})
+		return
+	}
+	if len(req.Files) == 0 {
+		writeJSON(w, http.StatusBadRequest, batchResponse{Error: "files にファイルを指定してください"})
+		return
+	}
+	opts, err := req.options()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, batchResponse{Error: err.Error()})
+		return
+	}
+
+	resp := batchResponse{Files: make([]batchFileResult, 0, len(req.Files))}
+	entries := make([]archiveEntry, 0, len(req.Files))
+	seen := make(map[string]bool)
+	status := http.StatusOK
+	for _, file := range req.Files {
+		if file.Name == "" || seen[file.Name] {
+			writeJSON(w, http.StatusBadRequest, batchResponse{Error: fmt.Sprintf("ファイルの名前が空か、重複しています: %q", file.Name)})
+			return
+		}
+		seen[file.Name] = true
+
+		result := batchFileResult{Name: file.Name, Tables: []string{}}
+		start := time.Now()
+		tables, _, err := ddl.Edges(strings.NewReader(file.SQL), opts)
+		if err == nil {
+			result.Tables = tables
+			result.SQL, err = ddl.OrderContext(r.Context(), file.SQL, opts)
+		}
+		metrics.observeOrder(len(file.SQL), start, err)
+		if err != nil {
+			result.Error = err.Error()
+			status = batchStatus(status, err)
+		}
+		resp.Files = append(resp.Files, result)
+		entries = append(entries, archiveEntry{name: file.Name, src: file.SQL, tables: result.Tables})
+	}
+
+	// すべてのファイルをつなげて、ファイルをまたぐ依存関係から適用順を決める
+	joined := joinEntries(entries)
+	start := time.Now()
+	result, err := ddl.AnalyzeContext(r.Context(), joined, opts)
+	metrics.observeOrder(len(joined), start, err)
+	if err == nil {
+		resp.Tables = result.Sorted
+		if resp.Order, err = orderEntries(entries, result.Graph); err != nil {
+			err = fmt.Errorf("ファイル間で%w", err)
+		}
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		status = batchStatus(status, err)
+	}
+	writeJSON(w, status, resp)
+}
+
+// クエリパラメータからオプションを読み取る（search_path はカンマ区切り）
+func queryOptions(query url.Values) requestOptions {
+	return requestOptions{
+		SoftConstraints: query.Get("soft_constraints"),
+		Dialect:         query.Get("dialect"),
+		SearchPath:      strings.FieldsFunc(query.Get("search_path"), func(r rune) bool { return r == ',' || r == ' ' }),
+		Views:           query.Get("views"),
+		Temporary:       query.Get("temporary"),
+	}
+}
+
+// 指定されたオプションを ddl.Options にする
+func (o requestOptions) options() (ddl.Options, error) {
+	softConstraints, err := ddl.ParseSoftConstraintPolicy(o.SoftConstraints)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	viewPolicy, err := ddl.ParseViewPolicy(o.Views)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	temporaryPolicy, err := ddl.ParseTemporaryPolicy(o.Temporary)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	var dialect ddl.Dialect
+	if o.Dialect != "" {
+		if dialect, err = ddl.LookupDialect(o.Dialect); err != nil {
+			return ddl.Options{}, err
+		}
+	}
+	return ddl.Options{SoftConstraints: softConstraints, Dialect: dialect, SearchPath: o.SearchPath, Views: viewPolicy, Temporary: temporaryPolicy}, nil
+}
+
+// これまでのステータスとエラーから、バッチのレスポンスのステータスを決める（循環依存は 422）
+func batchStatus(status int, err error) int {
+	if status == http.StatusInternalServerError || !errors.Is(err, ddl.ErrCycle) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// API の OpenAPI の定義を返す
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// エラーをリクエストと同じ形式で返す
+func writeOrderError(w http.ResponseWriter, asJSON bool, status int, err error) {
+	if asJSON {
+		writeJSON(w, status, orderResponse{Error: err.Error()})
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleOrder(t *testing.T) {
+	const schema = "CREATE TEMPORARY TABLE t (id int);\nCREATE TABLE b (id int, a_id int REFERENCES a(id));\nCREATE TABLE a (id int);\n"
+	body, _ := json.Marshal(map[string]any{"sql": schema, "dialect": "postgres", "temporary": "exclude"})
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		status      int
+		want        string
+	}{
+		{name: "テキスト", target: "/order", body: "CREATE TABLE b (a_id int REFERENCES a(id));\nCREATE TABLE a (id int);\n", status: http.StatusOK, want: "CREATE TABLE a (id int);\nCREATE TABLE b (a_id int REFERENCES a(id));\n"},
+		{name: "JSON のオプション", target: "/order", contentType: "application/json", body: string(body), status: http.StatusOK, want: "CREATE TABLE a (id int);\nCREATE TABLE b (id int, a_id int REFERENCES a(id));\n"},
+		{name: "クエリパラメータのオプション", target: "/order?temporary=exclude", body: schema, status: http.StatusOK, want: "CREATE TABLE a (id int);\nCREATE TABLE b (id int, a_id int REFERENCES a(id));\n"},
+		{name: "不明な方言", target: "/order?dialect=unknown", body: schema, status: http.StatusBadRequest},
+		{name: "不明な views の指定", target: "/order", contentType: "application/json", body: `{"sql": "", "views": "bogus"}`, status: http.StatusBadRequest},
+		{name: "循環依存", target: "/order", body: "CREATE TABLE a (b_id int REFERENCES b(id));\nCREATE TABLE b (a_id int REFERENCES a(id));\n", status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handleOrder(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want == "" {
+				return
+			}
+			got := w.Body.String()
+			if tt.contentType == "application/json" {
+				var resp orderResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				got = resp.SQL
+			}
+			if got != tt.want {
+				t.Errorf("SQL =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleOrderBatch(t *testing.T) {
+	body := `{"search_path": ["app"], "files": [` +
+		`{"name": "orders.sql", "sql": "CREATE TABLE app.orders (id int, u int REFERENCES users(id));\n"},` +
+		`{"name": "users.sql", "sql": "CREATE TABLE app.users (id int);\n"}]}`
+	w := httptest.NewRecorder()
+	handleOrderBatch(w, httptest.NewRequest(http.MethodPost, "/order/batch", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp batchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(resp.Order, ","); got != "users.sql,orders.sql" {
+		t.Errorf("Order = %s, want users.sql,orders.sql", got)
+	}
+	if got := strings.Join(resp.Tables, ","); got != "app.users,app.orders" {
+		t.Errorf("Tables = %s, want app.users,app.orders", got)
+	}
+}
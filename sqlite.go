@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// SQLite のデータベースファイルの先頭の16バイト
+const sqliteMagic = "SQLite format 3\x00"
+
+// 内容が SQLite のデータベースファイルかどうか
+func isSQLiteDatabase(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(sqliteMagic))
+}
+
+// SQLite のデータベースファイルの sqlite_master から CREATE 文を取り出し、保存された順につなげる。
+// インデックスとトリガーは対象のテーブルのブロックに含まれるように、テーブルの CREATE 文の直後に置く。
+// 自動で作られるインデックス（SQL が NULL のもの）と sqlite_ で始まる内部のテーブルは含めない。
+// ドライバを使わずにファイルの形式を直接読むため、WAL にだけある変更は含まれない
+func readSQLiteSchema(path string, content []byte) (string, error) {
+	db, err := newSQLiteFile(content)
+	if err != nil {
+		return "", fmt.Errorf("SQLite のデータベースを読み込めませんでした: %w", err)
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() > 0 {
+		fmt.Fprintln(os.Stderr, "⚠️ 警告: WAL ファイルがあります。チェックポイントされていないスキーマの変更は含まれません:", path+"-wal")
+	}
+
+	type object struct{ kind, name, table, sql string }
+	var objects []object
+	err = db.walkTable(1, func(record []any) error {
+		// sqlite_master の列は type, name, tbl_name, rootpage, sql
+		if len(record) < 5 {
+			return errors.New("sqlite_master の行の列が足りません")
+		}
+		var o object
+		o.kind, _ = record[0].(string)
+		o.name, _ = record[1].(string)
+		o.table, _ = record[2].(string)
+		o.sql, _ = record[4].(string)
+		if o.sql != "" && !strings.HasPrefix(strings.ToLower(o.name), "sqlite_") {
+			o.sql = strings.TrimRight(o.sql, " \t\r\n;") + ";\n"
+			objects = append(objects, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("SQLite のデータベースを読み込めませんでした: %w", err)
+	}
+
+	// テーブルごとのインデックスとトリガー
+	attached := make(map[string][]string)
+	tables := make(map[string]bool)
+	for _, o := range objects {
+		if o.kind == "table" {
+			tables[strings.ToLower(o.name)] = true
+		}
+	}
+	for _, o := range objects {
+		if table := strings.ToLower(o.table); (o.kind == "index" || o.kind == "trigger") && tables[table] {
+			attached[table] = append(attached[table], o.sql)
+		}
+	}
+	var out strings.Builder
+	for _, o := range objects {
+		switch {
+		case o.kind == "table":
+			out.WriteString(o.sql)
+			for _, sql := range attached[strings.ToLower(o.name)] {
+				out.WriteString(sql)
+			}
+		case (o.kind == "index" || o.kind == "trigger") && tables[strings.ToLower(o.table)]:
+		default:
+			out.WriteString(o.sql)
+		}
+	}
+	return out.String(), nil
+}
+
+// SQLite のデータベースファイルの内容
+type sqliteFile struct {
+	data []byte
+	// ページの大きさと、ページの末尾の予約領域を除いた大きさ
+	pageSize, usable int
+	// テキストの文字コード（1: UTF-8, 2: UTF-16le, 3: UTF-16be）
+	encoding uint32
+}
+
+func newSQLiteFile(data []byte) (*sqliteFile, error) {
+	if len(data) < 100 || !isSQLiteDatabase(data) {
+		return nil, errors.New("SQLite のデータベースファイルではありません")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("ページの大きさが正しくありません: %d", pageSize)
+	}
+	encoding := binary.BigEndian.Uint32(data[56:60])
+	if encoding == 0 {
+		encoding = 1
+	}
+	return &sqliteFile{data: data, pageSize: pageSize, usable: pageSize - int(data[20]), encoding: encoding}, nil
+}
+
+// 1始まりのページ番号のページの内容
+func (f *sqliteFile) page(number uint32) ([]byte, error) {
+	start := int(number-1) * f.pageSize
+	if number == 0 || start+f.pageSize > len(f.data) {
+		return nil, fmt.Errorf("ページ %d がファイルにありません", number)
+	}
+	return f.data[start : start+f.pageSize], nil
+}
+
+// root から始まるテーブルの B-tree の行を rowid の順に読む
+func (f *sqliteFile) walkTable(root uint32, visit func([]any) error) error {
+	return f.walkPage(root, visit, 0)
+}
+
+func (f *sqliteFile) walkPage(number uint32, visit func([]any) error, depth int) error {
+	// 壊れたファイルで循環しないように、B-tree の深さを制限する
+	if depth > 64 {
+		return errors.New("B-tree が深すぎます")
+	}
+	page, err := f.page(number)
+	if err != nil {
+		return err
+	}
+	// 最初のページはファイルのヘッダーの後ろから始まる
+	header := 0
+	if number == 1 {
+		header = 100
+	}
+	if header+8 > len(page) {
+		return fmt.Errorf("ページ %d が壊れています", number)
+	}
+	kind := page[header]
+	cells := int(binary.BigEndian.Uint16(page[header+3 : header+5]))
+	pointers := header + 8
+	if kind == 0x05 {
+		pointers = header + 12
+	}
+	if pointers+2*cells > len(page) {
+		return fmt.Errorf("ページ %d が壊れています", number)
+	}
+
+	switch kind {
+	case 0x05:
+		// 内部ページ: 各セルは左の子ページと rowid、最後に右端の子ページ
+		for i := range cells {
+			offset := int(binary.BigEndian.Uint16(page[pointers+2*i:]))
+			if offset+4 > len(page) {
+				return fmt.Errorf("ページ %d が壊れています", number)
+			}
+			if err := f.walkPage(binary.BigEndian.Uint32(page[offset:]), visit, depth+1); err != nil {
+				return err
+			}
+		}
+		return f.walkPage(binary.BigEndian.Uint32(page[header+8:]), visit, depth+1)
+	case 0x0d:
+		// 葉ページ: 各セルはペイロードの大きさ、rowid、ペイロード（入りきらない部分はオーバーフローページ）
+		for i := range cells {
+			offset := int(binary.BigEndian.Uint16(page[pointers+2*i:]))
+			payload, err := f.cellPayload(page, offset)
+			if err != nil {
+				return fmt.Errorf("ページ %d: %w", number, err)
+			}
+			record, err := f.decodeRecord(payload)
+			if err != nil {
+				return fmt.Errorf("ページ %d: %w", number, err)
+			}
+			if err := visit(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("ページ %d はテーブルの B-tree のページではありません（種類 %d）", number, kind)
+}
+
+// 葉ページのセルのペイロード（オーバーフローページの内容をつなげる）
+func (f *sqliteFile) cellPayload(page []byte, offset int) ([]byte, error) {
+	if offset >= len(page) {
+		return nil, errors.New("セルの位置が正しくありません")
+	}
+	size, n := sqliteVarint(page[offset:])
+	offset += n
+	_, rowid := sqliteVarint(page[offset:])
+	offset += rowid
+	// 壊れた大きさを int にすると負になることがあるため、ファイルより大きい値は先に除く
+	if n == 0 || rowid == 0 || size > uint64(len(f.data)) {
+		return nil, errors.New("セルが壊れています")
+	}
+
+	// ページに収まるペイロードの大きさ
+	total := int(size)
+	maxLocal := f.usable - 35
+	local := total
+	if total > maxLocal {
+		minLocal := (f.usable-12)*32/255 - 23
+		local = minLocal + (total-minLocal)%(f.usable-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if offset+local > len(page) {
+		return nil, errors.New("セルが壊れています")
+	}
+	payload := append([]byte{}, page[offset:offset+local]...)
+	if local == total {
+		return payload, nil
+	}
+
+	if offset+local+4 > len(page) {
+		return nil, errors.New("セルが壊れています")
+	}
+	next := binary.BigEndian.Uint32(page[offset+local:])
+	for pages := 0; len(payload) < total; pages++ {
+		if next == 0 || pages > len(f.data)/f.pageSize {
+			return nil, errors.New("オーバーフローページが壊れています")
+		}
+		overflow, err := f.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = binary.BigEndian.Uint32(overflow)
+		chunk := overflow[4:f.usable]
+		if remaining := total - len(payload); len(chunk) > remaining {
+			chunk = chunk[:remaining]
+		}
+		payload = append(payload, chunk...)
+	}
+	return payload, nil
+}
+
+// レコードの形式のペイロードを列の値（nil, int64, float64, string, []byte）にする
+func (f *sqliteFile) decodeRecord(payload []byte) ([]any, error) {
+	headerSize, n := sqliteVarint(payload)
+	// 大きさは int にする前に uint64 のまま比べる（int にすると負になることがある）
+	if n == 0 || headerSize < uint64(n) || headerSize > uint64(len(payload)) {
+		return nil, errors.New("レコードのヘッダーが壊れています")
+	}
+	var types []uint64
+	for pos := n; pos < int(headerSize); {
+		t, n := sqliteVarint(payload[pos:])
+		if n == 0 {
+			return nil, errors.New("レコードのヘッダーが壊れています")
+		}
+		types = append(types, t)
+		pos += n
+	}
+
+	body := payload[headerSize:]
+	values := make([]any, 0, len(types))
+	for _, t := range types {
+		size := sqliteValueSize(t)
+		if size > uint64(len(body)) {
+			return nil, errors.New("レコードが壊れています")
+		}
+		value := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t <= 6:
+			// 符号付きのビッグエンディアンの整数
+			v := int64(int8(value[0]))
+			for _, b := range value[1:] {
+				v = v<<8 | int64(b)
+			}
+			values = append(values, v)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(value)))
+		case t == 8 || t == 9:
+			values = append(values, int64(t-8))
+		case t >= 12 && t%2 == 0:
+			values = append(values, append([]byte{}, value...))
+		case t >= 13:
+			values = append(values, f.decodeText(value))
+		default:
+			return nil, fmt.Errorf("不明な列の型です: %d", t)
+		}
+	}
+	return values, nil
+}
+
+// データベースの文字コードのテキストを文字列にする
+func (f *sqliteFile) decodeText(value []byte) string {
+	if f.encoding == 1 {
+		return string(value)
+	}
+	units := make([]uint16, len(value)/2)
+	for i := range units {
+		if f.encoding == 2 {
+			units[i] = binary.LittleEndian.Uint16(value[2*i:])
+		} else {
+			units[i] = binary.BigEndian.Uint16(value[2*i:])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// 列の型の値のバイト数
+func sqliteValueSize(t uint64) uint64 {
+	switch {
+	case t <= 4:
+		return t
+	case t == 5:
+		return 6
+	case t == 6 || t == 7:
+		return 8
+	case t >= 12:
+		return (t - 12) / 2
+	}
+	return 0
+}
+
+// SQLite の可変長の整数（1〜9バイト）と読んだバイト数（読めない場合は 0）
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
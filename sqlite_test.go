@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestReadSQLiteSchema(t *testing.T) {
+	// testdata/schema.sqlite はページサイズ 512 で作成し、orders の CREATE 文はオーバーフローページにまたがる
+	path := "testdata/schema.sqlite"
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isSQLiteDatabase(content) {
+		t.Fatal("SQLite のデータベースファイルと判定されませんでした")
+	}
+	if isSQLiteDatabase([]byte("CREATE TABLE a (id int);\n")) {
+		t.Error("SQL のファイルが SQLite のデータベースファイルと判定されました")
+	}
+
+	src, err := readSQLiteSchema(path, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContainsInOrder(t, src,
+		"CREATE TABLE orders (", "  note_39 text\n);",
+		"CREATE INDEX ix_orders_user ON orders (user_id);",
+		"CREATE TABLE users", "CREATE TRIGGER tr_users")
+	if strings.Contains(src, "sqlite_autoindex") {
+		t.Errorf("自動で作られたインデックスが含まれています:\n%s", src)
+	}
+
+	out, err := ddl.Order(src, ddl.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertContainsInOrder(t, out, "CREATE TABLE users", "CREATE TRIGGER tr_users", "CREATE TABLE orders", "CREATE INDEX ix_orders_user")
+}
+
+// s に parts がこの順に現れることを確かめる
+func assertContainsInOrder(t *testing.T, s string, parts ...string) {
+	t.Helper()
+	rest := s
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			t.Fatalf("%q が順に含まれていません:\n%s", part, s)
+		}
+		rest = rest[i+len(part):]
+	}
+}
+
+func TestSQLiteCorruptVarints(t *testing.T) {
+	f := &sqliteFile{data: make([]byte, 4096), pageSize: 512, usable: 512, encoding: 1}
+	// int にすると負になる9バイトの可変長整数
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "ヘッダーの大きさ", payload: huge},
+		{name: "列の型", payload: append([]byte{10}, huge...)},
+	}
+	for _, tt := range tests {
+		if _, err := f.decodeRecord(tt.payload); err == nil {
+			t.Errorf("%s が壊れたレコードでエラーになりませんでした", tt.name)
+		}
+	}
+
+	page := make([]byte, 512)
+	copy(page, append(huge, 1))
+	if _, err := f.cellPayload(page, 0); err == nil {
+		t.Error("ペイロードの大きさが壊れたセルでエラーになりませんでした")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -stats で解析・並び替え・出力にかかった時間、メモリ使用量と入力の規模を標準エラー出力に表示する
+func printStats(result *ddl.Result, writeTime time.Duration) {
+	edges := 0
+	for _, children := range result.Graph {
+		edges += len(children)
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fmt.Fprintln(os.Stderr, "📊 統計:")
+	fmt.Fprintf(os.Stderr, "  解析:         %s\n", result.Stats.ParseTime.Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "  並び替え:     %s\n", result.Stats.SortTime.Round(time.Microsecond))
+	fmt.Fprintf(os.Stderr, "  出力:         %s\n", writeTime.Round(time.Microsecond))
+	if peak, ok := peakRSS(); ok {
+		fmt.Fprintf(os.Stderr, "  最大メモリ:   %s（ヒープの累計確保 %s）\n", formatBytes(peak), formatBytes(int64(mem.TotalAlloc)))
+	} else {
+		fmt.Fprintf(os.Stderr, "  最大メモリ:   %s（OS から確保した量、ヒープの累計確保 %s）\n", formatBytes(int64(mem.Sys)), formatBytes(int64(mem.TotalAlloc)))
+	}
+	fmt.Fprintf(os.Stderr, "  テーブル:     %d\n", len(result.Tables))
+	fmt.Fprintf(os.Stderr, "  依存関係:     %d\n", edges)
+	fmt.Fprintf(os.Stderr, "  文:           %d\n", result.Stats.Statements)
+	if result.Stats.Statements > 0 {
+		fmt.Fprintf(os.Stderr, "  最大の文:     %s（%d行目）\n", formatBytes(int64(result.Stats.LargestStatement)), result.Stats.LargestStatementLine)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package main
+
+// 最大常駐メモリを取得できない環境では runtime.MemStats の値を使う
+func peakRSS() (int64, bool) { return 0, false }
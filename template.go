@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -template のテンプレートに渡す、解析したスキーマ
+type templateData struct {
+	// 入力ファイルと方言
+	Input   string
+	Dialect string
+	// 入力に現れた順のテーブル
+	Tables []templateTable
+	// 作成順のテーブル名
+	Order []string
+	// 作成順序を決めた依存関係
+	Edges []auditEdge
+	// テーブルごとの段数（依存先をたどった最長の段数）
+	Levels map[string]int
+}
+
+type templateTable struct {
+	ddl.Table
+	// 作成順での位置（1始まり）と段数
+	Position int
+	Level    int
+	// 直接依存するテーブルと、直接依存されるテーブル（名前順）
+	Dependencies []string
+	Dependents   []string
+}
+
+// テンプレートで使える関数
+var templateFuncs = template.FuncMap{
+	"join":    strings.Join,
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": strings.ReplaceAll,
+	"reverse": func(list []string) []string {
+		reversed := slices.Clone(list)
+		slices.Reverse(reversed)
+		return reversed
+	},
+}
+
+// 解析した結果をテンプレートに渡して、出力先に書き出す
+func renderTemplate(ctx context.Context, src, input, output, path string, opts ddl.Options) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("テンプレートを読み込めませんでした: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("テンプレートを解析できませんでした: %w", err)
+	}
+
+	result, err := ddl.AnalyzeContext(ctx, src, opts)
+	if err != nil {
+		return err
+	}
+	tables, err := ddl.TablesWithOptions(strings.NewReader(src), opts)
+	if err != nil {
+		return err
+	}
+	graph := result.DependencyGraph()
+	levels := ddl.Levels(result.Sorted, result.Graph)
+	// 依存先のないテーブルも 0 段として含める
+	for _, table := range result.Sorted {
+		if _, exists := levels[table]; !exists {
+			levels[table] = 0
+		}
+	}
+
+	data := templateData{Input: input, Dialect: *dialectName, Order: result.Sorted, Edges: auditEdges(result.Edges), Levels: levels}
+	for _, name := range result.Tables {
+		table := templateTable{
+			Table:        ddl.Table{Name: name},
+			Position:     slices.Index(result.Sorted, name) + 1,
+			Level:        levels[name],
+			Dependencies: graph.Dependencies(name),
+			Dependents:   graph.Dependents(name),
+		}
+		for _, t := range tables {
+			if t.Name == name {
+				table.Table = *t
+				break
+			}
+		}
+		data.Tables = append(data.Tables, table)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("テンプレートを実行できませんでした: %w", err)
+	}
+	if err := os.WriteFile(output, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("出力ファイルを作成できませんでした: %w", err)
+	}
+	fmt.Println("✅ テンプレートで出力しました:", output)
+	return nil
+}
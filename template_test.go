@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	dir := t.TempDir()
+	src := "CREATE TABLE c (id int, p_id int REFERENCES p(id));\nCREATE TABLE p (id int PRIMARY KEY, name text);\n"
+	tmpl := filepath.Join(dir, "vars.tmpl")
+	content := `order: {{ join .Order "," }}
+drop: {{ join (reverse .Order) "," }}
+{{ range .Tables }}{{ upper .Name }} {{ .Position }}/{{ .Level }} columns={{ len .Columns }} deps=[{{ join .Dependencies "," }}] dependents=[{{ join .Dependents "," }}]
+{{ end }}{{ range .Edges }}{{ .Child }}->{{ .Parent }} {{ .Kind }}
+{{ end }}`
+	if err := os.WriteFile(tmpl, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "vars.yml")
+	if err := renderTemplate(context.Background(), src, "schema.sql", output, tmpl, ddl.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "order: p,c\ndrop: c,p\n" +
+		"C 2/1 columns=2 deps=[p] dependents=[]\n" +
+		"P 1/0 columns=2 deps=[] dependents=[c]\n" +
+		"c->p foreign_key\n"
+	if string(data) != want {
+		t.Errorf("テンプレートの結果 =\n%s\nwant\n%s", data, want)
+	}
+
+	// 存在しないフィールドはエラーにする
+	if err := os.WriteFile(tmpl, []byte("{{ .Missing }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := renderTemplate(context.Background(), src, "schema.sql", output, tmpl, ddl.Options{}); err == nil {
+		t.Error("存在しないフィールドでエラーになりませんでした")
+	}
+}
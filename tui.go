@@ -0,0 +1,406 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// 画面表示に使うエスケープシーケンス
+const (
+	ansiReset   = "\x1b[0m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiDim     = "\x1b[2m"
+)
+
+// TUIの1行分の表示内容
+type tuiRow struct {
+	table  string // 行が指すテーブル
+	depth  int    // 0: テーブル行, 1: 見出し行, 2: 依存関係の行
+	header string // 見出し行の文言
+}
+
+// TUIモードの状態
+type tuiState struct {
+	tables     []string            // 入力に現れた順のテーブル
+	deps       map[string][]string // 依存先（子 → 親）
+	dependents map[string][]string // 被依存（親 → 子）
+	ddlContent map[string]string
+	inCycle    map[string]bool
+	expanded   map[string]bool
+
+	rows   []tuiRow
+	cursor int
+	offset int
+
+	filter    string
+	filtering bool
+
+	// DDL表示中のテーブル（空の場合は一覧表示）
+	viewing    string
+	viewOffset int
+
+	message string
+}
+
+// TUIモードを起動する
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("使い方: orderddl tui <schema.sql>")
+	}
+
+	content, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("ファイルを開けませんでした: %w", err)
+	}
+	src := string(content)
+
+	graph, _, tableOrder, err := ddl.Parse(strings.NewReader(src), ddl.Options{})
+	if err != nil {
+		return err
+	}
+	ddlContent, err := ddl.Split(strings.NewReader(src))
+	if err != nil {
+		return err
+	}
+
+	state := &tuiState{
+		tables:     tableOrder,
+		deps:       make(map[string][]string),
+		dependents: make(map[string][]string),
+		ddlContent: ddlContent,
+		inCycle:    make(map[string]bool),
+		expanded:   make(map[string]bool),
+	}
+	for parent, children := range graph {
+		for _, child := range children {
+			if !slices.Contains(state.deps[child], parent) {
+				state.deps[child] = append(state.deps[child], parent)
+			}
+			if !slices.Contains(state.dependents[parent], child) {
+				state.dependents[parent] = append(state.dependents[parent], child)
+			}
+		}
+	}
+	for _, tables := range state.deps {
+		sort.Strings(tables)
+	}
+	for _, tables := range state.dependents {
+		sort.Strings(tables)
+	}
+	for _, cycle := range ddl.Cycles(graph) {
+		for _, table := range cycle {
+			state.inCycle[table] = true
+		}
+	}
+	state.buildRows()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("TUIモードは端末から実行してください")
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("端末を初期化できませんでした: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// 代替スクリーンに切り替え、カーソルを隠す
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		state.render(width, height)
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		if !state.handleKey(string(buf[:n]), height) {
+			return nil
+		}
+	}
+}
+
+// 一覧に表示する行を組み立てる
+func (s *tuiState) buildRows() {
+	s.rows = s.rows[:0]
+	for _, table := range s.tables {
+		if s.filter != "" && !strings.Contains(strings.ToLower(table), strings.ToLower(s.filter)) {
+			continue
+		}
+		s.rows = append(s.rows, tuiRow{table: table})
+		if !s.expanded[table] {
+			continue
+		}
+		s.rows = append(s.rows, tuiRow{table: table, depth: 1, header: fmt.Sprintf("依存先 (%d)", len(s.deps[table]))})
+		for _, dep := range s.deps[table] {
+			s.rows = append(s.rows, tuiRow{table: dep, depth: 2})
+		}
+		s.rows = append(s.rows, tuiRow{table: table, depth: 1, header: fmt.Sprintf("被依存 (%d)", len(s.dependents[table]))})
+		for _, dependent := range s.dependents[table] {
+			s.rows = append(s.rows, tuiRow{table: dependent, depth: 2})
+		}
+	}
+	if s.cursor >= len(s.rows) {
+		s.cursor = max(len(s.rows)-1, 0)
+	}
+}
+
+// キー入力を処理する。終了する場合は false を返す
+func (s *tuiState) handleKey(key string, height int) bool {
+	s.message = ""
+
+	// 絞り込みの入力中
+	if s.filtering {
+		switch key {
+		case "\r", "\n":
+			s.filtering = false
+		case "\x1b":
+			s.filtering = false
+			s.filter = ""
+		case "\x7f", "\b":
+			if r := []rune(s.filter); len(r) > 0 {
+				s.filter = string(r[:len(r)-1])
+			}
+		default:
+			if key[0] >= 0x20 {
+				s.filter += key
+			}
+		}
+		s.cursor = 0
+		s.buildRows()
+		return true
+	}
+
+	// DDL表示中
+	if s.viewing != "" {
+		lines := strings.Count(s.ddlContent[s.viewing], "\n")
+		switch key {
+		case "q", "\x1b", "\x1b[D", "h":
+			s.viewing = ""
+		case "j", "\x1b[B":
+			s.viewOffset = min(s.viewOffset+1, max(lines-1, 0))
+		case "k", "\x1b[A":
+			s.viewOffset = max(s.viewOffset-1, 0)
+		case "\x1b[6~", " ":
+			s.viewOffset = min(s.viewOffset+height-2, max(lines-1, 0))
+		case "\x1b[5~":
+			s.viewOffset = max(s.viewOffset-height+2, 0)
+		case "\x03":
+			return false
+		}
+		return true
+	}
+
+	switch key {
+	case "q", "\x03":
+		return false
+	case "j", "\x1b[B":
+		s.cursor = min(s.cursor+1, max(len(s.rows)-1, 0))
+	case "k", "\x1b[A":
+		s.cursor = max(s.cursor-1, 0)
+	case "\x1b[6~", " ":
+		s.cursor = min(s.cursor+height-2, max(len(s.rows)-1, 0))
+	case "\x1b[5~":
+		s.cursor = max(s.cursor-height+2, 0)
+	case "g":
+		s.cursor = 0
+	case "G":
+		s.cursor = max(len(s.rows)-1, 0)
+	case "/":
+		s.filtering = true
+	case "\r", "\n", "l", "\x1b[C":
+		if len(s.rows) == 0 {
+			break
+		}
+		row := s.rows[s.cursor]
+		switch row.depth {
+		case 0:
+			s.expanded[row.table] = !s.expanded[row.table]
+			s.buildRows()
+		case 2:
+			s.jumpTo(row.table)
+		}
+	case "h", "\x1b[D":
+		if len(s.rows) == 0 {
+			break
+		}
+		// 依存関係の行からは親のテーブル行に戻る
+		for s.cursor > 0 && s.rows[s.cursor].depth > 0 {
+			s.cursor--
+		}
+		s.expanded[s.rows[s.cursor].table] = false
+		s.buildRows()
+	case "d":
+		if len(s.rows) == 0 {
+			break
+		}
+		table := s.rows[s.cursor].table
+		if _, exists := s.ddlContent[table]; !exists {
+			s.message = "DDLが見つかりません: " + table
+			break
+		}
+		s.viewing = table
+		s.viewOffset = 0
+	case "c":
+		s.nextCycle()
+	}
+	return true
+}
+
+// 指定したテーブルの行にカーソルを移動する
+func (s *tuiState) jumpTo(table string) {
+	if !slices.Contains(s.tables, table) {
+		s.message = "入力に定義されていないテーブルです: " + table
+		return
+	}
+	for i, row := range s.rows {
+		if row.depth == 0 && row.table == table {
+			s.cursor = i
+			return
+		}
+	}
+	// 絞り込みで隠れている場合は解除してから移動する
+	s.filter = ""
+	s.buildRows()
+	s.jumpTo(table)
+}
+
+// 次の循環依存しているテーブルにカーソルを移動する
+func (s *tuiState) nextCycle() {
+	for i := 1; i <= len(s.rows); i++ {
+		next := (s.cursor + i) % len(s.rows)
+		if s.rows[next].depth == 0 && s.inCycle[s.rows[next].table] {
+			s.cursor = next
+			return
+		}
+	}
+	s.message = "循環依存しているテーブルはありません"
+}
+
+// 画面を描画する
+func (s *tuiState) render(width, height int) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+
+	bodyHeight := max(height-2, 1)
+	if s.viewing != "" {
+		b.WriteString(truncate(ansiReverse+" "+s.viewing+" のDDL", width) + ansiReset + "\r\n")
+		lines := strings.Split(strings.TrimRight(s.ddlContent[s.viewing], "\n"), "\n")
+		for i := s.viewOffset; i < len(lines) && i < s.viewOffset+bodyHeight; i++ {
+			b.WriteString(truncate(lines[i], width) + "\r\n")
+		}
+		s.writeFooter(&b, "j/k: スクロール  q: 一覧に戻る", width, height)
+		os.Stdout.WriteString(b.String())
+		return
+	}
+
+	cycles := 0
+	for _, table := range s.tables {
+		if s.inCycle[table] {
+			cycles++
+		}
+	}
+	title := fmt.Sprintf(" orderddl — %d テーブル / 循環依存 %d テーブル", len(s.tables), cycles)
+	if s.filter != "" || s.filtering {
+		title += "  絞り込み: " + s.filter
+	}
+	b.WriteString(truncate(ansiReverse+title, width) + ansiReset + "\r\n")
+
+	// カーソルが見える位置までスクロールする
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+bodyHeight {
+		s.offset = s.cursor - bodyHeight + 1
+	}
+
+	for i := s.offset; i < len(s.rows) && i < s.offset+bodyHeight; i++ {
+		row := s.rows[i]
+		var line string
+		switch row.depth {
+		case 0:
+			marker := "▸ "
+			if s.expanded[row.table] {
+				marker = "▾ "
+			}
+			line = marker + row.table
+		case 1:
+			line = "    " + ansiDim + row.header + ansiReset
+		case 2:
+			line = "      " + row.table
+		}
+		if row.depth != 1 && s.inCycle[row.table] {
+			line = ansiRed + line + " ⟳" + ansiReset
+		}
+		if i == s.cursor {
+			line = ansiReverse + line + ansiReset
+		}
+		b.WriteString(truncate(line, width) + ansiReset + "\r\n")
+	}
+
+	help := "j/k: 移動  Enter: 展開/移動  d: DDL表示  c: 次の循環  /: 絞り込み  q: 終了"
+	if s.filtering {
+		help = "絞り込み: " + s.filter + "█  (Enter: 確定  Esc: 解除)"
+	}
+	s.writeFooter(&b, help, width, height)
+	os.Stdout.WriteString(b.String())
+}
+
+// 最下行にメッセージまたは操作説明を表示する
+func (s *tuiState) writeFooter(b *strings.Builder, help string, width, height int) {
+	fmt.Fprintf(b, "\x1b[%d;1H", height)
+	if s.message != "" {
+		help = s.message
+	}
+	b.WriteString(truncate(ansiDim+help, width) + ansiReset)
+}
+
+// 表示幅に収まるように文字列を切り詰める（エスケープシーケンスは幅に数えない）
+func truncate(s string, width int) string {
+	var b strings.Builder
+	visible := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			b.WriteRune(r)
+			if r >= '@' && r <= '~' && r != '[' {
+				inEscape = false
+			}
+			continue
+		case r == '\x1b':
+			inEscape = true
+			b.WriteRune(r)
+			continue
+		}
+
+		w := 1
+		if r >= 0x1100 {
+			w = 2
+		}
+		if visible+w > width {
+			break
+		}
+		visible += w
+		b.WriteRune(r)
+	}
+	return b.String()
+}
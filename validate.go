@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// コンテナのデータベースが接続を受け付けるまで待つ時間
+const validationStartTimeout = 2 * time.Minute
+
+// -validate-with で出力を読み込ませる一時的なデータベース
+type validationDB struct {
+	db       *sql.DB
+	postgres bool
+	// 接続先に作った一時データベース（MySQL で DSN を指定した場合）
+	scratch string
+	// コンテナの削除など、検証の後に行う片付け
+	cleanup func()
+}
+
+var (
+	// トランザクションを開始・終了する文（ROLLBACK TO SAVEPOINT は除く）
+	reTransactionControl = regexp.MustCompile(`(?is)^\s*(?:BEGIN|START\s+TRANSACTION|COMMIT|END|ABORT|ROLLBACK)\b`)
+	reRollbackTo         = regexp.MustCompile(`(?is)^\s*ROLLBACK(?:\s+(?:WORK|TRANSACTION))?\s+TO\b`)
+	// 接続先とは別のデータベースを作成・削除・選択する文（MySQL の SCHEMA は DATABASE と同じ）
+	reDatabaseStatement = regexp.MustCompile(`(?is)^\s*(?:(?:CREATE|DROP)\s+DATABASE|USE)\b`)
+	reMySQLSchema       = regexp.MustCompile(`(?is)^\s*(?:CREATE|DROP)\s+SCHEMA\b`)
+)
+
+// 並び替えた出力を一時的なデータベースで実行し、最初に失敗した文を報告する。
+// target は docker:<イメージ>（postgres:16, mysql:8 など）か接続先（postgres://... または mysql://...）
+func validateOutput(ctx context.Context, target, content string, opts ddl.Options) error {
+	statements, err := ddl.ExecutableStatements(content, opts)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(target, "docker:") {
+		if err := checkScratchStatements(statements, !strings.HasPrefix(target, "mysql://")); err != nil {
+			return err
+		}
+	}
+
+	v, err := openValidationDB(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer v.close()
+
+	if err := v.exec(ctx, statements); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s で %d 個の文をすべて実行できました\n", target, len(statements))
+	return nil
+}
+
+// 接続先を指定した場合は、検証のためのトランザクション（Postgres）や一時データベース（MySQL）の外で
+// 実行されてしまう文を断る（コンテナでは接続先のデータベースごと削除するため断らない）
+func checkScratchStatements(statements []ddl.Statement, postgres bool) error {
+	for _, stmt := range statements {
+		escapes := reDatabaseStatement.MatchString(stmt.Code) ||
+			reTransactionControl.MatchString(stmt.Code) && !reRollbackTo.MatchString(stmt.Code) ||
+			!postgres && reMySQLSchema.MatchString(stmt.Code)
+		if escapes {
+			return fmt.Errorf("出力の%d行目の文は検証用の一時データベースやトランザクションの外で実行されるため、接続先では検証できません（docker:<イメージ> を指定してください）: %s", executedLine(stmt), stmt.SQL())
+		}
+	}
+	return nil
+}
+
+func openValidationDB(ctx context.Context, target string) (*validationDB, error) {
+	image, isDocker := strings.CutPrefix(target, "docker:")
+	if !isDocker {
+		if !strings.Contains(target, "://") {
+			return nil, fmt.Errorf("-validate-with には docker:<イメージ> か postgres://... / mysql://... の接続先を指定してください: %s", target)
+		}
+		db, err := openDB("", target)
+		if err != nil {
+			return nil, err
+		}
+		v := &validationDB{db: db, postgres: !strings.HasPrefix(target, "mysql://")}
+		if err := db.PingContext(ctx); err != nil {
+			v.close()
+			return nil, fmt.Errorf("データベースに接続できませんでした: %w", err)
+		}
+		if !v.postgres {
+			v.scratch = fmt.Sprintf("orderddl_validate_%d", time.Now().UnixNano())
+		}
+		return v, nil
+	}
+	return startContainer(ctx, image)
+}
+
+// イメージの名前から判断したデータベースのコンテナを起動し、接続できるまで待つ
+func startContainer(ctx context.Context, image string) (*validationDB, error) {
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	var port, dsnFormat string
+	var env []string
+	postgres := false
+	switch {
+	case strings.Contains(name, "postgres") || strings.Contains(name, "postgis") || strings.Contains(name, "timescale"):
+		postgres = true
+		port, env = "5432", []string{"POSTGRES_PASSWORD=orderddl"}
+		dsnFormat = "postgres://postgres:orderddl@%s/postgres?sslmode=disable"
+	case strings.Contains(name, "mysql") || strings.Contains(name, "mariadb"):
+		port, env = "3306", []string{"MYSQL_ROOT_PASSWORD=orderddl", "MARIADB_ROOT_PASSWORD=orderddl", "MYSQL_DATABASE=orderddl"}
+		dsnFormat = "mysql://root:orderddl@%s/orderddl"
+	default:
+		return nil, fmt.Errorf("イメージからデータベースの種類を判断できません（postgres, mysql, mariadb のイメージを指定してください）: %s", image)
+	}
+
+	args := []string{"run", "-d", "--rm", "-p", "127.0.0.1::" + port}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+	out, err := exec.CommandContext(ctx, "docker", append(args, image)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("コンテナを起動できませんでした: %w", commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	fmt.Printf("🚀 検証用のコンテナを起動しました: %s（%s）\n", image, id[:min(12, len(id))])
+	cleanup := func() {
+		exec.Command("docker", "rm", "-f", id).Run()
+	}
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, port+"/tcp").Output()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("コンテナのポートを取得できませんでした: %w", commandError(err))
+	}
+	address := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	db, err := openDB("", fmt.Sprintf(dsnFormat, address))
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	v := &validationDB{db: db, postgres: postgres, cleanup: cleanup}
+
+	// データベースの初期化が終わるまで接続を試す
+	waitCtx, cancel := context.WithTimeout(ctx, validationStartTimeout)
+	defer cancel()
+	for {
+		err := db.PingContext(waitCtx)
+		if err == nil {
+			return v, nil
+		}
+		select {
+		case <-waitCtx.Done():
+			v.close()
+			return nil, fmt.Errorf("コンテナのデータベースに接続できませんでした: %w", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// exec.ExitError の場合は標準エラー出力の内容をエラーに含める
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// 文を順に実行する。Postgres ではトランザクションの中で実行して最後に取り消し、
+// MySQL で接続先を指定した場合は一時データベースを作って実行し、最後に削除する
+func (v *validationDB) exec(ctx context.Context, statements []ddl.Statement) error {
+	conn, err := v.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("データベースに接続できませんでした: %w", err)
+	}
+	defer conn.Close()
+
+	if v.postgres {
+		if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+			return fmt.Errorf("トランザクションを開始できませんでした: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "ROLLBACK")
+	}
+	if v.scratch != "" {
+		if _, err := conn.ExecContext(ctx, "CREATE DATABASE "+v.scratch); err != nil {
+			return fmt.Errorf("検証用のデータベースを作成できませんでした: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "DROP DATABASE IF EXISTS "+v.scratch)
+		if _, err := conn.ExecContext(ctx, "USE "+v.scratch); err != nil {
+			return fmt.Errorf("検証用のデータベースを選択できませんでした: %w", err)
+		}
+	}
+
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt.SQL()); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Printf("❌ 出力の%d行目の文を実行できませんでした:\n%s\n", executedLine(stmt), stmt.SQL())
+			return fmt.Errorf("出力を読み込めませんでした: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *validationDB) close() {
+	v.db.Close()
+	if v.cleanup != nil {
+		v.cleanup()
+	}
+}
+
+// 前置きの空白とコメントを除いた、文の開始行
+func executedLine(stmt ddl.Statement) int {
+	leading := stmt.Code[:len(stmt.Code)-len(strings.TrimLeft(stmt.Code, " \t\r\n"))]
+	return stmt.Line + strings.Count(leading, "\n")
+}
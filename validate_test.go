@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+func TestValidateOutputScratchStatements(t *testing.T) {
+	const refused = "接続先では検証できません"
+	drivers := []struct {
+		name string
+		// 接続できない接続先（断らない文は接続しようとして失敗する）
+		target string
+		// 指定した場合は実際のデータベースで検証する
+		env string
+	}{
+		{name: "postgres", target: "postgres://orderddl@127.0.0.1:1/orderddl?sslmode=disable&connect_timeout=1", env: "ORDERDDL_TEST_POSTGRES_DSN"},
+		{name: "mysql", target: "mysql://orderddl@127.0.0.1:1/orderddl?timeout=1s", env: "ORDERDDL_TEST_MYSQL_DSN"},
+	}
+	tests := []struct {
+		name    string
+		content string
+		// 断るかどうか（driver ごと）
+		refused map[string]bool
+	}{
+		{name: "テーブルだけ", content: "CREATE TABLE a (id int PRIMARY KEY);\n", refused: map[string]bool{}},
+		{name: "COMMIT", content: "CREATE TABLE a (id int PRIMARY KEY);\nCOMMIT;\n", refused: map[string]bool{"postgres": true, "mysql": true}},
+		{name: "START TRANSACTION", content: "START TRANSACTION;\nCREATE TABLE a (id int PRIMARY KEY);\n", refused: map[string]bool{"postgres": true, "mysql": true}},
+		{name: "ROLLBACK TO SAVEPOINT", content: "CREATE TABLE a (id int PRIMARY KEY);\nROLLBACK TO SAVEPOINT s;\n", refused: map[string]bool{}},
+		{name: "CREATE DATABASE と USE", content: "CREATE DATABASE app;\nUSE app;\nCREATE TABLE a (id int PRIMARY KEY);\n", refused: map[string]bool{"postgres": true, "mysql": true}},
+		{name: "CREATE SCHEMA", content: "CREATE SCHEMA app;\nCREATE TABLE app.a (id int PRIMARY KEY);\n", refused: map[string]bool{"mysql": true}},
+	}
+	for _, driver := range drivers {
+		for _, tt := range tests {
+			t.Run(driver.name+"/"+tt.name, func(t *testing.T) {
+				err := validateOutput(context.Background(), driver.target, tt.content, ddl.Options{})
+				if got := err != nil && strings.Contains(err.Error(), refused); got != tt.refused[driver.name] {
+					t.Errorf("err = %v, 断る = %v", err, tt.refused[driver.name])
+				}
+			})
+		}
+		t.Run(driver.name+"/接続先", func(t *testing.T) {
+			target := os.Getenv(driver.env)
+			if target == "" {
+				t.Skipf("%s を指定した場合だけ実行します", driver.env)
+			}
+			if err := validateOutput(context.Background(), target, "CREATE TABLE orderddl_a (id int PRIMARY KEY);\nCREATE TABLE orderddl_b (a_id int REFERENCES orderddl_a(id));\n", ddl.Options{}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// -verify で、並び替えた出力に入力の文がちょうど1つずつ含まれていることを確かめ、
+// 失われた文と重複した文（または入力にない文）をすべて表示する
+func verifyOutput(ctx context.Context, src, content string) error {
+	diff, total, err := ddl.VerifyStatements(ctx, src, content, fixedConstraints, splitOptions())
+	if err != nil {
+		return err
+	}
+	if diff.Empty() {
+		fmt.Printf("✅ 出力には入力の %d 個の文がちょうど1つずつ含まれています\n", total)
+		return nil
+	}
+	for _, stmt := range diff.Missing {
+		fmt.Printf("❌ 入力の%d行目の文が出力にありません:\n%s\n", executedLine(stmt), stmt.SQL())
+	}
+	for _, stmt := range diff.Extra {
+		fmt.Printf("❌ 出力の%d行目の文は入力より多く現れます:\n%s\n", executedLine(stmt), stmt.SQL())
+	}
+	return errors.New("並び替えた出力の文が入力と一致しません（失われた文か重複した文があります）")
+}
@@ -0,0 +1,27 @@
+package main
+
+import "runtime/debug"
+
+// リリース時に -ldflags "-X main.version=..." で設定するバージョン
+var version = ""
+
+// orderddl のバージョン（設定されていない場合はビルド情報のモジュールのバージョンとリビジョン）
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	v := info.Main.Version
+	if v == "" {
+		v = "(devel)"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			v += " " + setting.Value[:12]
+		}
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+//go:build js && wasm
+
+// orderddl の並び替え処理を WebAssembly としてブラウザに公開する
+//
+// ビルド方法:
+//
+//	GOOS=js GOARCH=wasm go build -o wasm/orderddl.wasm ./wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" wasm/
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/ba58ajbse/orderddl/ddl"
+)
+
+// orderddl.order(sql, options) の実装。{sql, error} を返す
+//
+// options は省略でき、serve の POST /order と同じ名前のプロパティ
+// （soft_constraints・dialect・search_path・views・temporary）を指定する
+func order(this js.Value, args []js.Value) any {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return map[string]any{"error": "SQLを文字列で指定してください"}
+	}
+	var options js.Value
+	if len(args) > 1 {
+		options = args[1]
+	}
+	opts, err := orderOptions(options)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+
+	ordered, err := ddl.Order(args[0].String(), opts)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"sql": ordered}
+}
+
+// order のオプション（serve の requestOptions と同じ）
+type requestOptions struct {
+	SoftConstraints string
+	Dialect         string
+	SearchPath      []string
+	Views           string
+	Temporary       string
+}
+
+// JS のオプションのオブジェクトを ddl.Options にする（undefined と null は既定値）
+func orderOptions(v js.Value) (ddl.Options, error) {
+	if v.IsUndefined() || v.IsNull() {
+		return ddl.Options{}, nil
+	}
+	if v.Type() != js.TypeObject {
+		return ddl.Options{}, fmt.Errorf("オプションはオブジェクトで指定してください")
+	}
+	var o requestOptions
+	for _, p := range []struct {
+		name  string
+		value *string
+	}{
+		{"soft_constraints", &o.SoftConstraints},
+		{"dialect", &o.Dialect},
+		{"views", &o.Views},
+		{"temporary", &o.Temporary},
+	} {
+		value, err := stringProperty(v, p.name)
+		if err != nil {
+			return ddl.Options{}, err
+		}
+		*p.value = value
+	}
+	searchPath, err := stringsProperty(v, "search_path")
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	o.SearchPath = searchPath
+	return o.options()
+}
+
+func (o requestOptions) options() (ddl.Options, error) {
+	softConstraints, err := ddl.ParseSoftConstraintPolicy(o.SoftConstraints)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	viewPolicy, err := ddl.ParseViewPolicy(o.Views)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	temporaryPolicy, err := ddl.ParseTemporaryPolicy(o.Temporary)
+	if err != nil {
+		return ddl.Options{}, err
+	}
+	var dialect ddl.Dialect
+	if o.Dialect != "" {
+		if dialect, err = ddl.LookupDialect(o.Dialect); err != nil {
+			return ddl.Options{}, err
+		}
+	}
+	return ddl.Options{SoftConstraints: softConstraints, Dialect: dialect, SearchPath: o.SearchPath, Views: viewPolicy, Temporary: temporaryPolicy}, nil
+}
+
+// 文字列のプロパティの値（指定のない場合は空）
+func stringProperty(v js.Value, name string) (string, error) {
+	value := v.Get(name)
+	if value.IsUndefined() || value.IsNull() {
+		return "", nil
+	}
+	if value.Type() != js.TypeString {
+		return "", fmt.Errorf("%s は文字列で指定してください", name)
+	}
+	return value.String(), nil
+}
+
+// 文字列の配列のプロパティの値（指定のない場合は nil）
+func stringsProperty(v js.Value, name string) ([]string, error) {
+	value := v.Get(name)
+	if value.IsUndefined() || value.IsNull() {
+		return nil, nil
+	}
+	if !value.InstanceOf(js.Global().Get("Array")) {
+		return nil, fmt.Errorf("%s は文字列の配列で指定してください", name)
+	}
+	values := make([]string, value.Length())
+	for i := range values {
+		if item := value.Index(i); item.Type() == js.TypeString {
+			values[i] = item.String()
+		} else {
+			return nil, fmt.Errorf("%s は文字列の配列で指定してください", name)
+		}
+	}
+	return values, nil
+}
+
+func main() {
+	js.Global().Set("orderddl", js.ValueOf(map[string]any{
+		"order": js.FuncOf(order),
+	}))
+
+	// JSから呼び出されるまで終了しない
+	select {}
+}
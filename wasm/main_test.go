@@ -0,0 +1,41 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	src := "CREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\nCREATE TABLE a (\n  id int\n);\n"
+	got := order(js.Undefined(), []js.Value{js.ValueOf(src)}).(map[string]any)
+	if want := "CREATE TABLE a (\n  id int\n);\nCREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n"; got["sql"] != want {
+		t.Errorf("sql = %q, want %q（error: %v）", got["sql"], want, got["error"])
+	}
+
+	if got := order(js.Undefined(), []js.Value{js.ValueOf(1)}).(map[string]any); got["error"] == nil {
+		t.Errorf("文字列でない引数でエラーになりませんでした: %v", got)
+	}
+}
+
+func TestOrderOptions(t *testing.T) {
+	// search_path の public で修飾なしの参照を public.a に解決する
+	src := "CREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\nCREATE TABLE public.a (\n  id int\n);\n"
+	options := js.ValueOf(map[string]any{"dialect": "postgres", "search_path": []any{"public"}})
+	got := order(js.Undefined(), []js.Value{js.ValueOf(src), options}).(map[string]any)
+	if want := "CREATE TABLE public.a (\n  id int\n);\nCREATE TABLE b (\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\n"; got["sql"] != want {
+		t.Errorf("sql = %q, want %q（error: %v）", got["sql"], want, got["error"])
+	}
+
+	for _, options := range []any{
+		"postgres",
+		map[string]any{"dialect": "unknown"},
+		map[string]any{"views": 1},
+		map[string]any{"search_path": "public"},
+	} {
+		if got := order(js.Undefined(), []js.Value{js.ValueOf(src), js.ValueOf(options)}).(map[string]any); got["error"] == nil {
+			t.Errorf("オプション %v でエラーになりませんでした: %v", options, got)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// orderddl の WebAssembly 版を読み込むラッパー
+// 事前に wasm_exec.js を読み込んでおくこと
+export async function loadOrderDDL(wasmURL = "orderddl.wasm") {
+  const go = new Go();
+  const { instance } = await WebAssembly.instantiateStreaming(fetch(wasmURL), go.importObject);
+  go.run(instance);
+
+  return {
+    // DDLを外部キーの依存関係の順に並び替える
+    // options は serve の POST /order と同じ（例: { dialect: "postgres", search_path: ["public"] }）
+    order(sql, options) {
+      const result = globalThis.orderddl.order(sql, options);
+      if (result.error) {
+        throw new Error(result.error);
+      }
+      return result.sql;
+    },
+  };
+}
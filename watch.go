@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 保存時に連続して発生するイベントをまとめるための待ち時間
+const watchDebounce = 200 * time.Millisecond
+
+// 入力ファイルと、設定ファイルなど実行のたびに読み込むファイルの変更を監視し、
+// 変更のたびに並び替えを再実行する（ctx が取り消されるまで）
+func watchSQL(ctx context.Context, input, output string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("ファイルの監視を開始できませんでした: %w", err)
+	}
+	defer watcher.Close()
+
+	// エディタはリネームで保存することが多いため、ファイルではなくディレクトリを監視する
+	targets := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range append([]string{input}, optionFiles()...) {
+		path = filepath.Clean(path)
+		targets[path] = true
+		if dir := filepath.Dir(path); !dirs[dir] {
+			dirs[dir] = true
+			if err := watcher.Add(dir); err != nil {
+				return fmt.Errorf("ファイルの監視を開始できませんでした: %w", err)
+			}
+		}
+	}
+
+	run := func() {
+		if err := processSQL(ctx, input, output); err != nil {
+			fmt.Println("❌ エラー:", err)
+		}
+	}
+	run()
+	fmt.Println("👀 変更を監視しています:", input)
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("👋 監視を終了しました")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !targets[filepath.Clean(event.Name)] {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				timer = time.After(watchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println("❌ 監視エラー:", err)
+		case <-timer:
+			timer = nil
+			run()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 出力ファイルの内容が want を含むまで待つ
+func waitForOutput(t *testing.T, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, _ := os.ReadFile(path)
+		if strings.Contains(string(content), want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("出力に %q が含まれません:\n%s", want, content)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatchSQL(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.sql")
+	output := filepath.Join(dir, "output.sql")
+	if err := os.WriteFile(input, []byte("CREATE TABLE a (\n  id int\n);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go watchSQL(ctx, input, output)
+	waitForOutput(t, output, "CREATE TABLE a")
+
+	// 保存し直すと並び替えをやり直す
+	src := "CREATE TABLE b (\n  a_id int,\n  FOREIGN KEY (a_id) REFERENCES a(id)\n);\nCREATE TABLE a (\n  id int\n);\n"
+	if err := os.WriteFile(input, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, output, "CREATE TABLE a (\n  id int\n);\nCREATE TABLE b")
+}
+
+func TestWatchSQLOptionFiles(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.sql")
+	output := filepath.Join(dir, "output.sql")
+	mapping := filepath.Join(t.TempDir(), "rename.txt")
+	if err := os.WriteFile(input, []byte("CREATE TABLE a (\n  id int\n);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mapping, []byte("a=b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := *renameFile
+	*renameFile = mapping
+	t.Cleanup(func() { *renameFile = saved })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go watchSQL(ctx, input, output)
+	waitForOutput(t, output, "CREATE TABLE b")
+
+	// 入力とは別のディレクトリにある -rename-file の変更でも並び替えをやり直す
+	if err := os.WriteFile(mapping, []byte("a=c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, output, "CREATE TABLE c")
+}